@@ -3,12 +3,19 @@ package main
 import (
 	"fmt"
 	"os"
+	"hype-script/internal/diag"
 	"hype-script/internal/mainhype"
 )
 
 func main() {
 	hype := mainhype.NewHype()
 	err := hype.Start()
+	if _, ok := err.(*diag.Diagnostics); ok { // Already shown as they came up
+		os.Exit(1)
+	}
+	if code, ok := err.(mainhype.ExitCode); ok {
+		os.Exit(int(code))
+	}
 	if err != nil {
 		fmt.Println("Unable to get Hype with it: ", err)
 		os.Exit(1)
@@ -2,4 +2,12 @@ module hype-script
 
 go 1.23.2
 
-require github.com/traefik/yaegi v0.16.1 // indirect
+require (
+	github.com/peterh/liner v1.2.2
+	github.com/traefik/yaegi v0.16.1
+)
+
+require (
+	github.com/mattn/go-runewidth v0.0.3 // indirect
+	golang.org/x/sys v0.0.0-20211117180635-dee7805ff2e1 // indirect
+)
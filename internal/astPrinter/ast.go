@@ -1,75 +1,517 @@
-package typesPrinter
-
-// import (
-// 	"fmt"
-// 	"hype-script/internal/types"
-// 	"hype-script/internal/utils"
-// )
-
-// type typesPrinter struct{}
-
-// func NewtypesPrinter() types.Visitor {
-// 	return &typesPrinter{}
-// }
-
-// func (a *typesPrinter) VisitBinaryExpr(expr *types.BinaryExpr) (any, error) {
-// 	return utils.Parenthesize(a, expr.Operator.Lexeme, expr.Left, expr.Right)
-// }
-
-// func (a *typesPrinter) VisitUnaryExpr(expr *types.UnaryExpr) (any, error) {
-// 	return utils.Parenthesize(a, expr.Operator.Lexeme, expr.Right)
-// }
-
-// func (a *typesPrinter) VisitGroupingExpr(expr *types.GroupingExpr) (any, error) {
-// 	return utils.Parenthesize(a, "group", expr.Expr)
-// }
-
-// func (a *typesPrinter) VisitLiteralExpr(expr *types.LiteralExpr) (any, error) {
-// 	if expr.Val.Val == nil {
-// 		return "nil", nil
-// 	}
-// 	return expr.Val.String(), nil
-// }
-
-// func (a *typesPrinter) VisitVarExpr(expr *types.VarExpr) (any, error) {
-// 	return utils.Parenthesize(a, "var")
-// }
-
-// func (a *typesPrinter) VisitAssignExpr(expr *types.AssignExpr) (any, error) {
-// 	return utils.Parenthesize(a, "assign", expr.Val)
-// }
-
-// func (a *typesPrinter) VisitLogicalExpr(expr *types.LogicalExpr) (any, error) {
-// 	return utils.Parenthesize(a, "logical", expr.Left, expr.Right)
-// }
-
-// func (a *typesPrinter) VisitWhileExpr(expr *types.WhileExpr) (any, error) {
-// 	return utils.Parenthesize(a, "while")
-// }
-
-// func (a *typesPrinter) VisitCallExpr(expr *types.CallExpr) (any, error) {
-// 	return utils.Parenthesize(a, "call")
-// }
-
-// func (a *typesPrinter) VisitFunExpr(expr *types.FunExpr) (any, error) {
-// 	return utils.Parenthesize(a, "func")
-// }
-
-// func (a *typesPrinter) VisitReturnExpr(expr *types.ReturnExpr) (any, error) {
-// 	return utils.Parenthesize(a, "return")
-// }
-
-// func (a *typesPrinter) VisitPostfixExpr(expr *types.PostfixExpr) (any, error) {
-// 	return utils.Parenthesize(a, "postfix")
-// }
-
-// func (a *typesPrinter) VisitGlistExpr(expr *types.GlistExpr) (any, error) {
-// 	return utils.Parenthesize(a, "glist")
-// }
-
-// func (a *typesPrinter) Print(expr types.Expr) string {
-// 	val, err := expr.Accept(a)
-// 	if err != nil {
-// 	}
-// 	return fmt.Sprintf("%v", val)
-// }
\ No newline at end of file
+package astPrinter
+
+import (
+	"fmt"
+	"hype-script/internal/token"
+	"hype-script/internal/types"
+	"strconv"
+	"strings"
+)
+
+// Prints the AST as S-expressions, one line per top level statement
+// It shows what the parser made of the source, desugaring included, so for loops come out as blocks around a while
+
+type AstPrinter struct {
+	stmt string // What the last statement visited printed as, statement visits can only return an error
+}
+
+func NewAstPrinter() *AstPrinter {
+	return &AstPrinter{}
+}
+
+func (a *AstPrinter) PrintStmts(stmts []types.Stmt) (string, error) {
+	var builder strings.Builder
+	for _, stmt := range stmts {
+		line, err := a.printStmt(stmt)
+		if err != nil {
+			return "", err
+		}
+		builder.WriteString(line)
+		builder.WriteString("\n")
+	}
+	return builder.String(), nil
+}
+
+func (a *AstPrinter) Print(expr types.Expr) (string, error) {
+	if expr == nil {
+		return "nil", nil
+	}
+	val, err := expr.Accept(a)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%v", val), nil
+}
+
+func (a *AstPrinter) printStmt(stmt types.Stmt) (string, error) {
+	if stmt == nil {
+		return "nil", nil
+	}
+	if err := stmt.Accept(a); err != nil {
+		return "", err
+	}
+	return a.stmt, nil
+}
+
+// (name part part ...), parts are already printed
+func parenthesize(name string, parts ...string) string {
+	if len(parts) == 0 {
+		return "(" + name + ")"
+	}
+	return "(" + name + " " + strings.Join(parts, " ") + ")"
+}
+
+func (a *AstPrinter) exprs(exprs []types.Expr) ([]string, error) {
+	parts := make([]string, len(exprs))
+	for i, expr := range exprs {
+		part, err := a.Print(expr)
+		if err != nil {
+			return nil, err
+		}
+		parts[i] = part
+	}
+	return parts, nil
+}
+
+func (a *AstPrinter) stmts(stmts []types.Stmt) ([]string, error) {
+	parts := make([]string, len(stmts))
+	for i, stmt := range stmts {
+		part, err := a.printStmt(stmt)
+		if err != nil {
+			return nil, err
+		}
+		parts[i] = part
+	}
+	return parts, nil
+}
+
+// Prints exprs and wraps them, for the many nodes that are a name and some exprs
+func (a *AstPrinter) wrap(name string, exprs ...types.Expr) (string, error) {
+	parts, err := a.exprs(exprs)
+	if err != nil {
+		return "", err
+	}
+	return parenthesize(name, parts...), nil
+}
+
+func (a *AstPrinter) VisitBinaryExpr(expr *types.BinaryExpr) (any, error) {
+	return a.wrap(expr.Operator.Lexeme, expr.Left, expr.Right)
+}
+
+func (a *AstPrinter) VisitLiteralExpr(expr *types.LiteralExpr) (any, error) {
+	if expr.Val == nil || expr.Val.Val == nil {
+		return "newt", nil
+	}
+	if s, ok := expr.Val.Val.(string); ok {
+		return strconv.Quote(s), nil
+	}
+	return expr.Val.String(), nil
+}
+
+func (a *AstPrinter) VisitUnaryExpr(expr *types.UnaryExpr) (any, error) {
+	return a.wrap(expr.Operator.Lexeme, expr.Right)
+}
+
+func (a *AstPrinter) VisitGroupingExpr(expr *types.GroupingExpr) (any, error) {
+	return a.wrap("group", expr.Expr)
+}
+
+func (a *AstPrinter) VisitVarExpr(expr *types.VarExpr) (any, error) {
+	return expr.Name.Lexeme, nil
+}
+
+func (a *AstPrinter) VisitAssignExpr(expr *types.AssignExpr) (any, error) {
+	val, err := a.Print(expr.Val)
+	if err != nil {
+		return nil, err
+	}
+	return parenthesize("=", expr.Name.Lexeme, val), nil
+}
+
+func (a *AstPrinter) VisitLogicalExpr(expr *types.LogicalExpr) (any, error) {
+	return a.wrap(expr.Operator.Lexeme, expr.Left, expr.Right)
+}
+
+func (a *AstPrinter) VisitWhileExpr(expr *types.WhileExpr) (any, error) {
+	cond, err := a.Print(expr.Condition)
+	if err != nil {
+		return nil, err
+	}
+	body, err := a.printStmt(expr.Body)
+	if err != nil {
+		return nil, err
+	}
+	return parenthesize("while", cond, body), nil
+}
+
+// Named args print as (= name val) after the positional ones
+func (a *AstPrinter) VisitCallExpr(expr *types.CallExpr) (any, error) {
+	parts, err := a.exprs(append([]types.Expr{expr.Callee}, expr.Args...))
+	if err != nil {
+		return nil, err
+	}
+	for _, named := range expr.Named {
+		val, err := a.Print(named.Val)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, parenthesize("=", named.Name.Lexeme, val))
+	}
+	return parenthesize("call", parts...), nil
+}
+
+func (a *AstPrinter) VisitFunExpr(expr *types.FunExpr) (any, error) {
+	params := make([]string, len(expr.Params))
+	for i, param := range expr.Params {
+		params[i] = param.Lexeme
+	}
+	body, err := a.stmts(expr.Body)
+	if err != nil {
+		return nil, err
+	}
+	return parenthesize("fun", append([]string{expr.Name.Lexeme, "(" + strings.Join(params, " ") + ")"}, body...)...), nil
+}
+
+func (a *AstPrinter) VisitReturnExpr(expr *types.ReturnExpr) (any, error) {
+	if expr.Val == nil {
+		return "(return)", nil
+	}
+	return a.wrap("return", expr.Val)
+}
+
+func (a *AstPrinter) VisitPostfixExpr(expr *types.PostfixExpr) (any, error) {
+	return a.wrap("postfix"+expr.Operator.Lexeme, expr.Val)
+}
+
+func (a *AstPrinter) VisitGlistExpr(expr *types.GlistExpr) (any, error) {
+	return a.wrap("glist", expr.Data...)
+}
+
+func (a *AstPrinter) VisitIndexExpr(expr *types.IndexExpr) (any, error) {
+	return a.wrap("index", expr.Expr, expr.Index)
+}
+
+func (a *AstPrinter) VisitImportExpr(expr *types.ImportExpr) (any, error) {
+	return a.wrap("import", expr.Val)
+}
+
+func (a *AstPrinter) VisitAccessExpr(expr *types.AccessExpr) (any, error) {
+	return a.wrap(".", expr.Exprs...)
+}
+
+// Each entry is (key val)
+func (a *AstPrinter) VisitGmapExpr(expr *types.GmapExpr) (any, error) {
+	entries := make([]string, len(expr.Keys))
+	for i := range expr.Keys {
+		parts, err := a.exprs([]types.Expr{expr.Keys[i], expr.Vals[i]})
+		if err != nil {
+			return nil, err
+		}
+		entries[i] = "(" + strings.Join(parts, " ") + ")"
+	}
+	return parenthesize("gmap", entries...), nil
+}
+
+func (a *AstPrinter) VisitIndexAssignExpr(expr *types.IndexAssignExpr) (any, error) {
+	target, err := a.wrap("index", expr.Expr, expr.Index)
+	if err != nil {
+		return nil, err
+	}
+	val, err := a.Print(expr.Val)
+	if err != nil {
+		return nil, err
+	}
+	return parenthesize("=", target, val), nil
+}
+
+// A bound that was left out, s[:2], prints as nil
+func (a *AstPrinter) VisitSliceExpr(expr *types.SliceExpr) (any, error) {
+	return a.wrap("slice", expr.Expr, expr.Start, expr.End)
+}
+
+func (a *AstPrinter) VisitInterpolationExpr(expr *types.InterpolationExpr) (any, error) {
+	return a.wrap("interpolate", expr.Parts...)
+}
+
+func (a *AstPrinter) VisitTupleExpr(expr *types.TupleExpr) (any, error) {
+	return a.wrap("tuple", expr.Items...)
+}
+
+func (a *AstPrinter) VisitSpreadExpr(expr *types.SpreadExpr) (any, error) {
+	return a.wrap("...", expr.Expr)
+}
+
+func (a *AstPrinter) VisitExprStmt(stmt *types.Expression) error {
+	out, err := a.Print(stmt.Expr)
+	a.stmt = out
+	return err
+}
+
+func (a *AstPrinter) VisitPrintStmt(stmt *types.Print) error {
+	out, err := a.wrap("print", stmt.Expr)
+	a.stmt = out
+	return err
+}
+
+// var ^x = 1 declares a global, it prints as (var ^x 1)
+func (a *AstPrinter) VisitVarStmt(stmt *types.Var) error {
+	name := stmt.Name.Lexeme
+	if stmt.Global {
+		name = "^" + name
+	}
+	parts := []string{name}
+	if stmt.Initializer != nil {
+		init, err := a.Print(stmt.Initializer)
+		if err != nil {
+			return err
+		}
+		parts = append(parts, init)
+	}
+	a.stmt = parenthesize("var", parts...)
+	return nil
+}
+
+func (a *AstPrinter) VisitBlockStmt(stmt *types.Block) error {
+	parts, err := a.stmts(stmt.Statements)
+	if err != nil {
+		return err
+	}
+	a.stmt = parenthesize("block", parts...)
+	return nil
+}
+
+func (a *AstPrinter) VisitIfStmt(stmt *types.If) error {
+	cond, err := a.Print(stmt.Condition)
+	if err != nil {
+		return err
+	}
+	branches := []types.Stmt{stmt.Then}
+	if stmt.Final != nil {
+		branches = append(branches, stmt.Final)
+	}
+	parts, err := a.stmts(branches)
+	if err != nil {
+		return err
+	}
+	a.stmt = parenthesize("if", append([]string{cond}, parts...)...)
+	return nil
+}
+
+// Loops print as (while cond body increment), a labelled one as (label name (while ...))
+func (a *AstPrinter) VisitWhileStmt(stmt *types.While) error {
+	cond, err := a.Print(stmt.Condition)
+	if err != nil {
+		return err
+	}
+	body, err := a.printStmt(stmt.Body)
+	if err != nil {
+		return err
+	}
+	parts := []string{cond, body}
+	if stmt.Increment != nil {
+		inc, err := a.Print(stmt.Increment)
+		if err != nil {
+			return err
+		}
+		parts = append(parts, inc)
+	}
+	a.stmt = labelled(stmt.Label.Lexeme, parenthesize("while", parts...))
+	return nil
+}
+
+func labelled(label, loop string) string {
+	if label == "" {
+		return loop
+	}
+	return parenthesize("label", label, loop)
+}
+
+// (fun name (params) body...), a default prints as (= param default) and rest as ...rest
+func (a *AstPrinter) VisitFunStmt(stmt *types.Fun) error {
+	params := make([]string, 0, len(stmt.Params)+1)
+	for i, param := range stmt.Params {
+		if i < len(stmt.Defaults) && stmt.Defaults[i] != nil {
+			def, err := a.Print(stmt.Defaults[i])
+			if err != nil {
+				return err
+			}
+			params = append(params, parenthesize("=", param.Lexeme, def))
+			continue
+		}
+		params = append(params, param.Lexeme)
+	}
+	if stmt.Rest.Lexeme != "" {
+		params = append(params, "..."+stmt.Rest.Lexeme)
+	}
+	body, err := a.stmts(stmt.Body)
+	if err != nil {
+		return err
+	}
+	a.stmt = parenthesize("fun", append([]string{stmt.Name.Lexeme, "(" + strings.Join(params, " ") + ")"}, body...)...)
+	return nil
+}
+
+func (a *AstPrinter) VisitReturnStmt(stmt *types.Return) error {
+	if stmt.Val == nil {
+		a.stmt = "(return)"
+		return nil
+	}
+	out, err := a.wrap("return", stmt.Val)
+	a.stmt = out
+	return err
+}
+
+// Each item is its path, or (alias path)
+func (a *AstPrinter) VisitImportStmt(stmt *types.Import) error {
+	parts := []string{stmt.Lang.Lexeme}
+	for _, item := range stmt.Imports {
+		path := strconv.Quote(item.Val.Literal.String())
+		if item.Alias.Type == token.IDENTIFIER {
+			path = "(" + item.Alias.Lexeme + " " + path + ")"
+		}
+		parts = append(parts, path)
+	}
+	a.stmt = parenthesize("import", parts...)
+	return nil
+}
+
+func (a *AstPrinter) VisitAccessStmt(stmt *types.Access) error {
+	out, err := a.Print(stmt.Expr)
+	a.stmt = out
+	return err
+}
+
+func (a *AstPrinter) VisitForInStmt(stmt *types.ForIn) error {
+	iterable, err := a.Print(stmt.Iterable)
+	if err != nil {
+		return err
+	}
+	body, err := a.printStmt(stmt.Body)
+	if err != nil {
+		return err
+	}
+	a.stmt = labelled(stmt.Label.Lexeme, parenthesize("for", stmt.Name.Lexeme, iterable, body))
+	return nil
+}
+
+func (a *AstPrinter) VisitBreakStmt(stmt *types.Break) error {
+	a.stmt = jump("break", stmt.Label.Lexeme)
+	return nil
+}
+
+func (a *AstPrinter) VisitContinueStmt(stmt *types.Continue) error {
+	a.stmt = jump("continue", stmt.Label.Lexeme)
+	return nil
+}
+
+func jump(keyword, label string) string {
+	if label == "" {
+		return parenthesize(keyword)
+	}
+	return parenthesize(keyword, label)
+}
+
+// (try attempt (woops name body)), the name is left out when the error isn't bound
+func (a *AstPrinter) VisitTryStmt(stmt *types.Try) error {
+	attempt, err := a.printStmt(stmt.Attempt)
+	if err != nil {
+		return err
+	}
+	parts := []string{attempt}
+	if stmt.Woops != nil {
+		woops, err := a.printStmt(stmt.Woops)
+		if err != nil {
+			return err
+		}
+		if stmt.Name.Lexeme != "" {
+			woops = parenthesize("woops", stmt.Name.Lexeme, woops)
+		} else {
+			woops = parenthesize("woops", woops)
+		}
+		parts = append(parts, woops)
+	}
+	a.stmt = parenthesize("try", parts...)
+	return nil
+}
+
+func (a *AstPrinter) VisitWertStmt(stmt *types.Wert) error {
+	out, err := a.wrap("wert", stmt.Val)
+	a.stmt = out
+	return err
+}
+
+// (:= (targets) (vals)) or the same with =
+func (a *AstPrinter) VisitDestructureStmt(stmt *types.Destructure) error {
+	targets, err := a.exprs(stmt.Targets)
+	if err != nil {
+		return err
+	}
+	vals, err := a.exprs(stmt.Vals)
+	if err != nil {
+		return err
+	}
+	a.stmt = parenthesize(stmt.Op.Lexeme, "("+strings.Join(targets, " ")+")", "("+strings.Join(vals, " ")+")")
+	return nil
+}
+
+// (switch subject (case (patterns) body...) (default body...))
+func (a *AstPrinter) VisitSwitchStmt(stmt *types.Switch) error {
+	subject, err := a.Print(stmt.Subject)
+	if err != nil {
+		return err
+	}
+	parts := []string{subject}
+	for _, c := range stmt.Cases {
+		patterns := make([]string, len(c.Patterns))
+		for i, p := range c.Patterns {
+			if patterns[i], err = a.pattern(p); err != nil {
+				return err
+			}
+		}
+		body, err := a.stmts(c.Body)
+		if err != nil {
+			return err
+		}
+		parts = append(parts, parenthesize("case", append([]string{"(" + strings.Join(patterns, " ") + ")"}, body...)...))
+	}
+	if stmt.Default != nil {
+		body, err := a.stmts(stmt.Default.Body)
+		if err != nil {
+			return err
+		}
+		parts = append(parts, parenthesize("default", body...))
+	}
+	a.stmt = parenthesize("switch", parts...)
+	return nil
+}
+
+// Values print as exprs, binds as their name, lists as [items ...rest]
+func (a *AstPrinter) pattern(p *types.Pattern) (string, error) {
+	switch p.Kind {
+	case types.PatternValue:
+		return a.Print(p.Value)
+	case types.PatternList:
+		items := make([]string, len(p.Items))
+		for i, item := range p.Items {
+			out, err := a.pattern(item)
+			if err != nil {
+				return "", err
+			}
+			items[i] = out
+		}
+		if p.Rest != nil {
+			items = append(items, "..."+p.Rest.Lexeme)
+		}
+		return "[" + strings.Join(items, " ") + "]", nil
+	}
+	return p.String(), nil
+}
+
+func (a *AstPrinter) VisitStructStmt(stmt *types.Struct) error {
+	fields := make([]string, len(stmt.Fields))
+	for i, field := range stmt.Fields {
+		fields[i] = field.Lexeme
+	}
+	a.stmt = parenthesize("struct", append([]string{stmt.Name.Lexeme}, fields...)...)
+	return nil
+}
@@ -0,0 +1,130 @@
+package astPrinter
+
+import (
+	"encoding/json"
+	"flag"
+	"hype-script/internal/environment"
+	"hype-script/internal/parser"
+	"hype-script/internal/scanner"
+	"hype-script/internal/types"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata with what the printers print now")
+
+func parse(t *testing.T, file, src string) []types.Stmt {
+	sc := scanner.NewScanner()
+	sc.SetFile(file)
+	tokens, err := sc.ScanTokens(src)
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	stmts, err := parser.NewParser(environment.NewEnvironment(nil)).ParseTokens(tokens)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	return stmts
+}
+
+// Every script in testdata has a .sexp and a .json beside it with what it should print as
+func TestGolden(t *testing.T) {
+	files, err := filepath.Glob("testdata/*.hyp")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no scripts in testdata: %v", err)
+	}
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			src, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			name := filepath.Base(file)
+			stmts := parse(t, name, string(src))
+
+			sexp, err := NewAstPrinter().PrintStmts(stmts)
+			if err != nil {
+				t.Fatalf("print: %v", err)
+			}
+			dump, err := DumpJSON(name, stmts)
+			if err != nil {
+				t.Fatalf("dump: %v", err)
+			}
+			golden(t, strings.TrimSuffix(file, ".hyp")+".sexp", sexp)
+			golden(t, strings.TrimSuffix(file, ".hyp")+".json", string(dump)+"\n")
+		})
+	}
+}
+
+func golden(t *testing.T, path, got string) {
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v, run go test -update to make it", err)
+	}
+	if got != string(want) {
+		t.Errorf("%s is out of date, expected:\n%s\ngot:\n%s", path, want, got)
+	}
+}
+
+func TestPrintExpr(t *testing.T) {
+	cases := map[string]string{
+		"1 + 2 * 3":              "(+ 1 (* 2 3))",
+		"-(a)":                   "(- (group a))",
+		"f(1, ...xs, n=2)":       "(call f 1 (... xs) (= n 2))",
+		"m[\"k\"][1:]":           "(slice (index m \"k\") 1 nil)",
+		"a or b and !newt":       "(or a (and b (! newt)))",
+		"({\"a\": [1.5, true]})": "(group (gmap (\"a\" (glist 1.5 true))))",
+	}
+	for src, want := range cases {
+		stmts := parse(t, "expr.hyp", src+"\n")
+		got, err := NewAstPrinter().Print(stmts[0].(*types.Expression).Expr)
+		if err != nil || got != want {
+			t.Errorf("%s: expected %s, got %s (%v)", src, want, got, err)
+		}
+	}
+}
+
+// Positions are what tools need most, spot check they land where the source says
+func TestJSONPositions(t *testing.T) {
+	stmts := parse(t, "pos.hyp", "var port = 1194\nprint port\n")
+	dump, err := DumpJSON("pos.hyp", stmts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tree struct {
+		Version int `json:"version"`
+		Stmts   []struct {
+			Kind string `json:"kind"`
+			Name *struct {
+				Text string   `json:"text"`
+				Span jsonSpan `json:"span"`
+			} `json:"name"`
+			Expr *struct {
+				Kind string    `json:"kind"`
+				Span *jsonSpan `json:"span"`
+			} `json:"expr"`
+		} `json:"stmts"`
+	}
+	if err := json.Unmarshal(dump, &tree); err != nil {
+		t.Fatal(err)
+	}
+	if tree.Version != SchemaVersion || len(tree.Stmts) != 2 {
+		t.Fatalf("unexpected tree %s", dump)
+	}
+	name := tree.Stmts[0].Name
+	if tree.Stmts[0].Kind != "Var" || name.Text != "port" || name.Span.Start != (jsonPos{Offset: 4, Line: 1, Col: 5}) || name.Span.End.Col != 9 {
+		t.Errorf("expected var port at 1:5, got %+v", tree.Stmts[0])
+	}
+	expr := tree.Stmts[1].Expr
+	if tree.Stmts[1].Kind != "Print" || expr.Kind != "VarExpr" || expr.Span.Start != (jsonPos{Offset: 22, Line: 2, Col: 7}) {
+		t.Errorf("expected print of port at 2:7, got %+v", tree.Stmts[1])
+	}
+}
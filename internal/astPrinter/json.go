@@ -0,0 +1,373 @@
+package astPrinter
+
+import (
+	"encoding/json"
+	"hype-script/internal/token"
+	"hype-script/internal/types"
+)
+
+// The AST as JSON for tools and golden tests
+//
+// The root is {"version", "file", "stmts"}. Every node is an object with "kind", the Go type it was parsed into
+// like "BinaryExpr" or "If", and "span", where it sits in the source or null for nodes the parser made up
+// Its other keys are its fields in camelCase, a missing child is null and a list is always a list, even empty
+// Tokens are {"text", "span"}, spans {"start", "end"} and positions {"offset", "line", "col"}, lines and cols from 1
+// Keys come out sorted, so the same tree always prints the same way
+
+// Bumped whenever a change to the schema could break something reading it
+const SchemaVersion = 1
+
+type jsonPos struct {
+	Offset int `json:"offset"`
+	Line   int `json:"line"`
+	Col    int `json:"col"`
+}
+
+type jsonSpan struct {
+	Start jsonPos `json:"start"`
+	End   jsonPos `json:"end"`
+}
+
+type jsonToken struct {
+	Text string    `json:"text"`
+	Span *jsonSpan `json:"span"`
+}
+
+type node map[string]any
+
+type jsonBuilder struct {
+	built node // What the last statement visited built, statement visits can only return an error
+}
+
+func DumpJSON(file string, stmts []types.Stmt) ([]byte, error) {
+	b := &jsonBuilder{}
+	return json.MarshalIndent(map[string]any{
+		"version": SchemaVersion,
+		"file":    file,
+		"stmts":   b.stmts(stmts),
+	}, "", "  ")
+}
+
+func span(s token.Span) *jsonSpan {
+	if s.IsZero() {
+		return nil
+	}
+	return &jsonSpan{
+		Start: jsonPos{Offset: s.Start.Offset, Line: s.Start.Line, Col: s.Start.Col},
+		End:   jsonPos{Offset: s.End.Offset, Line: s.End.Line, Col: s.End.Col},
+	}
+}
+
+// nil for a token that was left out, like the label of an unlabelled loop
+func tok(t token.Token) *jsonToken {
+	if t.Lexeme == "" {
+		return nil
+	}
+	return &jsonToken{Text: t.Lexeme, Span: span(t.Span())}
+}
+
+func toks(ts []token.Token) []*jsonToken {
+	out := make([]*jsonToken, len(ts))
+	for i, t := range ts {
+		out[i] = tok(t)
+	}
+	return out
+}
+
+func newNode(kind string, s token.Span, fields node) node {
+	fields["kind"] = kind
+	fields["span"] = span(s)
+	return fields
+}
+
+func (b *jsonBuilder) expr(expr types.Expr) any {
+	if expr == nil {
+		return nil
+	}
+	// Building never fails, only the interface says it could
+	val, _ := expr.Accept(b)
+	return val
+}
+
+func (b *jsonBuilder) exprs(exprs []types.Expr) []any {
+	out := make([]any, len(exprs))
+	for i, expr := range exprs {
+		out[i] = b.expr(expr)
+	}
+	return out
+}
+
+func (b *jsonBuilder) stmt(stmt types.Stmt) any {
+	if stmt == nil {
+		return nil
+	}
+	stmt.Accept(b)
+	return b.built
+}
+
+func (b *jsonBuilder) stmts(stmts []types.Stmt) []any {
+	out := make([]any, len(stmts))
+	for i, stmt := range stmts {
+		out[i] = b.stmt(stmt)
+	}
+	return out
+}
+
+func (b *jsonBuilder) Print(expr types.Expr) (string, error) {
+	out, err := json.Marshal(b.expr(expr))
+	return string(out), err
+}
+
+func (b *jsonBuilder) VisitBinaryExpr(expr *types.BinaryExpr) (any, error) {
+	return newNode("BinaryExpr", expr.Span(), node{"left": b.expr(expr.Left), "operator": tok(expr.Operator), "right": b.expr(expr.Right)}), nil
+}
+
+// Type is "int", "float", "string", "bool" or "newt", so 1.0 and 1 still differ once they're JSON numbers
+func (b *jsonBuilder) VisitLiteralExpr(expr *types.LiteralExpr) (any, error) {
+	var val any
+	if expr.Val != nil {
+		val = expr.Val.Val
+	}
+	kind := "newt"
+	switch val.(type) {
+	case int64:
+		kind = "int"
+	case float64:
+		kind = "float"
+	case string:
+		kind = "string"
+	case bool:
+		kind = "bool"
+	}
+	return newNode("LiteralExpr", expr.Span(), node{"value": val, "type": kind}), nil
+}
+
+func (b *jsonBuilder) VisitUnaryExpr(expr *types.UnaryExpr) (any, error) {
+	return newNode("UnaryExpr", expr.Span(), node{"operator": tok(expr.Operator), "right": b.expr(expr.Right)}), nil
+}
+
+func (b *jsonBuilder) VisitGroupingExpr(expr *types.GroupingExpr) (any, error) {
+	return newNode("GroupingExpr", expr.Span(), node{"expr": b.expr(expr.Expr)}), nil
+}
+
+func (b *jsonBuilder) VisitVarExpr(expr *types.VarExpr) (any, error) {
+	return newNode("VarExpr", expr.Span(), node{"name": tok(expr.Name)}), nil
+}
+
+func (b *jsonBuilder) VisitAssignExpr(expr *types.AssignExpr) (any, error) {
+	return newNode("AssignExpr", expr.Span(), node{"name": tok(expr.Name), "val": b.expr(expr.Val)}), nil
+}
+
+func (b *jsonBuilder) VisitLogicalExpr(expr *types.LogicalExpr) (any, error) {
+	return newNode("LogicalExpr", expr.Span(), node{"left": b.expr(expr.Left), "operator": tok(expr.Operator), "right": b.expr(expr.Right)}), nil
+}
+
+func (b *jsonBuilder) VisitWhileExpr(expr *types.WhileExpr) (any, error) {
+	return newNode("WhileExpr", expr.Span(), node{"condition": b.expr(expr.Condition), "body": b.stmt(expr.Body)}), nil
+}
+
+// Named args are {"name", "val"}
+func (b *jsonBuilder) VisitCallExpr(expr *types.CallExpr) (any, error) {
+	named := make([]any, len(expr.Named))
+	for i, arg := range expr.Named {
+		named[i] = node{"name": tok(arg.Name), "val": b.expr(arg.Val)}
+	}
+	return newNode("CallExpr", expr.Span(), node{"callee": b.expr(expr.Callee), "args": b.exprs(expr.Args), "named": named}), nil
+}
+
+func (b *jsonBuilder) VisitFunExpr(expr *types.FunExpr) (any, error) {
+	return newNode("FunExpr", expr.Span(), node{"name": tok(expr.Name), "params": toks(expr.Params), "body": b.stmts(expr.Body)}), nil
+}
+
+func (b *jsonBuilder) VisitReturnExpr(expr *types.ReturnExpr) (any, error) {
+	return newNode("ReturnExpr", expr.Span(), node{"val": b.expr(expr.Val)}), nil
+}
+
+func (b *jsonBuilder) VisitPostfixExpr(expr *types.PostfixExpr) (any, error) {
+	return newNode("PostfixExpr", expr.Span(), node{"val": b.expr(expr.Val), "operator": tok(expr.Operator)}), nil
+}
+
+func (b *jsonBuilder) VisitGlistExpr(expr *types.GlistExpr) (any, error) {
+	return newNode("GlistExpr", expr.Span(), node{"data": b.exprs(expr.Data)}), nil
+}
+
+func (b *jsonBuilder) VisitIndexExpr(expr *types.IndexExpr) (any, error) {
+	return newNode("IndexExpr", expr.Span(), node{"expr": b.expr(expr.Expr), "index": b.expr(expr.Index)}), nil
+}
+
+func (b *jsonBuilder) VisitImportExpr(expr *types.ImportExpr) (any, error) {
+	return newNode("ImportExpr", expr.Span(), node{"val": b.expr(expr.Val)}), nil
+}
+
+func (b *jsonBuilder) VisitAccessExpr(expr *types.AccessExpr) (any, error) {
+	return newNode("AccessExpr", expr.Span(), node{"exprs": b.exprs(expr.Exprs)}), nil
+}
+
+func (b *jsonBuilder) VisitGmapExpr(expr *types.GmapExpr) (any, error) {
+	return newNode("GmapExpr", expr.Span(), node{"keys": b.exprs(expr.Keys), "vals": b.exprs(expr.Vals)}), nil
+}
+
+func (b *jsonBuilder) VisitIndexAssignExpr(expr *types.IndexAssignExpr) (any, error) {
+	return newNode("IndexAssignExpr", expr.Span(), node{"expr": b.expr(expr.Expr), "index": b.expr(expr.Index), "val": b.expr(expr.Val)}), nil
+}
+
+func (b *jsonBuilder) VisitSliceExpr(expr *types.SliceExpr) (any, error) {
+	return newNode("SliceExpr", expr.Span(), node{"expr": b.expr(expr.Expr), "start": b.expr(expr.Start), "end": b.expr(expr.End)}), nil
+}
+
+func (b *jsonBuilder) VisitInterpolationExpr(expr *types.InterpolationExpr) (any, error) {
+	return newNode("InterpolationExpr", expr.Span(), node{"parts": b.exprs(expr.Parts)}), nil
+}
+
+func (b *jsonBuilder) VisitTupleExpr(expr *types.TupleExpr) (any, error) {
+	return newNode("TupleExpr", expr.Span(), node{"items": b.exprs(expr.Items)}), nil
+}
+
+func (b *jsonBuilder) VisitSpreadExpr(expr *types.SpreadExpr) (any, error) {
+	return newNode("SpreadExpr", expr.Span(), node{"expr": b.expr(expr.Expr)}), nil
+}
+
+func (b *jsonBuilder) VisitExprStmt(stmt *types.Expression) error {
+	b.built = newNode("Expression", stmt.Span(), node{"expr": b.expr(stmt.Expr)})
+	return nil
+}
+
+func (b *jsonBuilder) VisitPrintStmt(stmt *types.Print) error {
+	b.built = newNode("Print", stmt.Span(), node{"expr": b.expr(stmt.Expr)})
+	return nil
+}
+
+func (b *jsonBuilder) VisitVarStmt(stmt *types.Var) error {
+	b.built = newNode("Var", stmt.Span(), node{"name": tok(stmt.Name), "initializer": b.expr(stmt.Initializer), "global": stmt.Global})
+	return nil
+}
+
+func (b *jsonBuilder) VisitBlockStmt(stmt *types.Block) error {
+	b.built = newNode("Block", stmt.Span(), node{"statements": b.stmts(stmt.Statements)})
+	return nil
+}
+
+func (b *jsonBuilder) VisitIfStmt(stmt *types.If) error {
+	b.built = newNode("If", stmt.Span(), node{"condition": b.expr(stmt.Condition), "then": b.stmt(stmt.Then), "final": b.stmt(stmt.Final)})
+	return nil
+}
+
+func (b *jsonBuilder) VisitWhileStmt(stmt *types.While) error {
+	b.built = newNode("While", stmt.Span(), node{"condition": b.expr(stmt.Condition), "body": b.stmt(stmt.Body), "increment": b.expr(stmt.Increment), "label": tok(stmt.Label)})
+	return nil
+}
+
+// Defaults line up with params, null where a param has none
+func (b *jsonBuilder) VisitFunStmt(stmt *types.Fun) error {
+	defaults := make([]any, len(stmt.Params))
+	for i := range stmt.Params {
+		if i < len(stmt.Defaults) {
+			defaults[i] = b.expr(stmt.Defaults[i])
+		}
+	}
+	b.built = newNode("Fun", stmt.Span(), node{"name": tok(stmt.Name), "params": toks(stmt.Params), "defaults": defaults, "rest": tok(stmt.Rest), "body": b.stmts(stmt.Body)})
+	return nil
+}
+
+func (b *jsonBuilder) VisitReturnStmt(stmt *types.Return) error {
+	b.built = newNode("Return", stmt.Span(), node{"val": b.expr(stmt.Val)})
+	return nil
+}
+
+// Each import is {"alias", "path"}, path's text being the path without quotes or escapes
+func (b *jsonBuilder) VisitImportStmt(stmt *types.Import) error {
+	imports := make([]any, len(stmt.Imports))
+	for i, item := range stmt.Imports {
+		var alias *jsonToken
+		if item.Alias.Type == token.IDENTIFIER { // Without one it holds the path
+			alias = tok(item.Alias)
+		}
+		path := tok(item.Val)
+		path.Text = item.Val.Literal.String()
+		imports[i] = node{"alias": alias, "path": path}
+	}
+	b.built = newNode("Import", stmt.Span(), node{"lang": tok(stmt.Lang), "imports": imports})
+	return nil
+}
+
+func (b *jsonBuilder) VisitAccessStmt(stmt *types.Access) error {
+	b.built = newNode("Access", stmt.Span(), node{"name": tok(stmt.Name), "expr": b.expr(stmt.Expr)})
+	return nil
+}
+
+func (b *jsonBuilder) VisitForInStmt(stmt *types.ForIn) error {
+	b.built = newNode("ForIn", stmt.Span(), node{"name": tok(stmt.Name), "iterable": b.expr(stmt.Iterable), "body": b.stmt(stmt.Body), "label": tok(stmt.Label)})
+	return nil
+}
+
+func (b *jsonBuilder) VisitBreakStmt(stmt *types.Break) error {
+	b.built = newNode("Break", stmt.Span(), node{"label": tok(stmt.Label)})
+	return nil
+}
+
+func (b *jsonBuilder) VisitContinueStmt(stmt *types.Continue) error {
+	b.built = newNode("Continue", stmt.Span(), node{"label": tok(stmt.Label)})
+	return nil
+}
+
+func (b *jsonBuilder) VisitTryStmt(stmt *types.Try) error {
+	b.built = newNode("Try", stmt.Span(), node{"attempt": b.stmt(stmt.Attempt), "woops": b.stmt(stmt.Woops), "name": tok(stmt.Name)})
+	return nil
+}
+
+func (b *jsonBuilder) VisitWertStmt(stmt *types.Wert) error {
+	b.built = newNode("Wert", stmt.Span(), node{"val": b.expr(stmt.Val)})
+	return nil
+}
+
+func (b *jsonBuilder) VisitDestructureStmt(stmt *types.Destructure) error {
+	b.built = newNode("Destructure", stmt.Span(), node{"targets": b.exprs(stmt.Targets), "vals": b.exprs(stmt.Vals), "op": tok(stmt.Op)})
+	return nil
+}
+
+// Cases are {"patterns", "body"}, default is one of those with no patterns or null
+func (b *jsonBuilder) VisitSwitchStmt(stmt *types.Switch) error {
+	cases := make([]any, len(stmt.Cases))
+	for i, c := range stmt.Cases {
+		cases[i] = b.switchCase(c)
+	}
+	var def any
+	if stmt.Default != nil {
+		def = b.switchCase(stmt.Default)
+	}
+	b.built = newNode("Switch", stmt.Span(), node{"subject": b.expr(stmt.Subject), "cases": cases, "default": def})
+	return nil
+}
+
+func (b *jsonBuilder) switchCase(c *types.SwitchCase) node {
+	patterns := make([]any, len(c.Patterns))
+	for i, p := range c.Patterns {
+		patterns[i] = b.pattern(p)
+	}
+	return node{"patterns": patterns, "body": b.stmts(c.Body)}
+}
+
+var patternKinds = map[types.PatternKind]string{
+	types.PatternValue: "value",
+	types.PatternBind:  "bind",
+	types.PatternWild:  "wild",
+	types.PatternList:  "list",
+}
+
+// "pattern" says which of value, name or items and rest it uses
+func (b *jsonBuilder) pattern(p *types.Pattern) node {
+	items := make([]any, len(p.Items))
+	for i, item := range p.Items {
+		items[i] = b.pattern(item)
+	}
+	var rest *jsonToken
+	if p.Rest != nil {
+		rest = tok(*p.Rest)
+	}
+	return newNode("Pattern", p.Span(), node{"pattern": patternKinds[p.Kind], "value": b.expr(p.Value), "name": tok(p.Name), "items": items, "rest": rest})
+}
+
+func (b *jsonBuilder) VisitStructStmt(stmt *types.Struct) error {
+	b.built = newNode("Struct", stmt.Span(), node{"name": tok(stmt.Name), "fields": toks(stmt.Fields)})
+	return nil
+}
@@ -0,0 +1,113 @@
+package diag
+
+import (
+	"fmt"
+	"hype-script/internal/token"
+	"strings"
+)
+
+type Severity int
+
+const (
+	Error Severity = iota
+	Warning
+	Info
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Warning:
+		return "warning"
+	case Info:
+		return "info"
+	}
+	return "error"
+}
+
+// Codes say what kind of problem a diagnostic is, so tools can match on them without parsing messages
+const (
+	UnexpectedChar     = "unexpected-char"
+	UnterminatedString = "unterminated-string"
+	BadEscape          = "bad-escape"
+	MalformedNumber    = "malformed-number"
+	Syntax             = "syntax"
+	Runtime            = "runtime"
+	Type               = "type"
+	IndexBounds        = "index-bounds"
+	Arity              = "arity"
+	Wert               = "wert"
+	Assert             = "assert"
+)
+
+// Extra context for a diagnostic, like a frame of a trace or the error being handled when it happened
+type Note struct {
+	Message string
+	Span    token.Span
+}
+
+type Diagnostic struct {
+	Severity Severity
+	Code     string
+	Message  string
+	Span     token.Span
+	Notes    []Note
+}
+
+func (d *Diagnostic) Error() string {
+	return fmt.Sprintf("%s: %s[%s]: %s", d.Span, d.Severity, d.Code, d.Message)
+}
+
+// Everything the scanner, parser or interpreter found, in the order it was found
+// The zero value is ready to use
+type Diagnostics struct {
+	List []*Diagnostic
+}
+
+func (d *Diagnostics) Add(severity Severity, code, message string, span token.Span, notes ...Note) *Diagnostic {
+	diagnostic := &Diagnostic{
+		Severity: severity,
+		Code:     code,
+		Message:  message,
+		Span:     span,
+		Notes:    notes,
+	}
+	d.List = append(d.List, diagnostic)
+	return diagnostic
+}
+
+func (d *Diagnostics) Errorf(code string, span token.Span, format string, args ...any) *Diagnostic {
+	return d.Add(Error, code, fmt.Sprintf(format, args...), span)
+}
+
+func (d *Diagnostics) HasErrors() bool {
+	for _, diagnostic := range d.List {
+		if diagnostic.Severity == Error {
+			return true
+		}
+	}
+	return false
+}
+
+// d as an error when any of it is an error, nil otherwise
+func (d *Diagnostics) Err() error {
+	if !d.HasErrors() {
+		return nil
+	}
+	return d
+}
+
+func (d *Diagnostics) Len() int {
+	return len(d.List)
+}
+
+func (d *Diagnostics) Reset() {
+	d.List = nil
+}
+
+func (d *Diagnostics) Error() string {
+	lines := make([]string, len(d.List))
+	for i, diagnostic := range d.List {
+		lines[i] = diagnostic.Error()
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,35 @@
+package diag
+
+import (
+	"hype-script/internal/token"
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	AddSource("vpn.hyp", "var port = 1194\n\tvar host = port + \"x\"\n")
+	var d Diagnostics
+	span := token.Span{File: "vpn.hyp", Start: token.Pos{Offset: 28, Line: 2, Col: 13}, End: token.Pos{Offset: 38, Line: 2, Col: 23}}
+	d.Add(Error, Type, "Can't add int and string.", span, Note{Message: "at <script> (vpn.hyp:2)"})
+	d.Add(Warning, "unused", "Nothing reads host.", token.Span{})
+
+	var out strings.Builder
+	Render(&out, &d)
+	want := "vpn.hyp:2:13: error[type]: Can't add int and string.\n" +
+		"2 | \tvar host = port + \"x\"\n" +
+		"  | \t           ^" + strings.Repeat("~", 9) + "\n" +
+		"  = at <script> (vpn.hyp:2)\n" +
+		"<stdin>:0:0: warning[unused]: Nothing reads host.\n"
+	if out.String() != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, out.String())
+	}
+
+	if !d.HasErrors() || d.Err() == nil {
+		t.Errorf("expected an error diagnostic to make Err non nil")
+	}
+	d.Reset()
+	d.Add(Warning, "unused", "Nothing reads host.", token.Span{})
+	if d.Err() != nil {
+		t.Errorf("warnings alone shouldn't be an error")
+	}
+}
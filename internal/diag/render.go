@@ -0,0 +1,61 @@
+package diag
+
+import (
+	"fmt"
+	"hype-script/internal/token"
+	"io"
+	"strings"
+)
+
+// Source of each script being run by file name, so diagnostics can show the line they point at
+var Sources = map[string]string{}
+
+func AddSource(file, source string) {
+	Sources[file] = source
+}
+
+// Writes each diagnostic the way the CLI shows them
+//
+//	vpn.hyp:3:16: error[type]: Can't add string and int.
+//	3 |     var port = "1194" + 1
+//	  |                ^~~~~~~~~~
+//	  = at connect (vpn.hyp:3)
+func Render(w io.Writer, d *Diagnostics) {
+	for _, diagnostic := range d.List {
+		fmt.Fprintln(w, diagnostic.Error())
+		fmt.Fprint(w, Snippet(diagnostic.Span))
+		for _, note := range diagnostic.Notes {
+			fmt.Fprintf(w, "  = %s\n", note.Message)
+		}
+	}
+}
+
+// The source line span starts on with a caret under it, empty when the source isn't known
+func Snippet(span token.Span) string {
+	source, ok := Sources[span.File]
+	if !ok || span.IsZero() {
+		return ""
+	}
+	lines := strings.Split(source, "\n")
+	if span.Start.Line > len(lines) {
+		return ""
+	}
+	line := strings.TrimRight(lines[span.Start.Line-1], "\r")
+
+	// Keep tabs in the padding so the caret lines up however wide they show
+	start := min(max(span.Start.Col-1, 0), len(line))
+	pad := []byte(line[:start])
+	for i, c := range pad {
+		if c != '\t' {
+			pad[i] = ' '
+		}
+	}
+	width := 1
+	if span.End.Line == span.Start.Line && span.End.Col-span.Start.Col > 1 {
+		width = span.End.Col - span.Start.Col
+	}
+
+	num := fmt.Sprint(span.Start.Line)
+	gutter := strings.Repeat(" ", len(num))
+	return fmt.Sprintf("%s | %s\n%s | %s^%s\n", num, line, gutter, pad, strings.Repeat("~", width-1))
+}
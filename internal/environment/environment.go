@@ -47,8 +47,7 @@ func (e *Environment) Assign(name string, val any) error {
 
 	// If the name in not in the local scope, check the one above and so on
 	if e.Enlcosing != nil {
-		e.Enlcosing.Assign(name, val)
-		return nil
+		return e.Enlcosing.Assign(name, val)
 	}
 
 	return fmt.Errorf("undefined variable %s", name)
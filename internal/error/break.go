@@ -0,0 +1,16 @@
+package error
+
+// Signals the nearest enclosing loop (or the loop named by Label) to stop
+type BreakErr struct {
+	Label string
+}
+
+func NewBreakErr(label string) *BreakErr {
+	return &BreakErr{
+		Label: label,
+	}
+}
+
+func (b *BreakErr) Error() string {
+	return "BreakErr"
+}
@@ -0,0 +1,16 @@
+package error
+
+// Signals the nearest enclosing loop (or the loop named by Label) to skip to its next iteration
+type ContinueErr struct {
+	Label string
+}
+
+func NewContinueErr(label string) *ContinueErr {
+	return &ContinueErr{
+		Label: label,
+	}
+}
+
+func (c *ContinueErr) Error() string {
+	return "ContinueErr"
+}
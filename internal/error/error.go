@@ -2,29 +2,31 @@ package error
 
 import (
 	"fmt"
+	"hype-script/internal/diag"
 	"hype-script/internal/token"
 )
 
 func ParserError(errToken token.Token, message string) {
 	if errToken.Type == token.EOF {
-		Report(errToken.Line, " at end", message, "parser")
+		Report(errToken.Span(), " at end", message, "parser")
 	} else {
-		Report(errToken.Line, fmt.Sprintf("at '%s'", errToken.Lexeme), message, "parser")
+		Report(errToken.Span(), fmt.Sprintf("at '%s'", errToken.Lexeme), message, "parser")
 	}
 }
 
 func InterpreterRuntimeError(errToken token.Token, message string) {
-	Report(errToken.Line, fmt.Sprintf(" at '%s'", errToken.Lexeme), message, "interpreter")
+	Report(errToken.Span(), fmt.Sprintf(" at '%s'", errToken.Lexeme), message, "interpreter")
 }
 
 func InterpreterSimpleRuntimeError(errToken token.Token, message string) {
-	Report(errToken.Line, fmt.Sprintf(" at '%s'", errToken.Lexeme), message, "interpreter")
+	Report(errToken.Span(), fmt.Sprintf(" at '%s'", errToken.Lexeme), message, "interpreter")
 }
 
-func ScannerError(line int, message string) {
-	Report(line, "", message, "scanner")
+func ScannerError(span token.Span, message string) {
+	Report(span, "", message, "scanner")
 }
 
-func Report(line int, where string, message string, subsystem string) {
-	fmt.Printf("[subsystem %s] [line %d] Error %s: %s\n", subsystem, line, where, message)
+func Report(span token.Span, where string, message string, subsystem string) {
+	fmt.Printf("%s: [subsystem %s] Error %s: %s\n", span, subsystem, where, message)
+	fmt.Print(diag.Snippet(span))
 }
@@ -1,15 +1,27 @@
 package error
 
+import (
+	"fmt"
+	"hype-script/internal/glorpups"
+	"hype-script/internal/token"
+)
+
+// Raised by a wert statement, unwinds until a try catches it
+// The interpreter fills in the trace from its call stack on the way out
 type WertErr struct {
-	Val any
+	Val  any
+	Line int        // Line of the wert statement
+	Span token.Span // The whole wert statement
+	glorpups.Trace
 }
 
-func NewWertErr(val any) *WertErr {
+func NewWertErr(val any, line int) *WertErr {
 	return &WertErr{
-		Val: val,
+		Val:  val,
+		Line: line,
 	}
 }
 
 func (r *WertErr) Error() string {
-	return "<WertErr>"
+	return fmt.Sprintf("Uncaught wert: %v", r.Val) + r.Trace.String()
 }
@@ -0,0 +1,454 @@
+package format
+
+import (
+	"fmt"
+	"hype-script/internal/environment"
+	"hype-script/internal/parser"
+	"hype-script/internal/scanner"
+	"hype-script/internal/token"
+	"sort"
+	"strings"
+)
+
+// Prints hype source the one canonical way
+// Newlines mean something in hype, so lines stay where they were written and only what's on and around them changes
+// It works on tokens rather than the AST, which has already turned sugar like for loops into something else
+
+const indent = "    "
+
+// One output line, tokens with an optional trailing comment, or a comment on its own
+type line struct {
+	toks    []token.Token
+	comment string
+	blank   bool   // A blank line came before it in the source
+	text    string // Already printed, for lines put together here like grouped imports
+	depth   int    // Extra indent on top of where text lands
+}
+
+// What a bracket that's still open is for, spacing inside it depends on it
+type opener struct {
+	kind openerKind
+	line int // Output line it was opened on, several opened on one line only indent once
+}
+
+type openerKind int
+
+const (
+	group  openerKind = iota // ( ) around an expression
+	call                     // f( )
+	params                   // func f( )
+	list                     // [ ] glist or pattern
+	index                    // xs[ ] or xs[1:2]
+	brace                    // { } block
+	switchBrace
+	gmap
+)
+
+// Formats src, a file that doesn't scan or parse is left alone and its diagnostics come back as the error
+func Source(file, src string) (string, error) {
+	sc := scanner.NewScanner()
+	sc.SetFile(file)
+	tokens, err := sc.ScanTokens(src)
+	if err != nil {
+		return "", err
+	}
+	if _, err := parser.NewParser(environment.NewEnvironment(nil)).ParseTokens(tokens); err != nil {
+		return "", err
+	}
+
+	f := &formatter{src: src}
+	lines := f.split(tokens, sc.GetComments())
+	lines = f.groupImports(lines)
+	out := f.print(lines)
+
+	// Only layout should change, anything else is a bug here and the file is better left alone
+	formatted, err := scanner.NewScanner().ScanTokens(out)
+	if err != nil || !sameTokens(tokens, formatted) {
+		return "", fmt.Errorf("formatting %s would change what it does, leaving it alone", file)
+	}
+	return out, nil
+}
+
+// Compares everything but imports, which get regrouped, and how many ENDs are in a row
+func sameTokens(a, b []token.Token) bool {
+	a, b = significant(a), significant(b)
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Type != b[i].Type || a[i].Lexeme != b[i].Lexeme {
+			return false
+		}
+	}
+	return true
+}
+
+func significant(tokens []token.Token) []token.Token {
+	var kept []token.Token
+	inImport := false
+	for _, tok := range tokens {
+		switch {
+		case tok.Type == token.IMPORT:
+			inImport = true
+		case inImport:
+			inImport = tok.Type != token.RIGHT_PAREN
+		case tok.Type != token.END || len(kept) == 0 || kept[len(kept)-1].Type != token.END:
+			kept = append(kept, token.Token{Type: tok.Type, Lexeme: tok.Lexeme})
+			if tok.Type == token.END {
+				kept[len(kept)-1].Lexeme = ""
+			}
+		}
+	}
+	return kept
+}
+
+type formatter struct {
+	src    string
+	out    strings.Builder
+	stack  []opener
+	lineNo int
+}
+
+// The token as it was written, lexemes have escapes quoted
+func (f *formatter) text(tok token.Token) string {
+	return f.src[tok.Offset:tok.End.Offset]
+}
+
+// Breaks the tokens and comments into the lines they'll be printed on
+func (f *formatter) split(tokens []token.Token, comments []token.Token) []*line {
+	items := make([]token.Token, 0, len(tokens)+len(comments))
+	for _, tok := range tokens {
+		if tok.Type != token.EOF {
+			items = append(items, tok)
+		}
+	}
+	items = append(items, comments...)
+	sort.SliceStable(items, func(a, b int) bool { return items[a].Offset < items[b].Offset })
+
+	var lines []*line
+	cur := &line{}
+	lastLine := 0 // Source line the last thing printed ended on
+	flush := func() {
+		if len(cur.toks) > 0 || cur.comment != "" {
+			lines = append(lines, cur)
+		}
+		cur = &line{}
+	}
+	start := func(tok token.Token) {
+		if len(cur.toks) == 0 && cur.comment == "" {
+			cur.blank = lastLine > 0 && tok.Line > lastLine+1
+		}
+	}
+
+	for i, tok := range items {
+		switch tok.Type {
+		case token.END:
+			// The scanner adds ENDs of its own, like before a ')', only real newlines and ';' count
+			if tok.Offset >= len(f.src) || (f.src[tok.Offset] != '\n' && f.src[tok.Offset] != ';') {
+				continue
+			}
+			// a; b stays on one line, a ';' ending a line is dropped
+			if f.src[tok.Offset] == ';' && i+1 < len(items) && items[i+1].Line == tok.Line && items[i+1].Type != token.END && items[i+1].Type != token.COMMENT {
+				cur.toks = append(cur.toks, tok)
+				continue
+			}
+			flush()
+			continue
+		case token.COMMENT:
+			if len(cur.toks) == 0 || tok.Line != lastLine {
+				flush()
+				start(tok)
+			}
+			cur.comment = strings.TrimRight(f.text(tok), " \t\r")
+			flush()
+		default:
+			if len(cur.toks) > 0 && tok.Line != lastLine {
+				flush()
+			}
+			start(tok)
+			cur.toks = append(cur.toks, tok)
+		}
+		lastLine = tok.End.Line
+	}
+	flush()
+	return lines
+}
+
+type importItem struct {
+	alias string
+	path  string // As written, quotes and all
+}
+
+// Runs of import statements for the same language become one, with each path once and in order
+// A run with a comment in it is left as it is so the comment doesn't lose its place
+func (f *formatter) groupImports(lines []*line) []*line {
+	var out []*line
+	for i := 0; i < len(lines); {
+		lang, items, next, ok := f.importStmt(lines, i)
+		if !ok {
+			out = append(out, lines[i])
+			i++
+			continue
+		}
+		blank := lines[i].blank
+		for {
+			nextLang, more, after, ok := f.importStmt(lines, next)
+			if !ok || nextLang != lang {
+				break
+			}
+			items = append(items, more...)
+			next = after
+		}
+		i = next
+
+		seen := map[importItem]bool{}
+		var unique []importItem
+		for _, item := range items {
+			if !seen[item] {
+				seen[item] = true
+				unique = append(unique, item)
+			}
+		}
+		sort.SliceStable(unique, func(a, b int) bool { return unique[a].path < unique[b].path })
+
+		if len(unique) == 1 {
+			out = append(out, &line{text: "import " + lang + " (" + unique[0].String() + ")", blank: blank})
+			continue
+		}
+		out = append(out, &line{text: "import " + lang + " (", blank: blank})
+		for _, item := range unique {
+			out = append(out, &line{text: item.String(), depth: 1})
+		}
+		out = append(out, &line{text: ")"})
+	}
+	return out
+}
+
+func (i importItem) String() string {
+	if i.alias == "" {
+		return i.path
+	}
+	return i.alias + " " + i.path
+}
+
+// The import statement starting at lines[i], and the index of the line after it
+func (f *formatter) importStmt(lines []*line, i int) (string, []importItem, int, bool) {
+	if i >= len(lines) || len(lines[i].toks) < 3 || lines[i].toks[0].Type != token.IMPORT {
+		return "", nil, i, false
+	}
+	var toks []token.Token
+	for ; i < len(lines); i++ {
+		if lines[i].comment != "" || lines[i].text != "" {
+			return "", nil, i, false
+		}
+		toks = append(toks, lines[i].toks...)
+		if toks[len(toks)-1].Type == token.RIGHT_PAREN {
+			break
+		}
+	}
+	if i == len(lines) || toks[1].Type != token.IDENTIFIER || toks[2].Type != token.LEFT_PAREN {
+		return "", nil, i, false
+	}
+
+	var items []importItem
+	alias := ""
+	for _, tok := range toks[3 : len(toks)-1] {
+		switch tok.Type {
+		case token.IDENTIFIER:
+			alias = tok.Lexeme
+		case token.STRING:
+			items = append(items, importItem{alias: alias, path: f.text(tok)})
+			alias = ""
+		case token.END:
+		default:
+			return "", nil, i, false
+		}
+	}
+	return toks[1].Lexeme, items, i + 1, true
+}
+
+func (f *formatter) print(lines []*line) string {
+	for i, l := range lines {
+		opens := len(f.stack) > 0 && f.stack[len(f.stack)-1].line == f.lineNo-1
+		if l.blank && i > 0 && !opens && !closes(l) {
+			f.out.WriteString("\n")
+		}
+		if l.text != "" {
+			f.out.WriteString(strings.Repeat(indent, f.depth()+l.depth) + l.text + "\n")
+			f.lineNo++
+			continue
+		}
+		f.printLine(l)
+		f.lineNo++
+	}
+	return f.out.String()
+}
+
+func closes(l *line) bool {
+	return len(l.toks) > 0 && isCloser(l.toks[0].Type)
+}
+
+func isCloser(t token.TokenType) bool {
+	return t == token.RIGHT_PAREN || t == token.RIGHT_BRACKET || t == token.RIGHT_BRACE
+}
+
+// Lines opened on count once each, so f([ on one line only indents what follows once
+func (f *formatter) depth() int {
+	depth := 0
+	for i, o := range f.stack {
+		if i == 0 || o.line != f.stack[i-1].line {
+			depth++
+		}
+	}
+	return depth
+}
+
+func (f *formatter) printLine(l *line) {
+	// Closers leading the line pull it back out before it's indented
+	lead := 0
+	for lead < len(l.toks) && isCloser(l.toks[lead].Type) {
+		f.pop()
+		lead++
+	}
+	depth := f.depth()
+	if len(l.toks) > 0 && (l.toks[0].Type == token.CASE || l.toks[0].Type == token.DEFAULT) && f.top() == switchBrace {
+		depth-- // case sits level with its switch, its body one in
+	}
+	f.out.WriteString(strings.Repeat(indent, max(depth, 0)))
+
+	var prev *token.Token
+	unary := false // The last token was a prefix operator, nothing goes between it and its operand
+	for i, tok := range l.toks {
+		if prev != nil && !unary && f.spaced(*prev, tok) {
+			f.out.WriteString(" ")
+		}
+		text := f.text(tok)
+		if tok.Type == token.END {
+			text = ";"
+		}
+		f.out.WriteString(text)
+
+		if i >= lead {
+			switch tok.Type {
+			case token.LEFT_PAREN:
+				f.push(f.parenKind(l.toks[:i]))
+			case token.LEFT_BRACKET:
+				if prev != nil && endsOperand(prev.Type) {
+					f.push(index)
+				} else {
+					f.push(list)
+				}
+			case token.LEFT_BRACE:
+				switch {
+				case isGmap(prev):
+					f.push(gmap)
+				case l.toks[0].Type == token.SWITCH:
+					f.push(switchBrace)
+				default:
+					f.push(brace)
+				}
+			case token.RIGHT_PAREN, token.RIGHT_BRACKET, token.RIGHT_BRACE:
+				f.pop()
+			}
+		}
+		unary = isPrefix(tok.Type) && (prev == nil || !endsOperand(prev.Type)) || tok.Type == token.ELLIPSIS
+		prev = &l.toks[i]
+	}
+
+	if l.comment != "" {
+		if len(l.toks) > 0 {
+			f.out.WriteString(" ")
+		}
+		f.out.WriteString(l.comment)
+	}
+	f.out.WriteString("\n")
+}
+
+func (f *formatter) push(kind openerKind) {
+	f.stack = append(f.stack, opener{kind: kind, line: f.lineNo})
+}
+
+func (f *formatter) pop() {
+	if len(f.stack) > 0 {
+		f.stack = f.stack[:len(f.stack)-1]
+	}
+}
+
+func (f *formatter) top() openerKind {
+	if len(f.stack) == 0 {
+		return -1
+	}
+	return f.stack[len(f.stack)-1].kind
+}
+
+// ( after a name or call is a call, after func name it's the params, anything else groups
+func (f *formatter) parenKind(before []token.Token) openerKind {
+	n := len(before)
+	if n >= 2 && before[n-1].Type == token.IDENTIFIER && before[n-2].Type == token.FUN {
+		return params
+	}
+	if n >= 1 && endsOperand(before[n-1].Type) && before[n-1].Type != token.NUMBER {
+		return call
+	}
+	return group
+}
+
+// Tokens an expression can end on, so an operator after one is binary and ( or [ after one is a call or index
+func endsOperand(t token.TokenType) bool {
+	switch t {
+	case token.IDENTIFIER, token.NUMBER, token.STRING, token.INTERPOLATION, token.TRUE, token.FALSE, token.NEWT,
+		token.RIGHT_PAREN, token.RIGHT_BRACKET, token.RIGHT_BRACE, token.PLUS_PLUS, token.MINUS_MINUS:
+		return true
+	}
+	return false
+}
+
+// A { where an expression goes is a gmap, after a condition, else, try or woops it's a block
+func isGmap(prev *token.Token) bool {
+	if prev == nil || endsOperand(prev.Type) {
+		return false
+	}
+	switch prev.Type {
+	case token.ELSE, token.TRY, token.WOOPS, token.SWITCH, token.END:
+		return false
+	}
+	return true
+}
+
+func isPrefix(t token.TokenType) bool {
+	switch t {
+	case token.MINUS, token.BANG, token.TILDE, token.KARAT:
+		return true
+	}
+	return false
+}
+
+// Whether a space goes between prev and tok, the default is one
+func (f *formatter) spaced(prev, tok token.Token) bool {
+	switch prev.Type {
+	case token.LEFT_PAREN, token.LEFT_BRACKET, token.DOT:
+		return false
+	case token.LEFT_BRACE:
+		return tok.Type != token.RIGHT_BRACE && f.top() != gmap
+	}
+	if tok.Type == token.RIGHT_BRACE && f.top() == gmap {
+		return false
+	}
+	if f.top() == call && (prev.Type == token.EQUAL || tok.Type == token.EQUAL) { // f(name="x")
+		return false
+	}
+	switch tok.Type {
+	case token.RIGHT_PAREN, token.RIGHT_BRACKET, token.COMMA, token.DOT, token.PLUS_PLUS, token.MINUS_MINUS, token.END:
+		return false
+	case token.LEFT_PAREN:
+		return f.parenKind([]token.Token{prev}) == group
+	case token.LEFT_BRACKET:
+		return !endsOperand(prev.Type)
+	case token.COLON:
+		return false
+	}
+	if f.top() == index && prev.Type == token.COLON { // xs[1:2]
+		return false
+	}
+	return true
+}
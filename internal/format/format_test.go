@@ -0,0 +1,77 @@
+package format
+
+import (
+	"hype-script/internal/diag"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	cases := []struct{ name, src, want string }{
+		{
+			"spacing",
+			"var port=1194\nx:=-port+ 2*(3 -1)\nprint xs[1:2][ 0 ]\n",
+			"var port = 1194\nx := -port + 2 * (3 - 1)\nprint xs[1:2][0]\n",
+		},
+		{
+			"indentation and braces",
+			"func greet(name,greeting=\"hi\",...rest){\nif name==\"x\"{print name} else{\n        print greeting\n  }\n}\n",
+			"func greet(name, greeting = \"hi\", ...rest) {\n    if name == \"x\" { print name } else {\n        print greeting\n    }\n}\n",
+		},
+		{
+			"calls, glists and gmaps",
+			"greet(...[ 1,2 ], name = \"x\")\nm := { a: 1, \"b\" :2 }\nempty := {  }\n",
+			"greet(...[1, 2], name=\"x\")\nm := {a: 1, \"b\": 2}\nempty := {}\n",
+		},
+		{
+			"switch cases sit level with the switch",
+			"switch x {\n    case 1, 2:\n        print x\n    default:\nprint 0\n}\n",
+			"switch x {\ncase 1, 2:\n    print x\ndefault:\n    print 0\n}\n",
+		},
+		{
+			"comments and blank lines",
+			"// top\n\n\n\nvar x = 1   // why\n{\n\n    // inside\n    print x\n\n}\n",
+			"// top\n\nvar x = 1 // why\n{\n    // inside\n    print x\n}\n",
+		},
+		{
+			"imports are grouped and sorted",
+			"import go (\"strings\")\nimport go (\n  \"os\"\n    \"strings\"\n)\nprint os.Getpid()\n",
+			"import go (\n    \"os\"\n    \"strings\"\n)\nprint os.Getpid()\n",
+		},
+		{
+			"semicolons",
+			"for i := 0;i < 3;i++ { print i };\nprint 1; print 2\n",
+			"for i := 0; i < 3; i++ { print i }\nprint 1; print 2\n",
+		},
+		{
+			"multi line calls indent once",
+			"greet([\n1,\n2,\n])\n",
+			"greet([\n    1,\n    2,\n])\n",
+		},
+		{
+			"strings are left as written",
+			"print \"a\\tb ${ x+1 }\"\nprint \"\"\"\n  raw\n\"\"\"\n",
+			"print \"a\\tb ${ x+1 }\"\nprint \"\"\"\n  raw\n\"\"\"\n",
+		},
+	}
+	for _, c := range cases {
+		got, err := Source("fmt.hyp", c.src)
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("%s: expected\n%s\ngot\n%s", c.name, c.want, got)
+		}
+		// Formatting again changes nothing
+		if again, _ := Source("fmt.hyp", got); again != got {
+			t.Errorf("%s: not stable, second pass gave\n%s", c.name, again)
+		}
+	}
+}
+
+func TestFormatSyntaxError(t *testing.T) {
+	_, err := Source("fmt.hyp", "var x = (\n")
+	if _, ok := err.(*diag.Diagnostics); !ok {
+		t.Errorf("expected the parse diagnostics back, got %v", err)
+	}
+}
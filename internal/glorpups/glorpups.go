@@ -7,24 +7,48 @@ import (
 
 type Glorpup interface {
 	Error() string
+	GetToken() token.Token
+	GetMessage() string
+	GetPrevious() Glorpup
+	GetTrace() *Trace
 }
 
 type RuntimeGlorpup struct {
 	Token    token.Token
 	Message  string
 	Previous Glorpup
+	Trace
 }
 
 type IndexBoundsGlorpup struct {
 	Token    token.Token
 	Message  string
 	Previous Glorpup
+	Trace
 }
 
 type TypeGlorpup struct {
 	Token    token.Token
 	Message  string
 	Previous Glorpup
+	Trace
+}
+
+// Wrong number of args for a call
+type ArityGlorpup struct {
+	Token    token.Token
+	Message  string
+	Previous Glorpup
+	Trace
+}
+
+// A failed assert, Diff spells out how the values differ when one line can't
+type AssertGlorpup struct {
+	Token    token.Token
+	Message  string
+	Diff     string
+	Previous Glorpup
+	Trace
 }
 
 func NewRuntimeGlorpup(token token.Token, message string, err Glorpup) Glorpup {
@@ -36,7 +60,19 @@ func NewRuntimeGlorpup(token token.Token, message string, err Glorpup) Glorpup {
 }
 
 func (g *RuntimeGlorpup) Error() string {
-	return Report(g.Message, g.Previous)
+	return Report(g.Message+g.Trace.String(), g.Previous)
+}
+
+func (g *RuntimeGlorpup) GetToken() token.Token {
+	return g.Token
+}
+
+func (g *RuntimeGlorpup) GetMessage() string {
+	return g.Message
+}
+
+func (g *RuntimeGlorpup) GetPrevious() Glorpup {
+	return g.Previous
 }
 
 func NewTypeGlorpup(token token.Token, message string, err Glorpup) Glorpup {
@@ -48,19 +84,139 @@ func NewTypeGlorpup(token token.Token, message string, err Glorpup) Glorpup {
 }
 
 func (g *TypeGlorpup) Error() string {
-	return Report(g.Message, g.Previous)
+	return Report(g.Message+g.Trace.String(), g.Previous)
+}
+
+func (g *TypeGlorpup) GetToken() token.Token {
+	return g.Token
+}
+
+func (g *TypeGlorpup) GetMessage() string {
+	return g.Message
+}
+
+func (g *TypeGlorpup) GetPrevious() Glorpup {
+	return g.Previous
 }
 
 func NewIndexBoundsGlorpup(token token.Token, message string, err Glorpup) Glorpup {
 	return &IndexBoundsGlorpup{
-		Token: token,
-		Message: message,
+		Token:    token,
+		Message:  message,
 		Previous: err,
 	}
 }
 
 func (g *IndexBoundsGlorpup) Error() string {
-	return Report(g.Message, g.Previous)
+	return Report(g.Message+g.Trace.String(), g.Previous)
+}
+
+func (g *IndexBoundsGlorpup) GetToken() token.Token {
+	return g.Token
+}
+
+func (g *IndexBoundsGlorpup) GetMessage() string {
+	return g.Message
+}
+
+func (g *IndexBoundsGlorpup) GetPrevious() Glorpup {
+	return g.Previous
+}
+
+func NewArityGlorpup(token token.Token, message string, err Glorpup) Glorpup {
+	return &ArityGlorpup{
+		Token:    token,
+		Message:  message,
+		Previous: err,
+	}
+}
+
+func (g *ArityGlorpup) Error() string {
+	return Report(g.Message+g.Trace.String(), g.Previous)
+}
+
+func (g *ArityGlorpup) GetToken() token.Token {
+	return g.Token
+}
+
+func (g *ArityGlorpup) GetMessage() string {
+	return g.Message
+}
+
+func (g *ArityGlorpup) GetPrevious() Glorpup {
+	return g.Previous
+}
+
+func NewAssertGlorpup(token token.Token, message, diff string, err Glorpup) Glorpup {
+	return &AssertGlorpup{
+		Token:    token,
+		Message:  message,
+		Diff:     diff,
+		Previous: err,
+	}
+}
+
+func (g *AssertGlorpup) Error() string {
+	return Report(g.Message+g.Trace.String(), g.Previous)
+}
+
+func (g *AssertGlorpup) GetToken() token.Token {
+	return g.Token
+}
+
+func (g *AssertGlorpup) GetMessage() string {
+	return g.Message
+}
+
+func (g *AssertGlorpup) GetPrevious() Glorpup {
+	return g.Previous
+}
+
+// Glorpups raised outside the interpreter, like in a Go call, don't know where they happened
+// Gives them tok so they still point at the call
+func WithToken(err error, tok token.Token) error {
+	var at *token.Token
+	switch g := err.(type) {
+	case *RuntimeGlorpup:
+		at = &g.Token
+	case *TypeGlorpup:
+		at = &g.Token
+	case *IndexBoundsGlorpup:
+		at = &g.Token
+	case *ArityGlorpup:
+		at = &g.Token
+	case *AssertGlorpup:
+		at = &g.Token
+	default:
+		return err
+	}
+	if at.Line == 0 && at.Lexeme == "" {
+		*at = tok
+	}
+	return err
+}
+
+// Links err to the glorpup that was being handled when it happened, so both get reported
+func WithPrevious(err error, prev Glorpup) error {
+	var at *Glorpup
+	switch g := err.(type) {
+	case *RuntimeGlorpup:
+		at = &g.Previous
+	case *TypeGlorpup:
+		at = &g.Previous
+	case *IndexBoundsGlorpup:
+		at = &g.Previous
+	case *ArityGlorpup:
+		at = &g.Previous
+	case *AssertGlorpup:
+		at = &g.Previous
+	default:
+		return err
+	}
+	if *at == nil && prev != err {
+		*at = prev
+	}
+	return err
 }
 
 func InterpreterRuntimeError(message string, err Glorpup) {
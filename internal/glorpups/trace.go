@@ -0,0 +1,39 @@
+package glorpups
+
+import (
+	"fmt"
+	"strings"
+)
+
+// One function an error unwound through, line is where it was inside that function
+type Frame struct {
+	Name string
+	File string
+	Line int
+}
+
+func (f Frame) String() string {
+	return fmt.Sprintf("at %s (%s:%d)", f.Name, f.File, f.Line)
+}
+
+// The call stack when an error escaped, innermost frame first
+// Filled in once by the interpreter, an error caught and rethrown keeps its first trace
+type Trace struct {
+	Frames []Frame
+}
+
+func (t *Trace) GetTrace() *Trace {
+	return t
+}
+
+func (t *Trace) Traced() bool {
+	return len(t.Frames) > 0
+}
+
+func (t *Trace) String() string {
+	var builder strings.Builder
+	for _, frame := range t.Frames {
+		builder.WriteString("\n    " + frame.String())
+	}
+	return builder.String()
+}
@@ -70,7 +70,7 @@ func (p *Parser) funDeclaration() (types.Stmt, error) {
 		return nil, err
 	}
 
-	return types.NewFun(name, params, body, p.Environment), nil
+	return types.NewFun(name, params, nil, token.Token{}, body, p.Environment), nil
 }
 
 // The problem is that all functions are defined within the global scope
@@ -429,7 +429,7 @@ func (p *Parser) finishCall(callee types.Expr) (types.Expr, error) {
 		return nil, err
 	}
 	// Finally perform func call
-	return types.NewCallExpr(callee, paren, args), nil
+	return types.NewCallExpr(callee, paren, args, nil), nil
 }
 
 func (p *Parser) index() (types.Expr, error) {
@@ -439,6 +439,7 @@ func (p *Parser) index() (types.Expr, error) {
 	}
 
 	if expr != nil && p.match(token.LEFT_BRACKET) { // If is non nil expr and leftbracket lies after, could only be index
+		bracket := p.previous()
 		idx, err := p.expression()
 		if err != nil {
 			return nil, err
@@ -447,26 +448,26 @@ func (p *Parser) index() (types.Expr, error) {
 		if err != nil {
 			return nil, err
 		}
-		return types.NewIndexExpr(expr, idx), nil
+		return types.NewIndexExpr(expr, idx, bracket), nil
 	}
 	return expr, nil
 }
 
 func (p *Parser) primary() (types.Expr, error) {
 	if p.match(token.FALSE) {
-		return types.NewLiteralExpr(literal.NewLiteral(false)), nil
+		return types.NewLiteralExpr(literal.NewLiteral(false), p.previous()), nil
 	}
 
 	if p.match(token.TRUE) {
-		return types.NewLiteralExpr(literal.NewLiteral(true)), nil
+		return types.NewLiteralExpr(literal.NewLiteral(true), p.previous()), nil
 	}
 
 	if p.match(token.NEWT) {
-		return types.NewLiteralExpr(literal.NewLiteral(nil)), nil
+		return types.NewLiteralExpr(literal.NewLiteral(nil), p.previous()), nil
 	}
 
 	if p.match(token.NUMBER, token.STRING) {
-		return types.NewLiteralExpr(p.previous().Literal), nil
+		return types.NewLiteralExpr(p.previous().Literal, p.previous()), nil
 	}
 
 	// IDENT DOT IDENT
@@ -497,7 +498,7 @@ func (p *Parser) primary() (types.Expr, error) {
 		if err != nil {
 			return nil, err
 		}
-		return types.NewGroupingExpr(expr), nil
+		return types.NewGroupingExpr(expr, token.Token{}, token.Token{}), nil
 	}
 
 	// It has to be in a func that sees if left bracket lies after an expression
@@ -55,7 +55,7 @@ func (p *Parser) forStmt() (types.Stmt, error) {
 		body = types.NewBlock([]types.Stmt{body, types.NewExpression(increment)})
 	}
 	if condition == nil {
-		condition = types.NewLiteralExpr(literal.NewLiteral(true))
+		condition = types.NewLiteralExpr(literal.NewLiteral(true), token.Token{})
 	}
 	body = types.NewWhile(condition, body)
 	if initializer != nil {
@@ -124,7 +124,7 @@ func (p *Parser) printStmt() (types.Stmt, error) {
 	if err != nil {
 		return nil, err
 	}
-	return types.NewPrint(val), nil
+	return types.NewPrint(token.Token{}, val), nil
 }
 
 func (p *Parser) exprStmt() (types.Stmt, error) {
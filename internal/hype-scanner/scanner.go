@@ -1,6 +1,7 @@
 package hype_HypeScanner
 
 import (
+	"hype-script/internal/diag"
 	"fmt"
 	herror "hype-script/internal/error"
 	"hype-script/internal/literal"
@@ -37,6 +38,18 @@ func NewHypeScanner() core.ScannerHandler {
 	}
 }
 
+// Only tracks lines, files are ignored
+func (s *HypeScanner) SetFile(name string) {}
+
+func (s *HypeScanner) GetComments() []token.Token {
+	return nil
+}
+
+// Errors are printed as they are found, nothing is collected
+func (s *HypeScanner) GetDiagnostics() *diag.Diagnostics {
+	return &diag.Diagnostics{}
+}
+
 func (s *HypeScanner) ScanTokens(source string) ([]token.Token, error) {
 	s.Source = source
 	// Each iteration we scan a single token
@@ -205,7 +218,7 @@ func (s *HypeScanner) scanToken() {
 		} else if s.isAlpha(c) {
 			s.identifier()
 		} else {
-			herror.ScannerError(s.Line, "Unexpected character")
+			herror.ScannerError(token.Span{Start: token.Pos{Line: s.Line}}, "Unexpected character")
 		}
 	}
 }
@@ -266,7 +279,7 @@ func (s *HypeScanner) string() {
 	//s.advance()
 
 	if s.isAtEnd() { // If it makes it to the end of line before finding closing "
-		herror.ScannerError(s.Line, "Unterminated string")
+		herror.ScannerError(token.Span{Start: token.Pos{Line: s.Line}}, "Unterminated string")
 		return
 	}
 
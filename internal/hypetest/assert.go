@@ -0,0 +1,248 @@
+package hypetest
+
+import (
+	"fmt"
+	"hype-script/internal/glorpups"
+	"hype-script/internal/native"
+	"hype-script/internal/token"
+	"hype-script/internal/types/core"
+	"hype-script/internal/utils"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// assert, assert_eq and assert_raises, defined in the env of every test
+// A failed one raises an Assert glorpup, that's how a test that failed is told apart from one that broke
+type assertion struct {
+	name string
+	sig  native.Signature
+	fn   func(interpreter core.InterpreterHandler, args []any) (any, error)
+}
+
+func (a *assertion) Call(interpreter core.InterpreterHandler, args []any) (any, error) {
+	return a.fn(interpreter, args)
+}
+
+func (a *assertion) Arity() int {
+	return len(a.sig.Params)
+}
+
+func (a *assertion) Signature() native.Signature {
+	return a.sig
+}
+
+func (a *assertion) String() string {
+	return fmt.Sprintf("<native fn %s>", a.name)
+}
+
+// Every assertion by the name it is defined under
+func Assertions() map[string]native.Callable {
+	return map[string]native.Callable{
+		"assert":        &assertion{"assert", native.Signature{Params: []string{"cond", "message"}, Required: 1}, assert},
+		"assert_eq":     &assertion{"assert_eq", native.Signature{Params: []string{"got", "want", "message"}, Required: 2}, assertEq},
+		"assert_raises": &assertion{"assert_raises", native.Signature{Params: []string{"fn", "kind"}, Required: 1}, assertRaises},
+	}
+}
+
+// assert(cond, message="")
+func assert(_ core.InterpreterHandler, args []any) (any, error) {
+	if args[0] != nil && args[0] != false {
+		return nil, nil
+	}
+	return nil, fail(args[1], fmt.Sprintf("assert expected a truthy value, got %s.", show(args[0])), "")
+}
+
+// assert_eq(got, want, message=""), glists, gmaps and structs are compared by what's in them
+func assertEq(_ core.InterpreterHandler, args []any) (any, error) {
+	got, want := args[0], args[1]
+	if equal(got, want) {
+		return nil, nil
+	}
+	diff := ""
+	if wantLines, gotLines := lines(want), lines(got); wantLines != nil && gotLines != nil {
+		diff = diffLines(wantLines, gotLines)
+	}
+	return nil, fail(args[2], fmt.Sprintf("assert_eq got %s, want %s.", show(got), show(want)), diff)
+}
+
+// assert_raises(fn, kind="") calls fn with no args and hands back the woops it raised
+// kind is checked against err.kind when given, "Type", "IndexBounds" and so on
+func assertRaises(interpreter core.InterpreterHandler, args []any) (any, error) {
+	fn, ok := args[0].(native.Callable)
+	if !ok {
+		return nil, glorpups.NewTypeGlorpup(token.Token{}, fmt.Sprintf("assert_raises() expects a function, got %s.", native.TypeName(args[0])), nil)
+	}
+	bound, err := native.BindArgs(fn, nil, nil, token.Token{})
+	if err != nil {
+		return nil, err
+	}
+	if _, err = fn.Call(interpreter, bound); err == nil {
+		return nil, fail(native.Missing, fmt.Sprintf("assert_raises expected %s to raise.", fn.String()), "")
+	}
+	woops := native.WoopsFrom(err)
+	if kind, ok := args[1].(string); ok && kind != "" && kind != woops.Kind {
+		return nil, fail(native.Missing, fmt.Sprintf("assert_raises expected a %s woops, got %s: %s", kind, woops.Kind, woops.Message), "")
+	}
+	return woops, nil
+}
+
+// message is what the test passed to say what it was checking, Missing if it didn't
+func fail(message any, what, diff string) error {
+	if message != native.Missing {
+		what = utils.Stringify(message) + ": " + what
+	}
+	return glorpups.NewAssertGlorpup(token.Token{}, what, diff, nil)
+}
+
+// Strings are quoted so "1" and 1 look different
+func show(val any) string {
+	if s, ok := val.(string); ok {
+		return strconv.Quote(s)
+	}
+	return utils.Stringify(val)
+}
+
+// Like ==, except glists, tuples, gmaps and structs are equal when what's in them is
+func equal(a, b any) bool {
+	return deepEqual(a, b, map[[2]any]bool{})
+}
+
+// comparing holds the container pairs being compared further up, meeting one again means nothing differed on the way round
+func deepEqual(a, b any, comparing map[[2]any]bool) bool {
+	switch a.(type) {
+	case *native.Glist, *native.Gmap, *native.StructVal:
+		pair := [2]any{a, b}
+		if a == b || comparing[pair] {
+			return true
+		}
+		comparing[pair] = true
+		defer delete(comparing, pair)
+	}
+	switch x := a.(type) {
+	case *native.Glist:
+		y, ok := b.(*native.Glist)
+		return ok && equalItems(x.Items, y.Items, comparing)
+	case *native.Tuple:
+		y, ok := b.(*native.Tuple)
+		return ok && equalItems(x.Items, y.Items, comparing)
+	case *native.Gmap:
+		y, ok := b.(*native.Gmap)
+		if !ok || x.Len() != y.Len() {
+			return false
+		}
+		for _, k := range x.Keys {
+			if !y.Has(k) || !deepEqual(x.Get(k), y.Get(k), comparing) {
+				return false
+			}
+		}
+		return true
+	case *native.StructVal:
+		y, ok := b.(*native.StructVal)
+		if !ok || x.Type != y.Type {
+			return false
+		}
+		for _, field := range x.Type.Fields {
+			if !deepEqual(x.Vals[field], y.Vals[field], comparing) {
+				return false
+			}
+		}
+		return true
+	}
+	if l, r, ok := utils.ConvInt(a, b); ok {
+		return l == r
+	}
+	if l, r, ok := utils.ConvFloat(a, b); ok {
+		return l == r
+	}
+	if a == nil || b == nil {
+		return a == b
+	}
+	if !reflect.TypeOf(a).Comparable() || !reflect.TypeOf(b).Comparable() {
+		return false
+	}
+	return a == b
+}
+
+func equalItems(a, b []any, comparing map[[2]any]bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !deepEqual(a[i], b[i], comparing) {
+			return false
+		}
+	}
+	return true
+}
+
+// A value one line per item, for diffing, nil for values that fit on one line anyway
+func lines(val any) []string {
+	var out []string
+	switch v := val.(type) {
+	case string:
+		if !strings.Contains(v, "\n") {
+			return nil
+		}
+		return strings.Split(v, "\n")
+	case *native.Glist:
+		for _, item := range v.Items {
+			out = append(out, show(item))
+		}
+	case *native.Tuple:
+		for _, item := range v.Items {
+			out = append(out, show(item))
+		}
+	case *native.Gmap:
+		for _, k := range v.Keys {
+			out = append(out, show(k)+": "+show(v.Vals[k]))
+		}
+	case *native.StructVal:
+		for _, field := range v.Type.Fields {
+			out = append(out, field+": "+show(v.Vals[field]))
+		}
+	default:
+		return nil
+	}
+	if out == nil {
+		out = []string{} // Empty, but still something to diff against
+	}
+	return out
+}
+
+// Line diff of want against got, "- " for lines only in want, "+ " for lines only in got
+// Lines both share are found as a longest common subsequence, test values are small enough for the table
+func diffLines(want, got []string) string {
+	common := make([][]int, len(want)+1)
+	for i := range common {
+		common[i] = make([]int, len(got)+1)
+	}
+	for i := len(want) - 1; i >= 0; i-- {
+		for j := len(got) - 1; j >= 0; j-- {
+			if want[i] == got[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	var builder strings.Builder
+	builder.WriteString("--- want\n+++ got\n")
+	i, j := 0, 0
+	for i < len(want) || j < len(got) {
+		switch {
+		case i < len(want) && j < len(got) && want[i] == got[j]:
+			builder.WriteString("  " + want[i] + "\n")
+			i++
+			j++
+		case j == len(got) || (i < len(want) && common[i+1][j] >= common[i][j+1]):
+			builder.WriteString("- " + want[i] + "\n")
+			i++
+		default:
+			builder.WriteString("+ " + got[j] + "\n")
+			j++
+		}
+	}
+	return builder.String()
+}
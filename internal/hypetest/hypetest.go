@@ -0,0 +1,150 @@
+package hypetest
+
+import (
+	"fmt"
+	"hype-script/internal/diag"
+	"hype-script/internal/environment"
+	herror "hype-script/internal/error"
+	"hype-script/internal/glorpups"
+	"hype-script/internal/interpreter"
+	"hype-script/internal/parser"
+	"hype-script/internal/scanner"
+	"hype-script/internal/token"
+	"hype-script/internal/types"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Runs the test_ functions in _test.hyp files
+// Each test gets a fresh env with the whole file run into it first, so tests can't lean on what another one left behind
+
+// Files the runner looks in
+const Suffix = "_test.hyp"
+
+// Top level functions named like this are tests
+const prefix = "test_"
+
+type Status int
+
+const (
+	Pass  Status = iota
+	Fail         // An assertion failed
+	Error        // Anything else went wrong, a glorpup or an uncaught wert
+)
+
+func (s Status) String() string {
+	switch s {
+	case Fail:
+		return "fail"
+	case Error:
+		return "error"
+	}
+	return "pass"
+}
+
+type Result struct {
+	File    string
+	Name    string
+	Status  Status
+	Message string     // What failed or broke, empty for a pass
+	Diff    string     // How assert_eq's values differ, when they're too big to read side by side
+	Span    token.Span // Where it failed
+	Output  string     // Whatever the test printed
+	Time    time.Duration
+}
+
+// Runs the tests in src whose names match run, every test when run is nil, in the order they're declared
+// A file that doesn't scan or parse has no tests to find, the error is its diagnostics
+func File(file, src string, run *regexp.Regexp) ([]Result, error) {
+	sc := scanner.NewScanner()
+	sc.SetFile(file)
+	tokens, err := sc.ScanTokens(src)
+	if err != nil {
+		return nil, err
+	}
+	stmts, err := parser.NewParser(environment.NewEnvironment(nil)).ParseTokens(tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []Result
+	for _, stmt := range stmts {
+		fun, ok := stmt.(*types.Fun)
+		if !ok || !strings.HasPrefix(fun.Name.Lexeme, prefix) {
+			continue
+		}
+		if run != nil && !run.MatchString(fun.Name.Lexeme) {
+			continue
+		}
+		results = append(results, test(file, stmts, fun))
+	}
+	return results, nil
+}
+
+func test(file string, stmts []types.Stmt, fun *types.Fun) Result {
+	result := Result{File: file, Name: fun.Name.Lexeme}
+	start := time.Now()
+	output, err := capture(func() error {
+		env := environment.NewEnvironment(nil)
+		in := interpreter.NewInterpreter(env)
+		in.SetFile(file)
+		for name, assertion := range Assertions() {
+			env.Define(name, assertion)
+		}
+		if err := in.InterpretStmts(stmts); err != nil {
+			return err
+		}
+		// Called like the script called it, so a failure is traced back through the test
+		call := types.NewCallExpr(types.NewVarExpr(fun.Name), fun.Name, nil, nil)
+		return in.ExecuteBlock([]types.Stmt{types.NewExpression(call)}, env)
+	})
+	result.Time = time.Since(start)
+	result.Output = output
+
+	switch e := err.(type) {
+	case nil:
+		result.Status = Pass
+	case *glorpups.AssertGlorpup:
+		result.Status, result.Message, result.Diff, result.Span = Fail, e.Message, e.Diff, e.Token.Span()
+	case glorpups.Glorpup:
+		result.Status, result.Message, result.Span = Error, e.GetMessage(), e.GetToken().Span()
+	case *herror.WertErr:
+		result.Status, result.Message, result.Span = Error, fmt.Sprintf("Uncaught wert: %v", e.Val), e.Span
+	case *diag.Diagnostics:
+		// The file itself failed before the test could be called
+		d := e.List[0]
+		result.Status, result.Message, result.Span = Error, d.Message, d.Span
+		if d.Code == diag.Assert {
+			result.Status = Fail
+		}
+	default:
+		result.Status, result.Message = Error, err.Error()
+	}
+	return result
+}
+
+// Runs fn with stdout going to a pipe, so what tests print lands in their result instead of the middle of the report
+func capture(fn func() error) (string, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return "", fn()
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	read := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		r.Close()
+		read <- string(data)
+	}()
+	defer func() {
+		os.Stdout = stdout
+	}()
+
+	err = fn()
+	w.Close()
+	return <-read, err
+}
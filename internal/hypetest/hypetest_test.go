@@ -0,0 +1,137 @@
+package hypetest
+
+import (
+	"bytes"
+	"encoding/xml"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+const src = `var calls = 0
+
+func bump() {
+    calls++
+    return calls
+}
+
+func out_of_bounds() {
+    return [1][5]
+}
+
+func test_pass() {
+    assert(true)
+    assert_eq(bump(), 1)
+    assert_eq([1, {"a": 2}], [1.0, {"a": 2}])
+    xs := [1]
+    append(xs, xs)
+    ys := [1]
+    append(ys, ys)
+    assert_eq(xs, ys)
+}
+
+func test_fresh_env() {
+    assert_eq(bump(), 1, "calls carried over")
+}
+
+func test_fail() {
+    print "before"
+    assert_eq([1, 2, 4], [1, 2, 3])
+}
+
+func test_raises() {
+    err := assert_raises(out_of_bounds, "IndexBounds")
+    assert_eq(err.kind, "IndexBounds")
+}
+
+func test_raises_wrong_kind() {
+    assert_raises(out_of_bounds, kind="Type")
+}
+
+func test_error() {
+    var x = 1 + "a"
+}
+
+func helper() {
+    assert(false)
+}
+`
+
+// Each test as "name:status"
+func statuses(results []Result) []string {
+	var got []string
+	for _, r := range results {
+		got = append(got, r.Name+":"+r.Status.String())
+	}
+	return got
+}
+
+func TestFile(t *testing.T) {
+	results, err := File("math_test.hyp", src, nil)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	want := []string{"test_pass:pass", "test_fresh_env:pass", "test_fail:fail", "test_raises:pass", "test_raises_wrong_kind:fail", "test_error:error"}
+	if got := statuses(results); strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	failed := results[2]
+	if failed.Span.Start.Line != 29 || failed.Output != "before\n" {
+		t.Errorf("expected a failure on line 29 that printed before, got line %d and %q", failed.Span.Start.Line, failed.Output)
+	}
+	if want := "--- want\n+++ got\n  1\n  2\n- 3\n+ 4\n"; failed.Diff != want {
+		t.Errorf("expected diff\n%s\ngot\n%s", want, failed.Diff)
+	}
+	if want := "Can't add int and string."; results[5].Message != want {
+		t.Errorf("expected %q, got %q", want, results[5].Message)
+	}
+}
+
+func TestRun(t *testing.T) {
+	results, err := File("math_test.hyp", src, regexp.MustCompile("raises"))
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if got := statuses(results); strings.Join(got, " ") != "test_raises:pass test_raises_wrong_kind:fail" {
+		t.Errorf("expected only the raises tests, got %v", got)
+	}
+}
+
+func TestSyntaxError(t *testing.T) {
+	if _, err := File("bad_test.hyp", "func test_x( {\n", nil); err == nil {
+		t.Error("expected the parse error")
+	}
+}
+
+func TestReports(t *testing.T) {
+	results, err := File("math_test.hyp", src, nil)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	var tap bytes.Buffer
+	if err := TAP(&tap, results); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"TAP version 13\n1..6\n", "ok 1 - math_test.hyp test_pass\n", "not ok 3 - math_test.hyp test_fail\n", "  diff: |\n    --- want\n", "# pass 3\n# fail 2\n# error 1\n"} {
+		if !strings.Contains(tap.String(), want) {
+			t.Errorf("expected TAP to contain %q, got\n%s", want, tap.String())
+		}
+	}
+
+	var junit bytes.Buffer
+	if err := JUnit(&junit, results); err != nil {
+		t.Fatal(err)
+	}
+	var suites junitSuites
+	if err := xml.Unmarshal(junit.Bytes(), &suites); err != nil {
+		t.Fatalf("JUnit isn't valid XML: %v", err)
+	}
+	if suites.Tests != 6 || suites.Failures != 2 || suites.Errors != 1 || len(suites.Suites) != 1 {
+		t.Errorf("expected 6 tests, 2 failures and 1 error in 1 suite, got %+v", suites)
+	}
+	if c := suites.Suites[0].Cases[2]; c.Failure == nil || !strings.Contains(c.Failure.Text, "- 3\n+ 4\n") {
+		t.Errorf("expected test_fail's failure to carry the diff, got %+v", c)
+	}
+}
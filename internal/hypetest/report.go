@@ -0,0 +1,147 @@
+package hypetest
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Writes results as TAP version 13, failures get a YAML block saying where and why
+func TAP(w io.Writer, results []Result) error {
+	var b strings.Builder
+	b.WriteString("TAP version 13\n")
+	fmt.Fprintf(&b, "1..%d\n", len(results))
+	counts := map[Status]int{}
+	for i, r := range results {
+		counts[r.Status]++
+		if r.Status == Pass {
+			fmt.Fprintf(&b, "ok %d - %s %s\n", i+1, r.File, r.Name)
+			continue
+		}
+		fmt.Fprintf(&b, "not ok %d - %s %s\n", i+1, r.File, r.Name)
+		b.WriteString("  ---\n")
+		fmt.Fprintf(&b, "  status: %s\n", r.Status)
+		fmt.Fprintf(&b, "  message: %s\n", strconv.Quote(r.Message))
+		if !r.Span.IsZero() {
+			fmt.Fprintf(&b, "  at: %s\n", strconv.Quote(r.Span.String()))
+		}
+		yamlBlock(&b, "diff", r.Diff)
+		yamlBlock(&b, "output", r.Output)
+		b.WriteString("  ...\n")
+	}
+	fmt.Fprintf(&b, "# pass %d\n# fail %d\n# error %d\n", counts[Pass], counts[Fail], counts[Error])
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// A literal block scalar, so diffs and output keep their lines
+func yamlBlock(b *strings.Builder, key, text string) {
+	if text == "" {
+		return
+	}
+	fmt.Fprintf(b, "  %s: |\n", key)
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		b.WriteString("    " + line + "\n")
+	}
+}
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Errors   int          `xml:"errors,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+// One per file
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Errors   int         `xml:"errors,attr"`
+	Time     string      `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+	SystemOut *junitText    `xml:"system-out,omitempty"`
+}
+
+// CDATA so output and diffs keep their newlines as they are
+type junitText struct {
+	Text string `xml:",cdata"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",cdata"`
+}
+
+// Writes results as JUnit XML, the files are the suites and their tests the cases
+func JUnit(w io.Writer, results []Result) error {
+	suites := junitSuites{}
+	var total time.Duration
+	var elapsed []time.Duration // Per suite
+	for _, r := range results {
+		if len(suites.Suites) == 0 || suites.Suites[len(suites.Suites)-1].Name != r.File {
+			suites.Suites = append(suites.Suites, junitSuite{Name: r.File})
+			elapsed = append(elapsed, 0)
+		}
+		suite := &suites.Suites[len(suites.Suites)-1]
+		c := junitCase{Name: r.Name, Classname: r.File, Time: seconds(r.Time)}
+		if r.Output != "" {
+			c.SystemOut = &junitText{Text: r.Output}
+		}
+		problem := &junitProblem{Message: r.Message, Type: r.Status.String(), Text: details(r)}
+		switch r.Status {
+		case Fail:
+			c.Failure = problem
+			suite.Failures++
+			suites.Failures++
+		case Error:
+			c.Error = problem
+			suite.Errors++
+			suites.Errors++
+		}
+		suite.Cases = append(suite.Cases, c)
+		suite.Tests++
+		suites.Tests++
+		elapsed[len(elapsed)-1] += r.Time
+		total += r.Time
+	}
+	for i := range suites.Suites {
+		suites.Suites[i].Time = seconds(elapsed[i])
+	}
+	suites.Time = seconds(total)
+
+	out, err := xml.MarshalIndent(suites, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, xml.Header+string(out)+"\n")
+	return err
+}
+
+// Where it failed then the diff, what a CI page shows under the message
+func details(r Result) string {
+	var b strings.Builder
+	if !r.Span.IsZero() {
+		b.WriteString("at " + r.Span.String() + "\n")
+	}
+	b.WriteString(r.Diff)
+	return b.String()
+}
+
+func seconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', 3, 64)
+}
@@ -2,13 +2,16 @@ package interpreter
 
 import (
 	"fmt"
+	"hype-script/internal/diag"
 	"hype-script/internal/environment"
 	herror "hype-script/internal/error"
 	"hype-script/internal/glorpups"
+	"hype-script/internal/native"
 	"hype-script/internal/token"
 	"hype-script/internal/types"
 	"hype-script/internal/types/core"
 	"hype-script/internal/utils"
+	"reflect"
 
 	"github.com/traefik/yaegi/interp"
 	"github.com/traefik/yaegi/stdlib"
@@ -34,8 +37,11 @@ import (
 
 type Interpreter struct {
 	HadRuntimeError bool
-	Globals         types.EnvironmentHandler
-	Environment     types.EnvironmentHandler
+	File            string                   // Name of the script being run, shown in traces
+	Frames          []callFrame              // Hype functions currently being called, innermost last
+	Diagnostics     diag.Diagnostics         // Uncaught errors from the last InterpretStmts
+	Globals         types.EnvironmentHandler // The root env, builtins and everything the script declares at the top
+	Environment     types.EnvironmentHandler // Whichever env is running right now
 	GoInterpreter   *interp.Interpreter
 	GoEnvironment   types.EnvironmentHandler
 }
@@ -48,34 +54,118 @@ func NewInterpreter(env types.EnvironmentHandler) core.InterpreterHandler {
 	goInterp := interp.New(interp.Options{})
 	goInterp.Use(stdlib.Symbols)
 
+	for name, builtin := range native.Builtins() {
+		env.Define(name, builtin)
+	}
+
 	return &Interpreter{
-		// Pass nil because we want this to point to the global scope
-		// Globals:         globals,
+		Globals:       env,
 		Environment:   env, // ROOT of all envs
 		GoEnvironment: environment.NewEnvironment(nil),
 		// Inherits from
 		HadRuntimeError: false,
+		File:            "<stdin>",
 		GoInterpreter:   goInterp,
 	}
 }
 
 func (i *Interpreter) InterpretStmts(stmts []types.Stmt) error {
+	i.Diagnostics.Reset()
+	i.HadRuntimeError = false
 	// Execute all statements, statements control Env
 	for _, stmt := range stmts {
 		err := i.execute(stmt)
-		if err != nil {
-			fmt.Println("Interpeter: ", err.Error())
-			i.HadRuntimeError = true
+		if err == nil {
+			continue
+		}
+		i.uncaught(err)
+		if _, ok := err.(*herror.WertErr); ok {
+			// Nothing caught it, the script stops here
+			break
 		}
 	}
-	if i.HadRuntimeError {
-		return fmt.Errorf("error encountered in interpreter")
+	return i.Diagnostics.Err()
+}
+
+// The value of expr in the global env, for the REPL to echo, a multi value call gives its tuple as is
+func (i *Interpreter) Evaluate(expr types.Expr) (any, error) {
+	i.Diagnostics.Reset()
+	i.HadRuntimeError = false
+	val, err := i.evaluate(expr)
+	if err != nil {
+		i.uncaught(err)
+		return nil, i.Diagnostics.Err()
 	}
-	return nil
+	return val, nil
+}
+
+// Records an error nothing caught, with the trace of where it got out from
+func (i *Interpreter) uncaught(err error) {
+	i.trace(err)
+	i.Frames = nil
+	i.Diagnostics.List = append(i.Diagnostics.List, diagnose(err))
+	i.HadRuntimeError = true
+}
+
+func (i *Interpreter) GetDiagnostics() *diag.Diagnostics {
+	return &i.Diagnostics
+}
+
+// What an uncaught error looks like to whoever ran the script
+// Each frame of the trace becomes a note, then the glorpup that was being handled when it happened
+func diagnose(err error) *diag.Diagnostic {
+	switch e := err.(type) {
+	case *herror.WertErr:
+		return &diag.Diagnostic{
+			Code:    diag.Wert,
+			Message: fmt.Sprintf("Uncaught wert: %v", e.Val),
+			Span:    e.Span,
+			Notes:   frameNotes(e.GetTrace()),
+		}
+	case glorpups.Glorpup:
+		d := &diag.Diagnostic{
+			Code:    glorpupCode(e),
+			Message: e.GetMessage(),
+			Span:    e.GetToken().Span(),
+			Notes:   frameNotes(e.GetTrace()),
+		}
+		for prev := e.GetPrevious(); prev != nil; prev = prev.GetPrevious() {
+			d.Notes = append(d.Notes, diag.Note{Message: "while handling: " + prev.GetMessage(), Span: prev.GetToken().Span()})
+			d.Notes = append(d.Notes, frameNotes(prev.GetTrace())...)
+		}
+		return d
+	}
+	return &diag.Diagnostic{Code: diag.Runtime, Message: err.Error()}
+}
+
+func glorpupCode(g glorpups.Glorpup) string {
+	switch g.(type) {
+	case *glorpups.TypeGlorpup:
+		return diag.Type
+	case *glorpups.IndexBoundsGlorpup:
+		return diag.IndexBounds
+	case *glorpups.ArityGlorpup:
+		return diag.Arity
+	case *glorpups.AssertGlorpup:
+		return diag.Assert
+	}
+	return diag.Runtime
+}
+
+func frameNotes(trace *glorpups.Trace) []diag.Note {
+	notes := make([]diag.Note, len(trace.Frames))
+	for idx, frame := range trace.Frames {
+		notes[idx] = diag.Note{Message: frame.String(), Span: token.Span{File: frame.File, Start: token.Pos{Line: frame.Line}}}
+	}
+	return notes
+}
+
+func (i *Interpreter) SetFile(name string) {
+	i.File = name
 }
 
 func (i *Interpreter) GetGlobals() types.EnvironmentHandler {
-	return i.Environment
+	return i.Globals
 }
 
 func (i *Interpreter) GetHadRuntimeError() bool {
@@ -85,6 +175,12 @@ func (i *Interpreter) GetHadRuntimeError() bool {
 func (i *Interpreter) ExecuteBlock(stmts []types.Stmt, environment types.EnvironmentHandler) error {
 	prev := i.Environment // Save old, for setting back later
 
+	// Always change back to original env, even when return/break unwinds through us
+	end := func() {
+		i.Environment = prev
+	}
+	defer end()
+
 	// Change to new block and execute from that env
 	i.Environment = environment
 	for _, stmt := range stmts {
@@ -95,12 +191,6 @@ func (i *Interpreter) ExecuteBlock(stmts []types.Stmt, environment types.Environ
 		}
 	}
 
-	// Always change back to original env
-	end := func() {
-		i.Environment = prev
-	}
-	defer end()
-
 	return nil
 }
 
@@ -114,85 +204,285 @@ func (i *Interpreter) execute(stmt types.Stmt) error {
 	return stmt.Accept(i)
 }
 
-func (i *Interpreter) evaluateIndex(index types.Expr) (any, error) {
-	switch index.(type) {
-	case *types.VarExpr:
-		variable, ok := index.(*types.VarExpr)
+// receiver[index] for any runtime value that supports it
+func (i *Interpreter) indexValue(receiver any, index any, tok token.Token) (any, error) {
+	switch r := receiver.(type) {
+	case *native.Gmap:
+		return i.indexGmap(r, index, tok)
+	case *native.Glist:
+		return i.indexGlist(r, index, tok)
+	case string:
+		return i.indexString(r, index, tok)
+	case *native.StructVal:
+		field, ok := index.(string)
 		if !ok {
-			fmt.Println("not good got var in indexexpr")
-			return nil, nil
+			return nil, glorpups.NewTypeGlorpup(tok, fmt.Sprintf("Struct fields are named by strings, got %s.", native.TypeName(index)), nil)
+		}
+		return r.Get(field, tok)
+	}
+	return nil, glorpups.NewTypeGlorpup(tok, fmt.Sprintf("Unable to index %s.", native.TypeName(receiver)), nil)
+}
+
+func (i *Interpreter) indexGlist(list *native.Glist, index any, tok token.Token) (any, error) {
+	idx, err := i.seqIndex(index, list.Len(), tok)
+	if err != nil {
+		return nil, err
+	}
+	return list.Items[idx], nil
+}
+
+// receiver[index] = val
+func (i *Interpreter) setIndex(receiver, index, val any, tok token.Token) error {
+	switch r := receiver.(type) {
+	case *native.Gmap:
+		if !native.IsHashable(index) {
+			return glorpups.NewTypeGlorpup(tok, fmt.Sprintf("Unable to use %s as gmap key.", native.TypeName(index)), nil)
 		}
-		val, err := i.Environment.Get(variable.Name.Lexeme)
+		r.Set(index, val)
+		return nil
+	case *native.Glist:
+		idx, err := i.seqIndex(index, r.Len(), tok)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		return val, nil
-	case *types.LiteralExpr:
-		idxLit, ok := index.(*types.LiteralExpr)
-		if ok {
-			return idxLit.GetRawVal(), nil
+		r.Items[idx] = val
+		return nil
+	case *native.StructVal:
+		field, ok := index.(string)
+		if !ok {
+			return glorpups.NewTypeGlorpup(tok, fmt.Sprintf("Struct fields are named by strings, got %s.", native.TypeName(index)), nil)
 		}
+		return r.Set(field, val, tok)
 	}
-	return nil, nil
+	return glorpups.NewTypeGlorpup(tok, fmt.Sprintf("Unable to assign to index of %s.", native.TypeName(receiver)), nil)
 }
 
-func (i *Interpreter) indexGlist(expr types.Expr, index any) (any, error) {
-	if float, ok := index.(float64); ok {
-		idx := int(float)
-		glist := expr.(*types.GlistExpr)
-		if idx > len(glist.Data)-1 {
-			return nil, glorpups.NewIndexBoundsGlorpup(glist.GetToken(), "Index out of bounds", nil)
+// Assigns to one target of a destructure, := declares it in the current scope instead
+func (i *Interpreter) assignTo(target types.Expr, val any, op token.Token) error {
+	switch t := target.(type) {
+	case *types.VarExpr:
+		if op.Type == token.COLON_EQUAL {
+			i.Environment.Define(t.Name.Lexeme, val)
+			return nil
+		}
+		if err := i.Environment.Assign(t.Name.Lexeme, val); err != nil {
+			return glorpups.NewRuntimeGlorpup(t.Name, fmt.Sprintf("Undefined variable '%s'.", t.Name.Lexeme), nil)
+		}
+		return nil
+	case *types.IndexExpr:
+		receiver, err := i.evaluate(t.Expr)
+		if err != nil {
+			return err
+		}
+		index, err := i.evaluate(t.Index)
+		if err != nil {
+			return err
 		}
-		return i.evaluate(glist.Data[idx])
+		return i.setIndex(receiver, index, val, t.Bracket)
 	}
-	return nil, glorpups.NewIndexBoundsGlorpup(token.Token{}, "Incorrect type for indexing Glist.", nil)
+	return glorpups.NewRuntimeGlorpup(op, "Invalid assignment target.", nil)
 }
 
-func (i *Interpreter) indexVar(expr types.Expr, index any) (any, error) {
-	var ok bool
+// Several values can only be destructured, x = f() where f returns two is a mismatch
+func single(val any, tok token.Token) error {
+	if tuple, ok := val.(*native.Tuple); ok {
+		return glorpups.NewRuntimeGlorpup(tok, fmt.Sprintf("Assignment mismatch: 1 variable but %d values.", tuple.Len()), nil)
+	}
+	return nil
+}
 
-	variable, ok := expr.(*types.VarExpr) // Collapse to variable expr
-	if ok {
-		varVal, err := i.Environment.Get(variable.Name.Lexeme) // Get var val from env
-		if err != nil {
-			return nil, err
-		}
+// Same as single for a value that lands somewhere other than a variable, [f()] holds f's values as one item otherwise
+func singleIn(val any, tok token.Token, what string) error {
+	if tuple, ok := val.(*native.Tuple); ok {
+		return glorpups.NewRuntimeGlorpup(tok, fmt.Sprintf("Expected 1 value for %s, got %d.", what, tuple.Len()), nil)
+	}
+	return nil
+}
 
-		exprList, ok := varVal.([]types.Expr) // Turn into slice of exprs
-		if ok {
-			indexedVal := exprList[int(index.(float64))]
-			v, ok := indexedVal.(*types.LiteralExpr)
-			if ok {
-				return v.Val.Val, nil
+// Binds into env as it goes, a failed match leaves env half filled so use a fresh one per pattern
+func (i *Interpreter) matchPattern(pattern *types.Pattern, val any, env types.EnvironmentHandler) (bool, error) {
+	switch pattern.Kind {
+	case types.PatternWild:
+		return true, nil
+	case types.PatternBind:
+		env.Define(pattern.Name.Lexeme, val)
+		return true, nil
+	case types.PatternList:
+		list, ok := val.(*native.Glist)
+		if !ok {
+			return false, nil
+		}
+		if list.Len() < len(pattern.Items) || (pattern.Rest == nil && list.Len() != len(pattern.Items)) {
+			return false, nil
+		}
+		for idx, item := range pattern.Items {
+			matched, err := i.matchPattern(item, list.Items[idx], env)
+			if err != nil || !matched {
+				return false, err
 			}
 		}
+		if pattern.Rest != nil && pattern.Rest.Lexeme != "_" {
+			env.Define(pattern.Rest.Lexeme, list.Slice(len(pattern.Items), list.Len()))
+		}
+		return true, nil
+	}
+
+	want, err := i.evaluate(pattern.Value)
+	if err != nil {
+		return false, err
 	}
-	return nil, fmt.Errorf("unable to index variable expression")
+	return i.isEqual(val, want), nil
 }
 
-func (i *Interpreter) indexLiteral(expr types.Expr, index any) (any, error) {
-	var str string
-	var ok bool
+// Adds a frame to a wert leaving a hype function, tok is where the function was called
+// A call into a hype function that hasn't returned yet
+type callFrame struct {
+	Name string
+	Call token.Token // The call's closing paren in the frame below
+}
+
+// Gives err a trace of the call stack as it is right now, the first time it passes through
+// Each frame's line is where the error or the call out of it happened
+func (i *Interpreter) trace(err error) {
+	var trace *glorpups.Trace
+	var line int
+	switch e := err.(type) {
+	case *herror.WertErr:
+		trace, line = e.GetTrace(), e.Line
+	case glorpups.Glorpup:
+		trace, line = e.GetTrace(), e.GetToken().Line
+	default:
+		return
+	}
+	if trace.Traced() {
+		return
+	}
+	for idx := len(i.Frames) - 1; idx >= 0; idx-- {
+		trace.Frames = append(trace.Frames, glorpups.Frame{Name: i.Frames[idx].Name, File: i.File, Line: line})
+		line = i.Frames[idx].Call.Line
+	}
+	trace.Frames = append(trace.Frames, glorpups.Frame{Name: "<script>", File: i.File, Line: line})
+}
 
-	val := expr.(*types.LiteralExpr)
-	str, ok = val.GetRawVal().(string)
+// Turns a hype number into a position in a sequence of length n, negative counts back from the end
+func (i *Interpreter) seqIndex(index any, n int, tok token.Token) (int, error) {
+	whole, ok := utils.IsWhole(index)
 	if !ok {
-		return nil, fmt.Errorf("literal is not iterable")
+		return 0, glorpups.NewTypeGlorpup(tok, fmt.Sprintf("Index must be a whole number, got %s.", utils.Stringify(index)), nil)
+	}
+	idx := int(whole)
+	if idx < 0 {
+		idx += n
+	}
+	if idx < 0 || idx >= n {
+		return 0, glorpups.NewIndexBoundsGlorpup(tok, fmt.Sprintf("Index %d out of bounds for length %d.", whole, n), nil)
 	}
+	return idx, nil
+}
+
+// Resolves xs[start:end] against a sequence of length n
+// Missing bounds default to the ends, negatives count back from the end and both are clamped like python
+func (i *Interpreter) sliceBounds(start, end any, n int, tok token.Token) (int, int, error) {
+	bound := func(val any, def int) (int, error) {
+		if val == nil {
+			return def, nil
+		}
+		whole, ok := utils.IsWhole(val)
+		if !ok {
+			return 0, glorpups.NewTypeGlorpup(tok, fmt.Sprintf("Slice bound must be a whole number, got %s.", utils.Stringify(val)), nil)
+		}
+		idx := int(max(-int64(n), min(whole, int64(n))))
+		if idx < 0 {
+			idx += n
+		}
+		return max(0, min(idx, n)), nil
+	}
+
+	s, err := bound(start, 0)
+	if err != nil {
+		return 0, 0, err
+	}
+	e, err := bound(end, n)
+	if err != nil {
+		return 0, 0, err
+	}
+	return s, max(s, e), nil
+}
+
+func (i *Interpreter) indexGmap(m *native.Gmap, index any, tok token.Token) (any, error) {
+	if !native.IsHashable(index) {
+		return nil, glorpups.NewTypeGlorpup(tok, fmt.Sprintf("Unable to use %s as gmap key.", native.TypeName(index)), nil)
+	}
+	return m.Get(index), nil
+}
 
-	switch index.(type) {
-	case int:
-		return str[index.(int)], nil
+// Snapshot of the items a for in loop walks over, so the body can change the original
+func (i *Interpreter) iterate(val any, tok token.Token) ([]any, error) {
+	switch v := val.(type) {
+	case *native.Gmap:
+		keys := make([]any, len(v.Keys))
+		copy(keys, v.Keys)
+		return keys, nil
+	case *native.Glist:
+		items := make([]any, len(v.Items))
+		copy(items, v.Items)
+		return items, nil
 	case string:
-		findChar := index.(string)
-		for i, r := range str {
-			if string(r) == findChar {
-				return i, nil
+		var items []any
+		for _, r := range v {
+			items = append(items, string(r))
+		}
+		return items, nil
+	}
+	return nil, glorpups.NewTypeGlorpup(tok, fmt.Sprintf("Unable to iterate over %s.", native.TypeName(val)), nil)
+}
+
+// Looks up name on a runtime value for the m.k access path
+func (i *Interpreter) member(val any, name token.Token) (any, error) {
+	switch v := val.(type) {
+	case *native.Gmap:
+		return v.Get(name.Lexeme), nil
+	case *native.StructVal:
+		return v.Get(name.Lexeme, name)
+	case *native.Woops:
+		if member, ok := v.Member(name.Lexeme); ok {
+			return member, nil
+		}
+	case *native.GoPackage:
+		member, err := i.ExecuteGo(v.Name + "." + name.Lexeme)
+		if err != nil {
+			return nil, glorpups.NewTypeGlorpup(name, fmt.Sprintf("Go package %s has no member '%s'.", v.Path, name.Lexeme), nil)
+		}
+		rv, ok := member.(reflect.Value)
+		if ok && rv.Kind() == reflect.Func {
+			return native.NewGoFunction(v.Name+"."+name.Lexeme, rv), nil
+		}
+		return native.FromGo(rv), nil
+	case nil, bool, int64, float64, string, *native.Glist, native.Callable:
+	default:
+		if member, ok := native.GoMember(v, name.Lexeme); ok {
+			return member, nil
+		}
+	}
+	return nil, glorpups.NewTypeGlorpup(name, fmt.Sprintf("Unable to access '%s' on %s.", name.Lexeme, native.TypeName(val)), nil)
+}
+
+// "abc"[1] is "b", and the old lookup form "abc"["b"] gives the position of the first "b"
+func (i *Interpreter) indexString(str string, index any, tok token.Token) (any, error) {
+	runes := []rune(str) // By character, the same as for-in and len
+	if find, ok := index.(string); ok {
+		for i, r := range runes {
+			if string(r) == find {
+				return int64(i), nil
 			}
 		}
-		return nil, fmt.Errorf("char not in string")
+		return nil, glorpups.NewIndexBoundsGlorpup(tok, fmt.Sprintf("%q not in string.", find), nil)
 	}
-	return nil, nil
+	idx, err := i.seqIndex(index, len(runes), tok)
+	if err != nil {
+		return nil, err
+	}
+	return string(runes[idx]), nil
 }
 
 func (i *Interpreter) Print(expr types.Expr) (string, error) {
@@ -220,16 +510,50 @@ func (i *Interpreter) isTruthy(val any) bool {
 	return true // For everything except nil and false
 }
 
+// Numbers are equal by value, so 1 == 1.0, and structs are equal when their fields are
+// Values Go can't compare with == (slices from Go calls) are never equal
 func (i *Interpreter) isEqual(a, b any) bool {
+	return i.equal(a, b, map[[2]*native.StructVal]bool{})
+}
+
+// comparing holds the struct pairs being compared further up, meeting one again means nothing differed on the way round
+func (i *Interpreter) equal(a, b any, comparing map[[2]*native.StructVal]bool) bool {
+	if sa, ok := a.(*native.StructVal); ok {
+		sb, ok := b.(*native.StructVal)
+		if !ok || sa.Type != sb.Type {
+			return false
+		}
+		pair := [2]*native.StructVal{sa, sb}
+		if sa == sb || comparing[pair] {
+			return true
+		}
+		comparing[pair] = true
+		defer delete(comparing, pair)
+		for _, field := range sa.Type.Fields {
+			if !i.equal(sa.Vals[field], sb.Vals[field], comparing) {
+				return false
+			}
+		}
+		return true
+	}
+	if l, r, ok := utils.ConvInt(a, b); ok {
+		return l == r
+	}
+	if l, r, ok := utils.ConvFloat(a, b); ok {
+		return l == r
+	}
+	if a == nil || b == nil {
+		return a == b
+	}
+	if !reflect.TypeOf(a).Comparable() || !reflect.TypeOf(b).Comparable() {
+		return false
+	}
 	return a == b
 }
 
 func checkNumberOperand(operator token.Token, operand any) error {
-	_, ok := utils.IsFloat(operand)
-
-	if !ok {
-		herror.InterpreterRuntimeError(operator, "Operand must be number.")
-		return fmt.Errorf("unable to convert operand to int")
+	if !utils.IsNumber(operand) {
+		return glorpups.NewTypeGlorpup(operator, fmt.Sprintf("Operand of '%s' must be a number, got %s.", operator.Lexeme, native.TypeName(operand)), nil)
 	}
 	return nil
 }
@@ -237,8 +561,7 @@ func checkNumberOperand(operator token.Token, operand any) error {
 func checkNumberOperands(operator token.Token, left any, right any) (float64, float64, error) {
 	l, r, ok := utils.ConvFloat(left, right)
 	if !ok {
-		herror.InterpreterRuntimeError(operator, "Operands must be numbers.")
-		return -1, -1, fmt.Errorf("unable to convert operands to int")
+		return -1, -1, glorpups.NewTypeGlorpup(operator, fmt.Sprintf("Operands of '%s' must be numbers, got %s and %s.", operator.Lexeme, native.TypeName(left), native.TypeName(right)), nil)
 	}
 	return l, r, nil
 }
@@ -310,22 +633,3 @@ func checkNumberOperands(operator token.Token, left any, right any) (float64, fl
 // func (i *Interpreter) VisitClassStmt(stmt *types.Class) error {
 // 	return nil
 // }
-
-// func (i *Interpreter) VisitTryStmt(stmt *types.Try) error {
-// 	err := i.execute(stmt.Attempt)
-// 	switch err.(type) {
-// 	case *glorpError.ReturnErr:
-// 		return err
-// 	case glorpups.Glorpup:
-// 		wertVal, _ := err.(*glorpError.WertErr)
-// 		newVar := types.NewVar(stmt.WoopsTok, types.NewLiteralExpr(literal.NewLiteral(wertVal)))
-// 		block := types.NewBlock([]types.Stmt{newVar, stmt.Woops})
-// 		err = i.execute(block)
-// 		// case *glorpError.WertErr:
-// 		// 	wertVal, _ := err.(*glorpError.WertErr)
-// 		// 	newVar := types.NewVar(stmt.WoopsTok, types.NewLiteralExpr(literal.NewLiteral(wertVal)))
-// 		// 	block := types.NewBlock([]types.Stmt{newVar, stmt.Woops})
-// 		// 	err = i.execute(block)
-// 	}
-// 	return err
-// }
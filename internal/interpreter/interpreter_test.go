@@ -1,26 +1,1112 @@
 package interpreter
 
-// import (
-// 	"glorp/environment"
-// 	"glorp/literal"
-// 	"glorp/token"
-// 	"glorp/types"
-// 	"testing"
-// )
-
-// func TestVisitTryStmt(t *testing.T) {
-// 	env := environment.NewEnvironment(nil)
-// 	interpreter := NewInterpreter(env)
-
-// 	// Create a mock Try statement
-// 	attempt := types.NewExpression(types.NewLiteralExpr(literal.NewLiteral("attempt")))
-// 	ohshit := types.NewExpression(types.NewLiteralExpr(literal.NewLiteral("ohshit")))
-// 	ohshitTok := token.Token{Type: token.OHSHIT, Lexeme: "ohshit", Line: 1}
-// 	tryStmt := types.NewTry(attempt, ohshit, ohshitTok)
-
-// 	// Execute the Try statement
-// 	err := interpreter.VisitTryStmt(tryStmt)
-// 	if err != nil {
-// 		t.Errorf("VisitTryStmt failed: %v", err)
-// 	}
-// }
+import (
+	"hype-script/internal/diag"
+	"hype-script/internal/environment"
+	herror "hype-script/internal/error"
+	"hype-script/internal/glorpups"
+	"hype-script/internal/literal"
+	"hype-script/internal/native"
+	"hype-script/internal/parser"
+	"hype-script/internal/scanner"
+	"hype-script/internal/token"
+	"hype-script/internal/types"
+	"hype-script/internal/utils"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVisitTryStmt(t *testing.T) {
+	env := environment.NewEnvironment(nil)
+	interpreter := NewInterpreter(env).(*Interpreter)
+
+	// try { wert "attempt" } woops err { caught = err }
+	wertTok := token.Token{Type: token.WERT, Lexeme: "wert", Line: 1}
+	attempt := types.NewBlock([]types.Stmt{types.NewWert(wertTok, types.NewLiteralExpr(literal.NewLiteral("attempt"), wertTok))})
+	name := token.Token{Type: token.IDENTIFIER, Lexeme: "err", Line: 1}
+	caught := token.Token{Type: token.IDENTIFIER, Lexeme: "caught", Line: 1}
+	woops := types.NewBlock([]types.Stmt{types.NewExpression(types.NewAssignExpr(caught, types.NewVarExpr(name)))})
+	env.Define("caught", nil)
+
+	err := interpreter.VisitTryStmt(types.NewTry(attempt, name, woops).(*types.Try))
+	if err != nil {
+		t.Errorf("VisitTryStmt failed: %v", err)
+	}
+	w, ok := env.Values["caught"].(*native.Woops)
+	if !ok || w.Message != "attempt" || w.Kind != "Runtime" || w.Line != 1 {
+		t.Errorf("expected woops bound to the wert, got %v", env.Values["caught"])
+	}
+}
+
+// Scan, parse and interpret src, handing back the global env so tests can inspect it
+func interpret(src string) (types.EnvironmentHandler, error) {
+	env := environment.NewEnvironment(nil)
+	tokens, err := scanner.NewScanner().ScanTokens(src)
+	if err != nil {
+		return env, err
+	}
+	stmts, err := parser.NewParser(env).ParseTokens(tokens)
+	if err != nil {
+		return env, err
+	}
+	return env, NewInterpreter(env).InterpretStmts(stmts)
+}
+
+func run(t *testing.T, src string) types.EnvironmentHandler {
+	t.Helper()
+	env, err := interpret(src)
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	return env
+}
+
+func expectVar(t *testing.T, env types.EnvironmentHandler, name string, want any) {
+	t.Helper()
+	got, err := env.Get(name)
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	if got != want {
+		t.Errorf("%s: expected %v (%T), got %v (%T)", name, want, want, got, got)
+	}
+}
+
+func TestBreak(t *testing.T) {
+	env := run(t, `
+var x = 0
+while true {
+    x++
+    if x == 3 {
+        break
+    }
+}
+`)
+	expectVar(t, env, "x", int64(3))
+}
+
+func TestContinueRunsForIncrement(t *testing.T) {
+	env := run(t, `
+var s = 0
+for var k = 0; k < 5; k++ {
+    if k == 2 { continue }
+    s = s + k
+}
+`)
+	expectVar(t, env, "s", int64(8))
+}
+
+func TestLabelledLoops(t *testing.T) {
+	env := run(t, `
+var hits = 0
+outer: for var i = 0; i < 3; i++ {
+    for var j = 0; j < 3; j++ {
+        if j == 1 { continue outer }
+        if i == 2 { break outer }
+        hits++
+    }
+}
+`)
+	expectVar(t, env, "hits", int64(2))
+}
+
+func TestReturnFromLoop(t *testing.T) {
+	env := run(t, `
+func f(n) {
+    while true {
+        return n
+    }
+}
+var r = f(7)
+`)
+	expectVar(t, env, "r", int64(7))
+}
+
+func TestGmap(t *testing.T) {
+	env := run(t, `
+var cfg = {
+    "PACKAGETYPE": "deb",
+    REMWORD: "vpn",
+    MAX_CURL_TIME: 5
+}
+cfg.MAX_CURL_TIME = 10
+cfg["extra"] = true
+var nested = {inner: {x: 1}}
+nested.inner.x = 2
+var word = cfg["REMWORD"]
+var curl = cfg.MAX_CURL_TIME
+var size = len(cfg)
+var had = has(cfg, "extra")
+delete(cfg, "extra")
+var gone = !has(cfg, "extra")
+var missing = cfg["nope"]
+var x = nested.inner["x"]
+var seen = ""
+for k in {b: 1, a: 2} {
+    seen = seen + k
+}
+`)
+	expectVar(t, env, "word", "vpn")
+	expectVar(t, env, "curl", int64(10))
+	expectVar(t, env, "size", int64(4))
+	expectVar(t, env, "had", true)
+	expectVar(t, env, "gone", true)
+	expectVar(t, env, "missing", nil)
+	expectVar(t, env, "x", int64(2))
+	expectVar(t, env, "seen", "ba")
+
+	cfg, _ := env.Get("cfg")
+	want := `{"PACKAGETYPE": "deb", "REMWORD": "vpn", "MAX_CURL_TIME": 10}`
+	if got := utils.Stringify(cfg); got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
+
+func TestGlist(t *testing.T) {
+	env := run(t, `
+var n = 2
+var xs = [1, n + 1, "three"]
+var second = xs[1]
+var last = xs[-1]
+xs[0] = 5
+var alias = xs
+alias[1] = 4
+append(xs, 7)
+var size = len(xs)
+var both = xs + [8]
+var mid = xs[1:3]
+var tail = xs[-2:]
+var head = xs[:1]
+var clamped = xs[2:100]
+var sub = "hello"[1:3]
+var sum = 0
+for x in [1, 2, 3] {
+    sum = sum + x
+}
+`)
+	expectVar(t, env, "second", int64(3))
+	expectVar(t, env, "last", "three")
+	expectVar(t, env, "size", int64(4))
+	expectVar(t, env, "sub", "el")
+	expectVar(t, env, "sum", int64(6))
+
+	printed := map[string]string{
+		"xs":      `[5, 4, "three", 7]`,
+		"both":    `[5, 4, "three", 7, 8]`,
+		"mid":     `[4, "three"]`,
+		"tail":    `["three", 7]`,
+		"head":    `[5]`,
+		"clamped": `["three", 7]`,
+	}
+	for name, want := range printed {
+		val, _ := env.Get(name)
+		if got := utils.Stringify(val); got != want {
+			t.Errorf("%s: expected %s, got %s", name, want, got)
+		}
+	}
+}
+
+func TestGlistOutOfBounds(t *testing.T) {
+	for _, src := range []string{"var xs = [1]\nprint xs[1]\n", "var xs = [1]\nxs[-2] = 0\n", "print [1][0.5]\n"} {
+		if _, err := interpret(src); err == nil {
+			t.Errorf("expected runtime error for %q", src)
+		}
+	}
+}
+
+// Runs src and hands back the raw error of the first stmt that fails, rather than InterpretStmts' summary
+func execErr(t *testing.T, src string) error {
+	t.Helper()
+	env := environment.NewEnvironment(nil)
+	tokens, _ := scanner.NewScanner().ScanTokens(src)
+	stmts, err := parser.NewParser(env).ParseTokens(tokens)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	interp := NewInterpreter(env).(*Interpreter)
+	for _, stmt := range stmts {
+		if err := interp.execute(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestIndexAnyExpression(t *testing.T) {
+	env := run(t, `
+func f() {
+    return [10, 20]
+}
+var xs = [[1, 2], [3, 4]]
+var i = 0
+var m = {a: {b: "deep"}, l: [5, 6]}
+var call = f()[1]
+var sum = xs[i+1]
+var deep = m["a"]["b"]
+var nested = xs[0][1]
+var access = m.l[1]
+var char = "abc"[-1]
+xs[1][0] = 9
+var set = xs[1][0]
+`)
+	expectVar(t, env, "call", int64(20))
+	expectVar(t, env, "deep", "deep")
+	expectVar(t, env, "nested", int64(2))
+	expectVar(t, env, "access", int64(6))
+	expectVar(t, env, "char", "c")
+	expectVar(t, env, "set", int64(9))
+	// sum shares the inner glist that xs[1][0] = 9 changed
+	sum, _ := env.Get("sum")
+	if got := utils.Stringify(sum); got != "[9, 4]" {
+		t.Errorf("sum: expected [9, 4], got %s", got)
+	}
+}
+
+func TestStringRunes(t *testing.T) {
+	env := run(t, `
+var s = "héllo"
+var char = s[1]
+var last = s[-1]
+var at = s["l"]
+var sub = s[1:3]
+var n = len(s)
+var joined = ""
+for c in s {
+    joined = joined + c
+}
+`)
+	expectVar(t, env, "char", "é")
+	expectVar(t, env, "last", "o")
+	expectVar(t, env, "at", int64(2))
+	expectVar(t, env, "sub", "él")
+	expectVar(t, env, "n", int64(5))
+	expectVar(t, env, "joined", "héllo")
+}
+
+func TestIndexUnsupportedReceiver(t *testing.T) {
+	err := execErr(t, "var n = 5\nprint n[0]\n")
+	if _, ok := err.(*glorpups.TypeGlorpup); !ok {
+		t.Fatalf("expected TypeGlorpup, got %T: %v", err, err)
+	}
+}
+
+func TestInterpolation(t *testing.T) {
+	env := run(t, `
+var name = "beans"
+var xs = [1, 2, 3]
+var m = {k: "v"}
+var msg = "${name} has ${len(xs)} items, ${m["k"]}${m.k} ${xs}"
+`)
+	expectVar(t, env, "msg", `beans has 3 items, vv [1, 2, 3]`)
+}
+
+func TestIntArithmetic(t *testing.T) {
+	env := run(t, `
+var div = 7 / 2
+var fdiv = 7.0 / 2
+var neg = -7 / 2
+var mod = 7 % 3
+var fmod = 7.5 % 2
+var mixed = 1 + 0.5
+var big = 9007199254740993 + 0
+var hex = 0xff + 0b11 + 0o7 + 1_000
+var same = 1 == 1.0
+var less = 2 < 2.5
+var port = 8080
+port += 1
+var n = 0
+n++
+`)
+	expectVar(t, env, "div", int64(3))
+	expectVar(t, env, "fdiv", 3.5)
+	expectVar(t, env, "neg", int64(-3))
+	expectVar(t, env, "mod", int64(1))
+	expectVar(t, env, "fmod", 1.5)
+	expectVar(t, env, "mixed", 1.5)
+	expectVar(t, env, "big", int64(9007199254740993))
+	expectVar(t, env, "hex", int64(1265))
+	expectVar(t, env, "same", true)
+	expectVar(t, env, "less", true)
+	expectVar(t, env, "port", int64(8081))
+	expectVar(t, env, "n", int64(1))
+}
+
+func TestIntDivisionByZero(t *testing.T) {
+	for _, src := range []string{"print 1 / 0\n", "print 1 % 0\n"} {
+		if _, ok := execErr(t, src).(*glorpups.RuntimeGlorpup); !ok {
+			t.Errorf("expected RuntimeGlorpup for %q", src)
+		}
+	}
+	env := run(t, "var inf = 1.0 / 0\n")
+	if f, _ := env.Get("inf"); f != math.Inf(1) {
+		t.Errorf("expected float division by zero to give +Inf, got %v", f)
+	}
+}
+
+func TestIntStringify(t *testing.T) {
+	for val, want := range map[any]string{int64(8080): "8080", 3.0: "3.0", 0.5: "0.5"} {
+		if got := utils.Stringify(val); got != want {
+			t.Errorf("expected %s, got %s", want, got)
+		}
+	}
+}
+
+// A container that holds itself prints and compares without going round forever
+func TestSelfReference(t *testing.T) {
+	env := run(t, `
+xs := [1]
+append(xs, xs)
+m := {"a": 1}
+m["self"] = m
+m["list"] = xs
+struct Node { val, next }
+n := Node(1, newt)
+n.next = n
+o := Node(1, newt)
+o.next = o
+var list = "${xs}"
+var gmap = "${m}"
+var node = "${n}"
+var same = n == o
+`)
+	expectVar(t, env, "list", "[1, [...]]")
+	expectVar(t, env, "gmap", "{\"a\": 1, \"self\": {...}, \"list\": [1, [...]]}")
+	expectVar(t, env, "node", "Node{val: 1, next: Node{...}}")
+	expectVar(t, env, "same", true)
+}
+
+func TestCompoundAssignTargets(t *testing.T) {
+	env := run(t, `
+var calls = 0
+func next() {
+    calls++
+    return 0
+}
+xs := [1, 2]
+xs[next()] += 10
+xs[1]++
+m := {"a": 1}
+m["a"] += 5
+struct Port { host, port }
+p := Port("a", 80)
+p.port += 1
+ps := [p]
+ps[0].port++
+var list = "${xs}"
+var gmap = m["a"]
+var port = p.port
+`)
+	expectVar(t, env, "list", "[11, 3]")
+	expectVar(t, env, "calls", int64(1))
+	expectVar(t, env, "gmap", int64(6))
+	expectVar(t, env, "port", int64(82))
+
+	if _, ok := execErr(t, "var s = \"ab\"\ns[0] += \"c\"").(glorpups.Glorpup); !ok {
+		t.Error("expected a glorpup assigning into a string")
+	}
+}
+
+func TestGoCallConversions(t *testing.T) {
+	env := run(t, `
+import go (
+    "strings"
+    "strconv"
+    "fmt"
+)
+
+var rep = strings.Repeat("ab", 2)
+var whole = strings.Repeat("x", 3.0)
+var itoa = strconv.Itoa(9007199254740993)
+parsed, perr := strconv.Atoi("42")
+var words = strings.Fields("a b c")
+var sprint = fmt.Sprint(1, " ", 2.5)
+`)
+	expectVar(t, env, "rep", "abab")
+	expectVar(t, env, "whole", "xxx")
+	expectVar(t, env, "itoa", "9007199254740993")
+	expectVar(t, env, "sprint", "1 2.5")
+	expectVar(t, env, "parsed", int64(42))
+	expectVar(t, env, "perr", nil)
+	words, _ := env.Get("words")
+	if utils.Stringify(words) != `["a", "b", "c"]` {
+		t.Errorf("expected glist of words, got %s", utils.Stringify(words))
+	}
+
+	for _, src := range []string{
+		"import go (\"strings\")\nprint strings.Repeat(\"x\", 2.5)\n",
+		"import go (\"strconv\")\nprint strconv.Itoa(\"1\")\n",
+	} {
+		if _, ok := execErr(t, src).(*glorpups.TypeGlorpup); !ok {
+			t.Errorf("expected TypeGlorpup for %q", src)
+		}
+	}
+	src := "import go (\"strings\")\nprint strings.Repeat(\"x\")\n"
+	if _, ok := execErr(t, src).(*glorpups.ArityGlorpup); !ok {
+		t.Errorf("expected ArityGlorpup for %q", src)
+	}
+}
+
+func TestPowerBitwiseOperators(t *testing.T) {
+	env := run(t, `
+var pow = 2 ** 10
+var frac = 2 ** -1
+var neg = -2 ** 2
+var right = 2 ** 3 ** 2
+var idiv = 7.9 ~/ 2
+var mask = 0xffffffff << (32 - 24) & 0xffffffff
+var net = 0xc0a80117 & mask
+var flags = 1 | 4
+var half = 256 >> 4
+var prec = 10 & 3 == 2
+`)
+	expectVar(t, env, "pow", int64(1024))
+	expectVar(t, env, "frac", 0.5)
+	expectVar(t, env, "neg", int64(-4))
+	expectVar(t, env, "right", int64(512))
+	expectVar(t, env, "idiv", int64(3))
+	expectVar(t, env, "mask", int64(0xffffff00))
+	expectVar(t, env, "net", int64(0xc0a80100))
+	expectVar(t, env, "flags", int64(5))
+	expectVar(t, env, "half", int64(16))
+	expectVar(t, env, "prec", true)
+}
+
+// Int powers stay exact right up to the edges of int64, and fail rather than wrap past them
+func TestIntPowOverflow(t *testing.T) {
+	env := run(t, `
+var top = 2 ** 62
+var bottom = (-2) ** 63
+var odd = (-3) ** 39
+var one = 1 ** 1000000
+var zero = 0 ** 64
+`)
+	expectVar(t, env, "top", int64(1)<<62)
+	expectVar(t, env, "bottom", int64(math.MinInt64))
+	expectVar(t, env, "odd", int64(-4052555153018976267))
+	expectVar(t, env, "one", int64(1))
+	expectVar(t, env, "zero", int64(0))
+
+	for _, src := range []string{"print 2 ** 63\n", "print 2 ** 64\n", "print 2 ** 100\n", "print (-2) ** 64\n", "print 3 ** 40\n"} {
+		err := execErr(t, src)
+		if g, ok := err.(*glorpups.RuntimeGlorpup); !ok || !strings.HasSuffix(g.Message, "does not fit in an int.") {
+			t.Errorf("expected overflow for %q, got %v", src, err)
+		}
+	}
+}
+
+func TestBitwiseTypeErrors(t *testing.T) {
+	for _, src := range []string{"print 1.5 & 1\n", "print \"a\" | 1\n", "print 1 << 2.0\n"} {
+		if _, ok := execErr(t, src).(*glorpups.TypeGlorpup); !ok {
+			t.Errorf("expected TypeGlorpup for %q", src)
+		}
+	}
+	for _, src := range []string{"print 1 << -1\n", "print 1 ~/ 0\n", "print 1.0 ~/ 0\n", "print 1 << 64\n", "print 1 >> 64\n", "print 1 << 63\n", "print 3 << 62\n"} {
+		if _, ok := execErr(t, src).(*glorpups.RuntimeGlorpup); !ok {
+			t.Errorf("expected RuntimeGlorpup for %q", src)
+		}
+	}
+
+	env := run(t, "var top = 1 << 62\nvar bottom = -1 << 63\nvar neg = -3 << 2\nvar down = -8 >> 63\n")
+	expectVar(t, env, "top", int64(1)<<62)
+	expectVar(t, env, "bottom", int64(math.MinInt64))
+	expectVar(t, env, "neg", int64(-12))
+	expectVar(t, env, "down", int64(-1))
+}
+
+func TestTryWoops(t *testing.T) {
+	env := run(t, `
+var kind = ""
+var msg = ""
+var line = 0
+try {
+    var xs = [1]
+    print xs[5]
+} woops err {
+    kind = err.kind
+    msg = err.message
+    line = err.line
+}
+var typed = ""
+try { print 1.5 & 1 } woops e { typed = e.kind }
+var after = 0
+try {
+    wert "disk full"
+    after = 1
+} woops e {
+    msg = msg + "|" + e.message
+}
+var code = 0
+func inner() {
+    wert {code: 7}
+}
+try { inner() } woops e { code = e.value.code }
+var rethrown = ""
+try {
+    try { wert "inner" } woops e { wert e }
+} woops e { rethrown = e.message }
+var ran = false
+try { ran = true } woops { ran = false }
+`)
+	expectVar(t, env, "kind", "IndexBounds")
+	expectVar(t, env, "msg", "Index 5 out of bounds for length 1.|disk full")
+	expectVar(t, env, "line", int64(7))
+	expectVar(t, env, "typed", "Type")
+	expectVar(t, env, "after", int64(0))
+	expectVar(t, env, "code", int64(7))
+	expectVar(t, env, "rethrown", "inner")
+	expectVar(t, env, "ran", true)
+}
+
+func TestTryPassesControlFlow(t *testing.T) {
+	env := run(t, `
+func f() {
+    try { return 1 } woops { return 2 }
+}
+var r = f()
+var n = 0
+while true {
+    try { break } woops { n = 99 }
+}
+`)
+	expectVar(t, env, "r", int64(1))
+	expectVar(t, env, "n", int64(0))
+}
+
+func TestUncaughtWertTrace(t *testing.T) {
+	err := execErr(t, `
+func inner() {
+    wert "boom"
+}
+func outer() {
+    inner()
+}
+outer()
+`)
+	wert, ok := err.(*herror.WertErr)
+	if !ok {
+		t.Fatalf("expected WertErr, got %v", err)
+	}
+	want := "\n    at inner (<stdin>:3)\n    at outer (<stdin>:6)\n    at <script> (<stdin>:8)"
+	if got := wert.Trace.String(); got != want {
+		t.Errorf("expected trace %q, got %q", want, got)
+	}
+	if wert.Line != 3 {
+		t.Errorf("expected wert at line 3, got %d", wert.Line)
+	}
+
+	// The script stops at an uncaught wert
+	env, err := interpret("var x = 1\nwert \"stop\"\nx = 2\n")
+	if err == nil {
+		t.Errorf("expected an error from an uncaught wert")
+	}
+	expectVar(t, env, "x", int64(1))
+}
+
+func TestMultipleReturns(t *testing.T) {
+	env := run(t, `
+import go (
+    "strconv"
+)
+
+func divmod(a, b) {
+    return a / b, a % b
+}
+q, r := divmod(7, 2)
+var a = 1
+var b = 2
+a, b = b, a
+var xs = [1, 2]
+var m = {k: 0}
+xs[0], m.k = divmod(9, 4)
+n, err := strconv.Atoi("nope")
+var failed = err != newt
+var msg = err.Error()
+`)
+	expectVar(t, env, "q", int64(3))
+	expectVar(t, env, "r", int64(1))
+	expectVar(t, env, "a", int64(2))
+	expectVar(t, env, "b", int64(1))
+	expectVar(t, env, "n", int64(0))
+	expectVar(t, env, "failed", true)
+	expectVar(t, env, "msg", `strconv.Atoi: parsing "nope": invalid syntax`)
+	xs, _ := env.Get("xs")
+	m, _ := env.Get("m")
+	if utils.Stringify(xs) != "[2, 2]" || utils.Stringify(m) != `{"k": 1}` {
+		t.Errorf("expected index targets to be assigned, got %s %s", utils.Stringify(xs), utils.Stringify(m))
+	}
+}
+
+func TestAssignmentMismatch(t *testing.T) {
+	for _, src := range []string{
+		"func two() {\n    return 1, 2\n}\na, b, c := two()\n",
+		"func two() {\n    return 1, 2\n}\nvar x = two()\n",
+		"a, b := 1, 2, 3\n",
+		"import go (\"strconv\")\nvar n = strconv.Atoi(\"1\")\n",
+	} {
+		err := execErr(t, src)
+		if g, ok := err.(*glorpups.RuntimeGlorpup); !ok || !strings.HasPrefix(g.Message, "Assignment mismatch") {
+			t.Errorf("expected assignment mismatch for %q, got %v", src, err)
+		}
+	}
+
+	// Nowhere but a destructure takes several values
+	two := "func two() {\n    return 1, 2\n}\n"
+	for _, src := range []string{
+		"var xs = [two()]\n",
+		"var m = {\"a\": two()}\n",
+		"var m = {two(): 1}\n",
+		"print len(two())\n",
+		"xs := [0]\nxs[0] = two()\n",
+	} {
+		err := execErr(t, two+src)
+		if g, ok := err.(*glorpups.RuntimeGlorpup); !ok || !strings.Contains(g.Message, "2") {
+			t.Errorf("expected a mismatch for %q, got %v", src, err)
+		}
+	}
+}
+
+func TestShortVarDecl(t *testing.T) {
+	env := run(t, `
+x := 1
+{
+    x := 2
+    y := x
+    x = 3
+}
+total := 0
+for i := 0; i < 4; i++ {
+    total += i
+}
+func f() {
+    x := 10
+    return x
+}
+fx := f()
+a, b := "a", "b"
+b, c := "b2", "c"
+`)
+	expectVar(t, env, "x", int64(1))
+	expectVar(t, env, "total", int64(6))
+	expectVar(t, env, "fx", int64(10))
+	expectVar(t, env, "a", "a")
+	expectVar(t, env, "b", "b2")
+	expectVar(t, env, "c", "c")
+	if _, err := env.Get("y"); err == nil {
+		t.Errorf("expected y to stay inside its block")
+	}
+}
+
+// A body is enclosed by the env it was declared in, not the one it was called from
+func TestClosures(t *testing.T) {
+	env := run(t, `
+var g = 1
+func read() {
+    return g
+}
+func outer() {
+    var x = 5
+    var g = 2
+    func inner(n) {
+        return n + x
+    }
+    func bump() {
+        x = x + 1
+    }
+    bump()
+    return inner(10), read()
+}
+fromInner, fromRead := outer()
+`)
+	expectVar(t, env, "fromInner", int64(16))
+	expectVar(t, env, "fromRead", int64(1))
+
+	// Not the caller's locals
+	err := execErr(t, "func read() {\n    return local\n}\n{\n    var local = 1\n    read()\n}\n")
+	if g, ok := err.(*glorpups.RuntimeGlorpup); !ok || !strings.HasPrefix(g.Message, "Undefined variable") {
+		t.Errorf("expected undefined variable, got %v", err)
+	}
+}
+
+func TestLocalRecursion(t *testing.T) {
+	env := run(t, `
+func outer() {
+    func fact(n) {
+        if n <= 1 {
+            return 1
+        }
+        return n * fact(n - 1)
+    }
+    return fact(4)
+}
+var got = outer()
+`)
+	expectVar(t, env, "got", int64(24))
+}
+
+func TestAssignRequiresBinding(t *testing.T) {
+	for _, src := range []string{"x = 1\n", "{\n    y = 1\n}\n", "a, b = 1, 2\n"} {
+		err := execErr(t, src)
+		if g, ok := err.(*glorpups.RuntimeGlorpup); !ok || !strings.HasPrefix(g.Message, "Undefined variable") {
+			t.Errorf("expected undefined variable for %q, got %v", src, err)
+		}
+	}
+}
+
+func TestSwitch(t *testing.T) {
+	env := run(t, `
+func dispatch(cmd) {
+    switch cmd {
+    case "up", "start":
+        return "up"
+    case "down":
+        return "down"
+    default:
+        return "usage"
+    }
+}
+var up = dispatch("start")
+var down = dispatch("down")
+var other = dispatch("status")
+var hits = 0
+switch 1 {
+case 1.0:
+    hits += 1
+case 1:
+    hits += 10
+}
+var size = ""
+x := 5
+switch {
+case x > 3: size = "big"
+case x > 1: size = "medium"
+}
+var none = "untouched"
+switch "nope" {
+case "yes": none = "touched"
+}
+`)
+	expectVar(t, env, "up", "up")
+	expectVar(t, env, "down", "down")
+	expectVar(t, env, "other", "usage")
+	expectVar(t, env, "hits", int64(1))
+	expectVar(t, env, "size", "big")
+	expectVar(t, env, "none", "untouched")
+}
+
+func TestSwitchPatterns(t *testing.T) {
+	env := run(t, `
+func route(args) {
+    switch args {
+    case ["down", iface]:
+        return "down " + iface
+    case ["route", _, ...rest]:
+        return rest
+    case [["nested", n]]:
+        return n
+    case []:
+        return "empty"
+    }
+    return "no match"
+}
+var down = route(["down", "wg0"])
+var rest = route(["route", "add", "a", "b"])
+var nested = route([["nested", 3]])
+var empty = route([])
+var short = route(["down"])
+var notList = route("down")
+`)
+	expectVar(t, env, "down", "down wg0")
+	expectVar(t, env, "nested", int64(3))
+	expectVar(t, env, "empty", "empty")
+	expectVar(t, env, "short", "no match")
+	expectVar(t, env, "notList", "no match")
+	rest, _ := env.Get("rest")
+	if utils.Stringify(rest) != `["a", "b"]` {
+		t.Errorf(`expected rest ["a", "b"], got %s`, utils.Stringify(rest))
+	}
+	if _, err := env.Get("iface"); err == nil {
+		t.Errorf("expected pattern bindings to stay in their case")
+	}
+}
+
+func TestStruct(t *testing.T) {
+	env := run(t, `
+struct Profile { host, port }
+struct Vpn {
+    name
+    profile
+}
+p := Profile("vpn.example", 1194)
+var host = p.host
+p.port = 443
+v := Vpn("work", p)
+v.profile.host = "other"
+var nested = v.profile.host
+var same = Profile("a", 1) == Profile("a", 1.0)
+var differs = Profile("a", 1) == Profile("a", 2)
+var shown = "${v}"
+`)
+	expectVar(t, env, "host", "vpn.example")
+	expectVar(t, env, "nested", "other")
+	expectVar(t, env, "same", true)
+	expectVar(t, env, "differs", false)
+	expectVar(t, env, "shown", `Vpn{name: "work", profile: Profile{host: "other", port: 443}}`)
+}
+
+func TestStructErrors(t *testing.T) {
+	for _, src := range []string{
+		"struct P { a }\np := P(1)\nprint p.b\n",
+		"struct P { a }\np := P(1)\np.b = 2\n",
+		"struct P { a }\np := P(1)\nprint p[0]\n",
+	} {
+		if _, ok := execErr(t, src).(*glorpups.TypeGlorpup); !ok {
+			t.Errorf("expected TypeGlorpup for %q", src)
+		}
+	}
+}
+
+func TestCallArgs(t *testing.T) {
+	env := run(t, `
+func greet(name, greeting = "hi", punct = greeting + "!") {
+    return "${greeting} ${name}${punct}"
+}
+func sum(first, ...rest) {
+    var total = first
+    for x in rest { total += x }
+    return total
+}
+func count(...xs) {
+    return len(xs)
+}
+struct Profile { host, port }
+var plain = greet("bob")
+var named = greet("bob", punct="?")
+var reordered = greet(greeting="yo", name="al")
+var one = sum(1)
+var many = sum(1, 2, 3)
+xs := [4, 5, 6]
+var spread = sum(...xs)
+var mixed = sum(0, ...xs, 10)
+var none = count()
+var empty = count(...[])
+p := Profile(port=1194, host="vpn")
+var host = p.host
+`)
+	expectVar(t, env, "plain", "hi bobhi!")
+	expectVar(t, env, "named", "hi bob?")
+	expectVar(t, env, "reordered", "yo alyo!")
+	expectVar(t, env, "one", int64(1))
+	expectVar(t, env, "many", int64(6))
+	expectVar(t, env, "spread", int64(15))
+	expectVar(t, env, "mixed", int64(25))
+	expectVar(t, env, "none", int64(0))
+	expectVar(t, env, "empty", int64(0))
+	expectVar(t, env, "host", "vpn")
+}
+
+func TestCallArgErrors(t *testing.T) {
+	for _, src := range []string{
+		"func f(a, b = 1) {}\nf()\n",
+		"func f(a, b = 1) {}\nf(1, 2, 3)\n",
+		"func f(a) {}\nf(...[1, 2])\n",
+	} {
+		if _, ok := execErr(t, src).(*glorpups.ArityGlorpup); !ok {
+			t.Errorf("expected ArityGlorpup for %q", src)
+		}
+	}
+	for _, src := range []string{
+		"func f(a) {}\nf(1, a=2)\n",
+		"func f(a) {}\nf(b=2)\n",
+		"func f(...xs) {}\nf(xs=[1])\n",
+		"func f(a) {}\nf(...5)\n",
+		"len(x=[1])\n",
+		"struct P { a }\nP(b=1)\n",
+	} {
+		if _, ok := execErr(t, src).(*glorpups.TypeGlorpup); !ok {
+			t.Errorf("expected TypeGlorpup for %q", src)
+		}
+	}
+}
+
+// Every script in testdata/calls is a call that can't work, its first line says which glorpup it should be
+// None of them may take the interpreter down with a panic
+func TestMalformedCalls(t *testing.T) {
+	files, err := filepath.Glob("testdata/calls/*.hyp")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no malformed call scripts: %v", err)
+	}
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			src, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := strings.TrimSpace(strings.TrimPrefix(strings.SplitN(string(src), "\n", 2)[0], "// want:"))
+			defer func() {
+				if r := recover(); r != nil {
+					t.Fatalf("panicked: %v", r)
+				}
+			}()
+			err = execErr(t, string(src))
+			if err == nil {
+				t.Fatalf("expected %s, got no error", want)
+			}
+			if got := strings.TrimPrefix(fmt.Sprintf("%T", err), "*glorpups."); got != want {
+				t.Errorf("expected %s, got %s: %v", want, got, err)
+			}
+		})
+	}
+}
+
+func TestGlorpupTrace(t *testing.T) {
+	interp, err := interpretFile("vpn.hyp", `
+func down(iface) {
+    return iface.name()
+}
+func toggle(iface) {
+    if iface {
+        return down(iface)
+    }
+}
+toggle(5)
+`)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	g, ok := err.(*glorpups.TypeGlorpup)
+	if !ok {
+		t.Fatalf("expected TypeGlorpup, got %T: %v", err, err)
+	}
+	want := "\n    at down (vpn.hyp:3)\n    at toggle (vpn.hyp:7)\n    at <script> (vpn.hyp:10)"
+	if got := g.Trace.String(); got != want {
+		t.Errorf("expected trace %q, got %q", want, got)
+	}
+	if len(interp.Frames) != 0 {
+		t.Errorf("expected an empty call stack after the error, got %v", interp.Frames)
+	}
+
+	// An error inside woops keeps what it was handling as Previous, each with its own trace
+	_, err = interpretFile("vpn.hyp", `
+func retry() {
+    try {
+        len(1, 2)
+    } woops {
+        5()
+    }
+}
+retry()
+`)
+	g, ok = err.(*glorpups.TypeGlorpup)
+	if !ok {
+		t.Fatalf("expected TypeGlorpup, got %T: %v", err, err)
+	}
+	prev, ok := g.Previous.(*glorpups.ArityGlorpup)
+	if !ok {
+		t.Fatalf("expected the ArityGlorpup being handled as Previous, got %v", g.Previous)
+	}
+	if got := prev.Trace.String(); got != "\n    at retry (vpn.hyp:4)\n    at <script> (vpn.hyp:9)" {
+		t.Errorf("unexpected previous trace %q", got)
+	}
+	if !strings.Contains(err.Error(), "at retry (vpn.hyp:6)") || !strings.Contains(err.Error(), "at retry (vpn.hyp:4)") {
+		t.Errorf("expected both traces in %q", err.Error())
+	}
+}
+
+// Runs src as if it were the file name and hands back the first error unwrapped, traced like an uncaught one
+func interpretFile(name, src string) (*Interpreter, error) {
+	env := environment.NewEnvironment(nil)
+	tokens, err := scanner.NewScanner().ScanTokens(src)
+	if err != nil {
+		return nil, err
+	}
+	stmts, err := parser.NewParser(env).ParseTokens(tokens)
+	if err != nil {
+		return nil, err
+	}
+	interp := NewInterpreter(env).(*Interpreter)
+	interp.SetFile(name)
+	for _, stmt := range stmts {
+		if err := interp.execute(stmt); err != nil {
+			interp.trace(err)
+			return interp, err
+		}
+	}
+	return interp, nil
+}
+
+func TestRuntimeDiagnostics(t *testing.T) {
+	_, err := interpret(`
+func down(iface) {
+    return iface.name()
+}
+down(5)
+print [1][3]
+wert "stop"
+print "never"
+`)
+	d, ok := err.(*diag.Diagnostics)
+	if !ok {
+		t.Fatalf("expected diagnostics, got %T: %v", err, err)
+	}
+	if d.Len() != 3 {
+		t.Fatalf("expected a diagnostic for each failed statement up to the wert, got %v", d)
+	}
+	want := []struct {
+		code  string
+		at    string
+		notes []string
+	}{
+		{diag.Type, "<stdin>:3:18", []string{"at down (<stdin>:3)", "at <script> (<stdin>:5)"}},
+		{diag.IndexBounds, "<stdin>:6:10", []string{"at <script> (<stdin>:6)"}},
+		{diag.Wert, "<stdin>:7:1", []string{"at <script> (<stdin>:7)"}},
+	}
+	for i, w := range want {
+		got := d.List[i]
+		if got.Code != w.code || got.Span.String() != w.at || len(got.Notes) != len(w.notes) {
+			t.Errorf("diagnostic %d: expected %s at %s, got %v %v", i, w.code, w.at, got, got.Notes)
+			continue
+		}
+		for j, note := range got.Notes {
+			if note.Message != w.notes[j] {
+				t.Errorf("diagnostic %d note %d: expected %q, got %q", i, j, w.notes[j], note.Message)
+			}
+		}
+	}
+}
+
+// Imports and member access fail like any other statement, with a span and catchable by try
+func TestImportAndAccessDiagnostics(t *testing.T) {
+	_, err := interpret(`import go (
+    "nonexistent/pkg"
+)
+import py (
+    "os"
+)
+var x = 1
+print x.5
+`)
+	d, ok := err.(*diag.Diagnostics)
+	if !ok || d.Len() != 3 {
+		t.Fatalf("expected a diagnostic for each failed statement, got %T: %v", err, err)
+	}
+	for i, at := range []string{"<stdin>:2:5", "<stdin>:4:8", "<stdin>:8:9"} {
+		if got := d.List[i]; got.Code != diag.Runtime || got.Span.String() != at {
+			t.Errorf("diagnostic %d: expected runtime at %s, got %v", i, at, got)
+		}
+	}
+
+	env := run(t, `var kind = ""
+try {
+    import go (
+        "nonexistent/pkg"
+    )
+} woops err {
+    kind = err.kind
+}
+`)
+	expectVar(t, env, "kind", "Runtime")
+}
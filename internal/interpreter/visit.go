@@ -1,17 +1,31 @@
 package interpreter
 
 import (
+	"cmp"
 	"fmt"
 	"hype-script/internal/environment"
 	herror "hype-script/internal/error"
+	"hype-script/internal/glorpups"
 	"hype-script/internal/native"
 	"hype-script/internal/token"
 	"hype-script/internal/types"
 	"hype-script/internal/utils"
-	"reflect"
+	"math"
+	"path"
+	"strings"
 )
 
 func (i *Interpreter) VisitBinaryExpr(expr *types.BinaryExpr) (any, error) {
+	if op, ok := compoundOps[expr.Operator.Type]; ok {
+		return i.update(expr.Left, expr.Operator, func(old any) (any, error) {
+			right, err := i.evaluate(expr.Right)
+			if err != nil {
+				return nil, err
+			}
+			return i.arithmetic(expr.Operator, op, old, right)
+		})
+	}
+
 	left, err := i.evaluate(expr.Left)
 	if err != nil {
 		return nil, err
@@ -23,88 +37,261 @@ func (i *Interpreter) VisitBinaryExpr(expr *types.BinaryExpr) (any, error) {
 	}
 
 	switch expr.Operator.Type {
-	case token.MINUS:
-		l, r, err := checkNumberOperands(expr.Operator, left, right)
-		if err != nil {
-			return nil, err
+	case token.PLUS, token.MINUS, token.SLASH, token.STAR, token.PERCENT, token.STAR_STAR, token.TILDE_SLASH:
+		return i.arithmetic(expr.Operator, expr.Operator.Type, left, right)
+	case token.AMPERSAND, token.PIPE, token.LESS_LESS, token.GREATER_GREATER:
+		return i.bitwise(expr.Operator, left, right)
+	case token.GREATER, token.GREATER_EQUAL, token.LESS, token.LESS_EQUAL:
+		return i.compare(expr.Operator, left, right)
+	case token.BANG_EQUAL:
+		return !i.isEqual(left, right), nil
+	case token.EQUAL_EQUAL:
+		return i.isEqual(left, right), nil
+	}
+
+	return utils.Parenthesize(i, expr.Operator.Lexeme, expr.Left, expr.Right)
+}
+
+// The plain operator each compound assignment applies before assigning
+var compoundOps = map[token.TokenType]token.TokenType{
+	token.PLUS_EQUAL:  token.PLUS,
+	token.MINUS_EQUAL: token.MINUS,
+	token.STAR_EQUAL:  token.STAR,
+	token.SLASH_EQUAL: token.SLASH,
+}
+
+// Two ints stay an int, anything involving a float promotes to float
+// int / int truncates like Go, use a float operand for a float result
+func (i *Interpreter) arithmetic(operator token.Token, op token.TokenType, left, right any) (any, error) {
+	if op == token.PLUS {
+		if lg, rg, ok := native.ConvGlist(left, right); ok {
+			return lg.Concat(rg), nil
 		}
+		ls, lok := left.(string)
+		rs, rok := right.(string)
+		if lok && rok {
+			return ls + rs, nil // If they are both strings then concat
+		}
+		if !utils.IsNumber(left) || !utils.IsNumber(right) {
+			return nil, glorpups.NewTypeGlorpup(operator, fmt.Sprintf("Can't add %s and %s.", native.TypeName(left), native.TypeName(right)), nil)
+		}
+	}
+
+	if l, r, ok := utils.ConvInt(left, right); ok {
+		switch op {
+		case token.PLUS:
+			return l + r, nil
+		case token.MINUS:
+			return l - r, nil
+		case token.STAR:
+			return l * r, nil
+		case token.SLASH, token.TILDE_SLASH, token.PERCENT:
+			if r == 0 {
+				return nil, glorpups.NewRuntimeGlorpup(operator, "Integer division by zero.", nil)
+			}
+			if op == token.PERCENT {
+				return l % r, nil
+			}
+			return l / r, nil
+		case token.STAR_STAR:
+			if r >= 0 { // A negative power is a fraction, leave it to the float path
+				pow, ok := intPow(l, r)
+				if !ok {
+					return nil, glorpups.NewRuntimeGlorpup(operator, fmt.Sprintf("%d ** %d does not fit in an int.", l, r), nil)
+				}
+				return pow, nil
+			}
+		}
+	}
+
+	l, r, err := checkNumberOperands(operator, left, right)
+	if err != nil {
+		return nil, err
+	}
+	switch op {
+	case token.PLUS:
+		return l + r, nil
+	case token.MINUS:
 		return l - r, nil
+	case token.STAR:
+		return l * r, nil
 	case token.SLASH:
-		l, r, err := checkNumberOperands(expr.Operator, left, right)
-		if err != nil {
-			return nil, err
-		}
 		return l / r, nil
-	case token.STAR:
-		l, r, err := checkNumberOperands(expr.Operator, left, right)
+	case token.PERCENT:
+		return math.Mod(l, r), nil
+	case token.STAR_STAR:
+		return math.Pow(l, r), nil
+	case token.TILDE_SLASH:
+		// ~/ always gives an int, truncated towards zero like int /
+		if r == 0 {
+			return nil, glorpups.NewRuntimeGlorpup(operator, "Integer division by zero.", nil)
+		}
+		q, ok := utils.IsWhole(math.Trunc(l / r))
+		if !ok {
+			return nil, glorpups.NewRuntimeGlorpup(operator, fmt.Sprintf("%s ~/ %s does not fit in an int.", utils.FormatFloat(l), utils.FormatFloat(r)), nil)
+		}
+		return q, nil
+	}
+	return nil, glorpups.NewRuntimeGlorpup(operator, fmt.Sprintf("Unknown operator '%s'.", operator.Lexeme), nil)
+}
+
+// Exponentiation by squaring, so big int powers stay exact, false when the result doesn't fit in an int
+func intPow(base, exp int64) (int64, bool) {
+	result := int64(1)
+	ok := true
+	for exp > 0 {
+		if exp&1 == 1 {
+			if result, ok = mulInt(result, base); !ok {
+				return 0, false
+			}
+		}
+		exp >>= 1
+		if exp > 0 { // Squaring past the last bit could overflow on a base the result never needs
+			if base, ok = mulInt(base, base); !ok {
+				return 0, false
+			}
+		}
+	}
+	return result, true
+}
+
+// a * b, false if it overflowed
+func mulInt(a, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	c := a * b
+	if c/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return 0, false
+	}
+	return c, true
+}
+
+// & | << >> only make sense on ints, a float operand is a TypeGlorpup
+func (i *Interpreter) bitwise(operator token.Token, left, right any) (any, error) {
+	l, r, ok := utils.ConvInt(left, right)
+	if !ok {
+		return nil, glorpups.NewTypeGlorpup(operator, fmt.Sprintf("Operands of '%s' must be ints, got %s and %s.", operator.Lexeme, native.TypeName(left), native.TypeName(right)), nil)
+	}
+	switch operator.Type {
+	case token.AMPERSAND:
+		return l & r, nil
+	case token.PIPE:
+		return l | r, nil
+	}
+	if r < 0 {
+		return nil, glorpups.NewRuntimeGlorpup(operator, fmt.Sprintf("Negative shift count %d.", r), nil)
+	}
+	if r >= 64 {
+		return nil, glorpups.NewRuntimeGlorpup(operator, fmt.Sprintf("Shift count %d is too big for an int.", r), nil)
+	}
+	if operator.Type == token.LESS_LESS {
+		// Shifting back has to give l again, otherwise bits or the sign fell off the top
+		if shifted := l << r; shifted>>r == l {
+			return shifted, nil
+		}
+		return nil, glorpups.NewRuntimeGlorpup(operator, fmt.Sprintf("%d << %d does not fit in an int.", l, r), nil)
+	}
+	return l >> r, nil
+}
+
+// Ints compare exactly, mixed numbers compare as floats and strings compare by bytes
+func (i *Interpreter) compare(operator token.Token, left, right any) (bool, error) {
+	var c int
+	ls, lok := left.(string)
+	rs, rok := right.(string)
+	if l, r, ok := utils.ConvInt(left, right); ok {
+		c = cmp.Compare(l, r)
+	} else if lok && rok {
+		c = strings.Compare(ls, rs)
+	} else {
+		l, r, err := checkNumberOperands(operator, left, right)
 		if err != nil {
-			return nil, err
+			return false, err
 		}
-		return l * r, nil
+		c = cmp.Compare(l, r)
+	}
+
+	switch operator.Type {
 	case token.GREATER:
-		l, r, err := checkNumberOperands(expr.Operator, left, right)
+		return c > 0, nil
+	case token.GREATER_EQUAL:
+		return c >= 0, nil
+	case token.LESS:
+		return c < 0, nil
+	}
+	return c <= 0, nil
+}
+
+// Reads target, works out its new value with apply and writes it back, for x += 1 and x++
+// The receiver and index are only evaluated once, so xs[next()] += 1 calls next once
+func (i *Interpreter) update(target types.Expr, op token.Token, apply func(old any) (any, error)) (any, error) {
+	var receiver, index any
+	var tok token.Token
+	var field bool // p.port, read the way member access reads it
+	var err error
+	switch t := target.(type) {
+	case *types.VarExpr:
+		old, err := i.evaluate(t)
 		if err != nil {
 			return nil, err
 		}
-		return l > r, nil
-	case token.GREATER_EQUAL:
-		l, r, err := checkNumberOperands(expr.Operator, left, right)
+		val, err := apply(old)
 		if err != nil {
 			return nil, err
 		}
-		return l >= r, nil
-	case token.LESS:
-		l, r, err := checkNumberOperands(expr.Operator, left, right)
-		if err != nil {
+		return val, i.Environment.Assign(t.Name.Lexeme, val)
+	case *types.IndexExpr: // xs[0] += 1
+		if receiver, err = i.evaluate(t.Expr); err != nil {
 			return nil, err
 		}
-		return l < r, nil
-	case token.LESS_EQUAL:
-		l, r, err := checkNumberOperands(expr.Operator, left, right)
-		if err != nil {
+		if index, err = i.evaluate(t.Index); err != nil {
 			return nil, err
 		}
-		return l <= r, nil
-	case token.BANG_EQUAL:
-		return !i.isEqual(left, right), nil
-	case token.PLUS_EQUAL, token.MINUS_EQUAL, token.STAR_EQUAL, token.SLASH_EQUAL:
-		l, r, ok := utils.ConvFloat(left, right) // See if it is int
-		if !ok {
-			break
+		tok = t.Bracket
+	case *types.AccessExpr: // p.port += 1 and p.ports[0] += 1
+		base, err := i.evaluate(t.Exprs[0])
+		if err != nil {
+			return nil, err
 		}
-		var val any
-		switch expr.Operator.Type {
-		case token.PLUS_EQUAL:
-			val = l + r
-		case token.MINUS_EQUAL:
-			val = l - r
-		case token.STAR_EQUAL:
-			val = l * r
-		case token.SLASH_EQUAL:
-			val = l / r
-		}
-		if err := i.postfixAssign(expr.Left, val); err != nil { // Attempt to assign to var if one exists
+		if base, err = i.accessMembers(base, t.Exprs[1:len(t.Exprs)-1]); err != nil {
 			return nil, err
 		}
-		return val, nil
-	case token.PLUS:
-		l, r, ok := utils.ConvFloat(left, right) // See if it is int
-		if ok {
-			return l + r, nil
-		} else if reflect.TypeOf(left).Kind().String() == "string" && reflect.TypeOf(right).Kind().String() == "string" {
-			return fmt.Sprintf("%v", left) + fmt.Sprintf("%v", right), nil // If they are both strings then concat
+		switch last := t.Exprs[len(t.Exprs)-1].(type) {
+		case *types.VarExpr:
+			receiver, index, tok, field = base, last.Name.Lexeme, last.Name, true
+		case *types.IndexExpr:
+			if receiver, err = i.accessMember(base, last.Expr); err != nil {
+				return nil, err
+			}
+			if index, err = i.evaluate(last.Index); err != nil {
+				return nil, err
+			}
+			tok = last.Bracket
+		default:
+			return nil, glorpups.NewRuntimeGlorpup(op, "Invalid assignment target.", nil)
 		}
+	default:
+		return nil, glorpups.NewRuntimeGlorpup(op, "Invalid assignment target.", nil)
 	}
 
-	return utils.Parenthesize(i, expr.Operator.Lexeme, expr.Left, expr.Right)
-}
-
-func (i *Interpreter) postfixAssign(expr types.Expr, val any) error {
-	variable, ok := expr.(*types.VarExpr)
-	if ok {
-		return i.Environment.Assign(variable.Name.Lexeme, val)
+	var old any
+	if field {
+		old, err = i.member(receiver, tok)
+	} else {
+		old, err = i.indexValue(receiver, index, tok)
 	}
-	return nil
+	if err != nil {
+		return nil, err
+	}
+	val, err := apply(old)
+	if err != nil {
+		return nil, err
+	}
+	if err := i.setIndex(receiver, index, val, tok); err != nil {
+		return nil, err
+	}
+	return val, nil
 }
 
 func (i *Interpreter) VisitUnaryExpr(expr *types.UnaryExpr) (any, error) {
@@ -117,10 +304,13 @@ func (i *Interpreter) VisitUnaryExpr(expr *types.UnaryExpr) (any, error) {
 	case token.BANG:
 		return !i.isTruthy(right), nil // If the expression on the right is
 	case token.MINUS:
-		val, ok := right.(float64)
-		if ok {
+		switch val := right.(type) {
+		case int64:
+			return -val, nil
+		case float64:
 			return -val, nil
 		}
+		return nil, checkNumberOperand(expr.Operator, right)
 	case token.KARAT:
 		fmt.Println("Got karat")
 	}
@@ -129,38 +319,17 @@ func (i *Interpreter) VisitUnaryExpr(expr *types.UnaryExpr) (any, error) {
 }
 
 func (i *Interpreter) VisitPostfixExpr(expr *types.PostfixExpr) (any, error) {
-	var val float64
-	var ok bool
-
-	// Find actual value of value of expr to perform oper on (i in i++)
-	left, err := i.evaluate(expr.Val)
-	if err != nil {
-		return nil, err
+	// Do operation, ints stay ints
+	op := token.PLUS
+	if expr.Operator.Type == token.MINUS_MINUS {
+		op = token.MINUS
 	}
-
-	// Do operation
-	switch expr.Operator.Type {
-	case token.PLUS_PLUS:
-		val, ok = left.(float64)
-		if ok {
-			val = val + 1
-		}
-	case token.MINUS_MINUS:
-		val, ok = left.(float64)
-		if ok {
-			val = val - 1
-		}
-	}
-
-	// If expr is a variable, reassign the variable
-	variable, ok := expr.Val.(*types.VarExpr)
-	if ok {
-		if err = i.Environment.Assign(variable.Name.Lexeme, val); err != nil {
+	return i.update(expr.Val, expr.Operator, func(old any) (any, error) {
+		if err := checkNumberOperand(expr.Operator, old); err != nil {
 			return nil, err
 		}
-	}
-
-	return utils.Parenthesize(i, expr.Operator.Lexeme, expr.Val)
+		return i.arithmetic(expr.Operator, op, old, int64(1))
+	})
 }
 
 // Recursively looks through layered parens
@@ -190,35 +359,79 @@ func (i *Interpreter) VisitCallExpr(expr *types.CallExpr) (any, error) {
 		return nil, err
 	}
 
+	fun, ok := callee.(native.Callable)
+	if !ok {
+		return nil, glorpups.NewTypeGlorpup(expr.Paren, fmt.Sprintf("Unable to call %s.", native.TypeName(callee)), nil)
+	}
+	return i.call(fun, expr)
+}
+
+// Evaluates the args of a call, spreads them out and binds them to what fun takes before calling it
+func (i *Interpreter) call(fun native.Callable, expr *types.CallExpr) (any, error) {
 	var args []any
 	for _, arg := range expr.Args {
-		val, err := i.evaluate(arg)
+		spread, ok := arg.(*types.SpreadExpr)
+		if !ok {
+			val, err := i.evaluate(arg)
+			if err != nil {
+				return nil, err
+			}
+			if err := singleIn(val, expr.Paren, "an argument"); err != nil {
+				return nil, err
+			}
+			args = append(args, val)
+			continue
+		}
+		val, err := i.evaluate(spread.Expr)
 		if err != nil {
 			return nil, err
 		}
-		args = append(args, val)
+		switch v := val.(type) {
+		case *native.Glist:
+			args = append(args, v.Items...)
+		case *native.Tuple:
+			args = append(args, v.Items...)
+		default:
+			return nil, glorpups.NewTypeGlorpup(spread.Token, fmt.Sprintf("Can only spread a glist into a call, got %s.", native.TypeName(val)), nil)
+		}
 	}
 
-	fun, ok := callee.(native.Callable)
-	if !ok {
-		herror.InterpreterRuntimeError(expr.Paren, fmt.Sprintf("Expected identifier, got type %T", fun))
+	var named []native.NamedArg
+	for _, arg := range expr.Named {
+		val, err := i.evaluate(arg.Val)
+		if err != nil {
+			return nil, err
+		}
+		if err := singleIn(val, arg.Name, "an argument"); err != nil {
+			return nil, err
+		}
+		named = append(named, native.NamedArg{Name: arg.Name, Val: val})
+	}
+
+	args, err := native.BindArgs(fun, args, named, expr.Paren)
+	if err != nil {
+		return nil, err
 	}
 
-	// Check that the function has the right amount of args passed, args same len as params
-	if len(args) != fun.Arity() {
-		herror.InterpreterRuntimeError(expr.Paren, fmt.Sprintf("Expected %d args but got %d.", fun.Arity(), len(args)))
+	if f, ok := fun.(*native.GlorpFunction); ok {
+		i.Frames = append(i.Frames, callFrame{Name: f.Declaration.Name.Lexeme, Call: expr.Paren})
+		defer func() {
+			i.Frames = i.Frames[:len(i.Frames)-1]
+		}()
 	}
 
 	x, err := fun.Call(i, args)
-	if err != nil {
-		switch err.(type) {
-		case *herror.WertErr:
-		}
-	}
+	err = glorpups.WithToken(err, expr.Paren)
+	i.trace(err)
 
 	return x, err
 }
 
+// Spreads are only taken apart by call, one anywhere else has nothing to spread into
+func (i *Interpreter) VisitSpreadExpr(expr *types.SpreadExpr) (any, error) {
+	return nil, glorpups.NewRuntimeGlorpup(expr.Token, "Can only spread into a call.", nil)
+}
+
 func (i *Interpreter) VisitExprStmt(stmt *types.Expression) error {
 	_, err := i.evaluate(stmt.Expr)
 	return err
@@ -253,6 +466,9 @@ func (i *Interpreter) VisitVarStmt(stmt *types.Var) error {
 		if err != nil {
 			return err
 		}
+		if err := single(val, stmt.Name); err != nil {
+			return err
+		}
 	}
 	// A variable without an initializer is declared but NOT assigned, error to access before assignment
 	// But here, we auto assign the var to nil
@@ -270,8 +486,18 @@ func (i *Interpreter) VisitVarStmt(stmt *types.Var) error {
 
 func (i *Interpreter) VisitFunStmt(stmt *types.Fun) error {
 	// Take fun syntax node
-	function := native.NewGlorpFunction(*stmt)
-	i.Environment.Define(stmt.Name.Lexeme, function) // Add function to global environment by name, can be used anywhere now
+	// Closes over the env it's declared in, so a nested function sees the locals around it and itself
+	function := native.NewGlorpFunction(*stmt, i.Environment)
+	i.Environment.Define(stmt.Name.Lexeme, function)
+	return nil
+}
+
+func (i *Interpreter) VisitStructStmt(stmt *types.Struct) error {
+	fields := make([]string, len(stmt.Fields))
+	for idx, field := range stmt.Fields {
+		fields[idx] = field.Lexeme
+	}
+	i.Environment.Define(stmt.Name.Lexeme, native.NewStructType(stmt.Name.Lexeme, fields))
 	return nil
 }
 
@@ -290,12 +516,158 @@ func (i *Interpreter) VisitWhileStmt(stmt *types.While) error {
 			break
 		}
 
-		err = i.execute(stmt.Body)
+		stop, err := i.loopSignal(i.execute(stmt.Body), stmt.Label)
+		if stop || err != nil {
+			return err
+		}
+
+		if stmt.Increment != nil {
+			if _, err = i.evaluate(stmt.Increment); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// for k in m { }, each iter gets its own env holding the loop var
+func (i *Interpreter) VisitForInStmt(stmt *types.ForIn) error {
+	iterable, err := i.evaluate(stmt.Iterable)
+	if err != nil {
+		return err
+	}
+	items, err := i.iterate(iterable, stmt.Name)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		env := environment.NewEnvironment(i.Environment)
+		env.Define(stmt.Name.Lexeme, item)
+		stop, err := i.loopSignal(i.ExecuteBlock([]types.Stmt{stmt.Body}, env), stmt.Label)
+		if stop || err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Sorts out what a loop does with the error from running its body once
+// stop means leave the loop, a non nil err has to keep unwinding past it
+// An unlabelled break/continue belongs to the innermost loop, a labelled one keeps unwinding till its loop
+func (i *Interpreter) loopSignal(err error, label token.Token) (bool, error) {
+	switch sig := err.(type) {
+	case nil:
+		return false, nil
+	case *herror.BreakErr:
+		if sig.Label == "" || sig.Label == label.Lexeme {
+			return true, nil
+		}
+	case *herror.ContinueErr:
+		if sig.Label == "" || sig.Label == label.Lexeme {
+			return false, nil
+		}
+	}
+	return true, err
+}
+
+func (i *Interpreter) VisitBreakStmt(stmt *types.Break) error {
+	return herror.NewBreakErr(stmt.Label.Lexeme)
+}
+
+func (i *Interpreter) VisitContinueStmt(stmt *types.Continue) error {
+	return herror.NewContinueErr(stmt.Label.Lexeme)
+}
+
+// Anything but return, break and continue unwinding through the try lands in woops
+func (i *Interpreter) VisitTryStmt(stmt *types.Try) error {
+	err := i.execute(stmt.Attempt)
+	switch err.(type) {
+	case nil, *herror.ReturnErr, *herror.BreakErr, *herror.ContinueErr:
+		return err
+	}
+
+	env := environment.NewEnvironment(i.Environment)
+	if stmt.Name.Lexeme != "" {
+		env.Define(stmt.Name.Lexeme, native.WoopsFrom(err))
+	}
+	handled := i.ExecuteBlock(stmt.Woops.(*types.Block).Statements, env)
+	if caught, ok := err.(glorpups.Glorpup); ok && handled != nil {
+		// Failing inside woops still reports what it was handling, traced from where it was caught
+		i.trace(caught)
+		handled = glorpups.WithPrevious(handled, caught)
+	}
+	return handled
+}
+
+// wert err rethrows a caught error as is, any other value becomes a new Runtime one
+func (i *Interpreter) VisitWertStmt(stmt *types.Wert) error {
+	val, err := i.evaluate(stmt.Val)
+	if err != nil {
+		return err
+	}
+	woops, ok := val.(*native.Woops)
+	if !ok {
+		woops = native.NewWoops("Runtime", utils.Stringify(val), stmt.Keyword.Line, val)
+	}
+	wert := herror.NewWertErr(woops, stmt.Keyword.Line)
+	wert.Span = stmt.Span()
+	return wert
+}
+
+// Every value is worked out before any target is assigned, so a, b = b, a swaps
+func (i *Interpreter) VisitDestructureStmt(stmt *types.Destructure) error {
+	var vals []any
+	for _, expr := range stmt.Vals {
+		val, err := i.evaluate(expr)
 		if err != nil {
 			return err
 		}
+		if tuple, ok := val.(*native.Tuple); ok && len(stmt.Vals) == 1 {
+			vals = tuple.Items
+			break
+		}
+		if err := single(val, stmt.Op); err != nil {
+			return err
+		}
+		vals = append(vals, val)
+	}
+	if len(vals) != len(stmt.Targets) {
+		return glorpups.NewRuntimeGlorpup(stmt.Op, fmt.Sprintf("Assignment mismatch: %d variables but %d values.", len(stmt.Targets), len(vals)), nil)
 	}
 
+	for idx, target := range stmt.Targets {
+		if err := i.assignTo(target, vals[idx], stmt.Op); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// The first case with a matching value or pattern runs, then the switch is done
+func (i *Interpreter) VisitSwitchStmt(stmt *types.Switch) error {
+	subject, err := i.evaluate(stmt.Subject)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range stmt.Cases {
+		for _, pattern := range c.Patterns {
+			env := environment.NewEnvironment(i.Environment) // Holds what the pattern binds
+			matched, err := i.matchPattern(pattern, subject, env)
+			if err != nil {
+				return err
+			}
+			if matched {
+				return i.ExecuteBlock(c.Body, env)
+			}
+		}
+	}
+
+	if stmt.Default != nil {
+		return i.ExecuteBlock(stmt.Default.Body, environment.NewEnvironment(i.Environment))
+	}
 	return nil
 }
 
@@ -312,26 +684,31 @@ func (i *Interpreter) VisitIfStmt(stmt *types.If) error {
 			return err
 		}
 	} else if stmt.Final != nil { // Final (else keyword is taken in Go)
-		i.execute(stmt.Final)
+		return i.execute(stmt.Final)
 	}
 	return nil
 }
 
 func (i *Interpreter) VisitImportStmt(expr *types.Import) error {
-	fmt.Println("Visited import stmt in inter")
 	switch expr.Lang.Lexeme {
 	case "go":
-		// Add aliases to go env
+		// Each package is a hype value under its alias, members are looked up through yaegi
 		for _, item := range expr.Imports {
-			i.GoEnvironment.Define(item.Alias.Lexeme, item.Val.Lexeme)
-			_, err := i.GoInterpreter.Eval(fmt.Sprintf("import \"%s\"", item.Val.Literal.String()))
+			pkgPath := item.Val.Literal.String()
+			alias := path.Base(pkgPath)
+			if item.Alias.Type == token.IDENTIFIER {
+				alias = item.Alias.Lexeme
+			}
+			_, err := i.GoInterpreter.Eval(fmt.Sprintf("import %s %q", alias, pkgPath))
 			if err != nil {
-				return fmt.Errorf("error in evaluating go source code: %w", err)
+				return glorpups.NewRuntimeGlorpup(item.Val, fmt.Sprintf("Can't import Go package \"%s\": %s", pkgPath, err), nil)
 			}
+			i.GoEnvironment.Define(alias, pkgPath)
+			i.Environment.Define(alias, native.NewGoPackage(alias, pkgPath))
 		}
 	case "hype":
 	default:
-		return fmt.Errorf("unexpected language type for import: %s", &expr.Lang)
+		return glorpups.NewRuntimeGlorpup(expr.Lang, fmt.Sprintf("Can't import from '%s', only go and hype.", expr.Lang.Lexeme), nil)
 	}
 	return nil
 }
@@ -341,36 +718,134 @@ func (i *Interpreter) VisitAssignExpr(expr *types.AssignExpr) (any, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := single(val, expr.Name); err != nil {
+		return nil, err
+	}
+	// = never declares, use := or var for that
 	err = i.Environment.Assign(expr.Name.Lexeme, val)
 	if err != nil {
-		return nil, err
+		return nil, glorpups.NewRuntimeGlorpup(expr.Name, fmt.Sprintf("Undefined variable '%s'.", expr.Name.Lexeme), nil)
 	}
 	return val, nil
 }
 
 func (i *Interpreter) VisitVarExpr(expr *types.VarExpr) (any, error) {
-	return i.Environment.Get(expr.Name.Lexeme)
+	val, err := i.Environment.Get(expr.Name.Lexeme)
+	if err != nil {
+		return nil, glorpups.NewRuntimeGlorpup(expr.Name, fmt.Sprintf("Undefined variable '%s'.", expr.Name.Lexeme), nil)
+	}
+	return val, nil
 }
 
+// Items are evaluated as soon as the glist is, in order
 func (i *Interpreter) VisitGlistExpr(expr *types.GlistExpr) (any, error) {
-	return expr.Data, nil
+	items := make([]any, 0, len(expr.Data))
+	for _, e := range expr.Data {
+		item, err := i.evaluate(e)
+		if err != nil {
+			return nil, err
+		}
+		if err := singleIn(item, expr.Token, "a glist item"); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return native.NewGlist(items), nil
+}
+
+func (i *Interpreter) VisitSliceExpr(expr *types.SliceExpr) (any, error) {
+	receiver, err := i.evaluate(expr.Expr)
+	if err != nil {
+		return nil, err
+	}
+	var start, end any
+	if expr.Start != nil {
+		if start, err = i.evaluate(expr.Start); err != nil {
+			return nil, err
+		}
+	}
+	if expr.End != nil {
+		if end, err = i.evaluate(expr.End); err != nil {
+			return nil, err
+		}
+	}
+
+	switch r := receiver.(type) {
+	case *native.Glist:
+		s, e, err := i.sliceBounds(start, end, r.Len(), expr.Bracket)
+		if err != nil {
+			return nil, err
+		}
+		return r.Slice(s, e), nil
+	case string:
+		runes := []rune(r)
+		s, e, err := i.sliceBounds(start, end, len(runes), expr.Bracket)
+		if err != nil {
+			return nil, err
+		}
+		return string(runes[s:e]), nil
+	}
+	return nil, glorpups.NewTypeGlorpup(expr.Bracket, fmt.Sprintf("Unable to slice %s.", native.TypeName(receiver)), nil)
 }
 
 func (i *Interpreter) VisitIndexExpr(expr *types.IndexExpr) (any, error) {
-	indexVal, err := i.evaluateIndex(expr.Index)
+	receiver, err := i.evaluate(expr.Expr)
+	if err != nil {
+		return nil, err
+	}
+	index, err := i.evaluate(expr.Index)
 	if err != nil {
 		return nil, err
 	}
+	return i.indexValue(receiver, index, expr.Bracket)
+}
 
-	switch expr.Expr.(type) {
-	case *types.LiteralExpr:
-		return i.indexLiteral(expr.Expr, indexVal)
-	case *types.GlistExpr:
-		return i.indexGlist(expr.Expr, indexVal)
-	case *types.VarExpr:
-		return i.indexVar(expr.Expr, indexVal)
+func (i *Interpreter) VisitGmapExpr(expr *types.GmapExpr) (any, error) {
+	m := native.NewGmap()
+	for idx, keyExpr := range expr.Keys {
+		key, err := i.evaluate(keyExpr)
+		if err != nil {
+			return nil, err
+		}
+		if err := singleIn(key, expr.Token, "a gmap key"); err != nil {
+			return nil, err
+		}
+		if !native.IsHashable(key) {
+			return nil, glorpups.NewTypeGlorpup(expr.Token, fmt.Sprintf("Unable to use %s as gmap key.", native.TypeName(key)), nil)
+		}
+		val, err := i.evaluate(expr.Vals[idx])
+		if err != nil {
+			return nil, err
+		}
+		if err := singleIn(val, expr.Token, "a gmap value"); err != nil {
+			return nil, err
+		}
+		m.Set(key, val)
 	}
-	return nil, nil
+	return m, nil
+}
+
+func (i *Interpreter) VisitIndexAssignExpr(expr *types.IndexAssignExpr) (any, error) {
+	receiver, err := i.evaluate(expr.Expr)
+	if err != nil {
+		return nil, err
+	}
+	index, err := i.evaluate(expr.Index)
+	if err != nil {
+		return nil, err
+	}
+	val, err := i.evaluate(expr.Val)
+	if err != nil {
+		return nil, err
+	}
+	if err := single(val, expr.Equals); err != nil {
+		return nil, err
+	}
+
+	if err := i.setIndex(receiver, index, val, expr.Equals); err != nil {
+		return nil, err
+	}
+	return val, nil
 }
 
 func (i *Interpreter) VisitFunExpr(expr *types.FunExpr) (any, error) {
@@ -402,46 +877,86 @@ func (i *Interpreter) VisitLogicalExpr(expr *types.LogicalExpr) (any, error) {
 }
 
 func (i *Interpreter) VisitAccessExpr(expr *types.AccessExpr) (any, error) {
-	v, ok := expr.Exprs[0].(*types.VarExpr)
-	if ok {
-		var str string
-		// Check if v in golang imports
-		rootName := v.Name.Lexeme
-		_, err := i.GoEnvironment.Get(rootName)
-		if err != nil {
-			// Build expr
-			str += rootName
-			for _, item := range expr.Exprs[1:] {
-				switch i := item.(type) {
-				case *types.VarExpr:
-					str += ("." + i.Name.Lexeme)
-				case *types.CallExpr:
-					root := i.Callee.(*types.VarExpr).Name.Lexeme
-					str += "." + root + "("
-					for _, arg := range i.Args {
-						t, ok := arg.(*types.LiteralExpr)
-						if ok {
-							str += "\"" + t.Val.String() + "\""
-						}
-					}
-					str += ")"
-				default:
-					return nil, fmt.Errorf("unexpected type of component expression in access expression")
-				}
-			}
-			// When importing go libs, add to central env yaegi
-			// This is used when any go is run
-			_, err := i.ExecuteGo(str)
-			// fmt.Println(x)
-			if err != nil {
-				return nil, err
-			}
-		}
-		fmt.Println(str)
+	root, err := i.evaluate(expr.Exprs[0])
+	if err != nil {
+		return nil, err
 	}
-	return nil, nil
+	return i.accessMembers(root, expr.Exprs[1:])
 }
 
 func (i *Interpreter) VisitAccessStmt(expr *types.Access) error {
 	return nil
 }
+
+// a.b, a.b["c"] and a.b(x) where a is a hype value
+func (i *Interpreter) accessMembers(val any, members []types.Expr) (any, error) {
+	var err error
+	for _, m := range members {
+		if val, err = i.accessMember(val, m); err != nil {
+			return nil, err
+		}
+	}
+	return val, nil
+}
+
+// The parser hands each member over as a full expression, b in a.b, b["c"][0] in a.b["c"][0]
+// Walk down to the name and look it up on val rather than in the env, then apply whatever wraps it
+func (i *Interpreter) accessMember(val any, m types.Expr) (any, error) {
+	switch member := m.(type) {
+	case *types.VarExpr:
+		return i.member(val, member.Name)
+	case *types.IndexExpr:
+		receiver, err := i.accessMember(val, member.Expr)
+		if err != nil {
+			return nil, err
+		}
+		index, err := i.evaluate(member.Index)
+		if err != nil {
+			return nil, err
+		}
+		return i.indexValue(receiver, index, member.Bracket)
+	case *types.CallExpr:
+		callee, err := i.accessMember(val, member.Callee)
+		if err != nil {
+			return nil, err
+		}
+		fun, ok := callee.(native.Callable)
+		if !ok {
+			return nil, glorpups.NewTypeGlorpup(member.Paren, fmt.Sprintf("Unable to call %s.", native.TypeName(callee)), nil)
+		}
+		return i.call(fun, member)
+	}
+	// The parser shouldn't make anything else, point at it all the same if it does
+	span := m.Span()
+	at := token.Token{File: span.File, Line: span.Start.Line, Col: span.Start.Col, Offset: span.Start.Offset, End: span.End}
+	return nil, glorpups.NewRuntimeGlorpup(at, "Expected a member name after '.'.", nil)
+}
+
+// The values of return a, b, each one has to be a single value itself
+func (i *Interpreter) VisitTupleExpr(expr *types.TupleExpr) (any, error) {
+	items := make([]any, len(expr.Items))
+	for idx, item := range expr.Items {
+		val, err := i.evaluate(item)
+		if err != nil {
+			return nil, err
+		}
+		if err := single(val, expr.Token); err != nil {
+			return nil, err
+		}
+		items[idx] = val
+	}
+	return native.NewTuple(items), nil
+}
+
+// Every part is stringified the same way print would and joined
+func (i *Interpreter) VisitInterpolationExpr(expr *types.InterpolationExpr) (any, error) {
+	var builder strings.Builder
+	for _, part := range expr.Parts {
+		val, err := i.evaluate(part)
+		if err != nil {
+			return nil, err
+		}
+		builder.WriteString(utils.Stringify(val))
+	}
+	return builder.String(), nil
+}
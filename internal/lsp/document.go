@@ -0,0 +1,316 @@
+package lsp
+
+import (
+	"fmt"
+	"go/constant"
+	"hype-script/internal/diag"
+	"hype-script/internal/environment"
+	"hype-script/internal/parser"
+	"hype-script/internal/resolver"
+	"hype-script/internal/scanner"
+	"hype-script/internal/token"
+	"net/url"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/traefik/yaegi/stdlib"
+)
+
+// An open .hyp file, analyzed again on every change
+type document struct {
+	URI         string
+	Text        string
+	LineStarts  []int
+	Diagnostics []*diag.Diagnostic
+	Resolver    *resolver.Resolver
+}
+
+// Scans, parses and resolves text, carrying on past errors so a half typed file still gets help
+func newDocument(uri, text string) *document {
+	d := &document{URI: uri, Text: text, LineStarts: []int{0}}
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\n' {
+			d.LineStarts = append(d.LineStarts, i+1)
+		}
+	}
+
+	sc := scanner.NewScanner()
+	sc.SetFile(filename(uri))
+	tokens, _ := sc.ScanTokens(text)
+	d.Diagnostics = append(d.Diagnostics, sc.GetDiagnostics().List...)
+
+	p := parser.NewParser(environment.NewEnvironment(nil))
+	stmts, _ := p.ParseTokens(tokens)
+	d.Diagnostics = append(d.Diagnostics, p.GetDiagnostics().List...)
+
+	d.Resolver = resolver.NewResolver()
+	d.Resolver.Resolve(stmts)
+	return d
+}
+
+// file:///home/me/vpn.hyp names its spans /home/me/vpn.hyp, the same as running it would
+func filename(uri string) string {
+	if u, err := url.Parse(uri); err == nil && u.Scheme == "file" {
+		return u.Path
+	}
+	return uri
+}
+
+// Byte offset of an lsp position, clamped to the document
+func (d *document) offset(pos Position) int {
+	if pos.Line < 0 {
+		return 0
+	}
+	if pos.Line >= len(d.LineStarts) {
+		return len(d.Text)
+	}
+	offset := d.LineStarts[pos.Line]
+	for units := 0; units < pos.Character && offset < len(d.Text) && d.Text[offset] != '\n'; {
+		r, size := utf8.DecodeRuneInString(d.Text[offset:])
+		units += utf16.RuneLen(r)
+		offset += size
+	}
+	return offset
+}
+
+func (d *document) position(offset int) Position {
+	offset = min(max(offset, 0), len(d.Text))
+	line := sort.Search(len(d.LineStarts), func(i int) bool { return d.LineStarts[i] > offset }) - 1
+	units := 0
+	for _, r := range d.Text[d.LineStarts[line]:offset] {
+		units += utf16.RuneLen(r)
+	}
+	return Position{Line: line, Character: units}
+}
+
+func (d *document) rng(span token.Span) Range {
+	return Range{Start: d.position(span.Start.Offset), End: d.position(span.End.Offset)}
+}
+
+func (d *document) source(span token.Span) string {
+	if span.IsZero() || span.End.Offset > len(d.Text) {
+		return ""
+	}
+	return d.Text[span.Start.Offset:span.End.Offset]
+}
+
+func (d *document) diagnostics() []Diagnostic {
+	diags := []Diagnostic{}
+	for _, dg := range d.Diagnostics {
+		diags = append(diags, Diagnostic{
+			Range:    d.rng(dg.Span),
+			Severity: int(dg.Severity) + 1, // Error, Warning, Info line up with the spec's 1, 2, 3
+			Code:     dg.Code,
+			Source:   "hype",
+			Message:  dg.Message,
+		})
+	}
+	return diags
+}
+
+func (d *document) definition(pos Position) *Location {
+	sym, _ := d.Resolver.At(d.offset(pos))
+	if sym == nil || sym.Decl.Lexeme == "" {
+		return nil
+	}
+	return &Location{URI: d.URI, Range: d.rng(sym.Decl.Span())}
+}
+
+func (d *document) hover(pos Position) *Hover {
+	offset := d.offset(pos)
+	if m, ok := d.Resolver.MemberAt(offset); ok {
+		text := goMemberDetail(m.Package.Path, m.Name.Lexeme)
+		if text == "" {
+			return nil
+		}
+		r := d.rng(m.Name.Span())
+		return &Hover{Contents: markdown(text), Range: &r}
+	}
+	sym, tok := d.Resolver.At(offset)
+	if sym == nil {
+		return nil
+	}
+	r := d.rng(tok.Span())
+	return &Hover{Contents: markdown(d.detail(sym)), Range: &r}
+}
+
+func markdown(code string) markupContent {
+	return markupContent{Kind: "markdown", Value: "```hype\n" + code + "\n```"}
+}
+
+// One line saying what sym is, the way it was declared
+func (d *document) detail(sym *resolver.Symbol) string {
+	switch sym.Kind {
+	case resolver.Function:
+		return "func " + d.signature(sym)
+	case resolver.Param:
+		return "param " + sym.Name
+	case resolver.StructType:
+		fields := make([]string, len(sym.Struct.Fields))
+		for i, field := range sym.Struct.Fields {
+			fields[i] = field.Lexeme
+		}
+		return fmt.Sprintf("struct %s { %s }", sym.Name, strings.Join(fields, ", "))
+	case resolver.Package:
+		return fmt.Sprintf("go package %s %q", sym.Name, sym.Path)
+	case resolver.Builtin:
+		return "builtin " + sym.Name
+	}
+	return "var " + sym.Name
+}
+
+// name(a, b = 2, ...rest) with defaults as they were written
+func (d *document) signature(sym *resolver.Symbol) string {
+	fun := sym.Fun
+	params := make([]string, 0, len(fun.Params)+1)
+	for i, param := range fun.Params {
+		if i < len(fun.Defaults) && fun.Defaults[i] != nil {
+			params = append(params, fmt.Sprintf("%s = %s", param.Lexeme, d.source(fun.Defaults[i].Span())))
+			continue
+		}
+		params = append(params, param.Lexeme)
+	}
+	if fun.Rest.Lexeme != "" {
+		params = append(params, "..."+fun.Rest.Lexeme)
+	}
+	return fmt.Sprintf("%s(%s)", sym.Name, strings.Join(params, ", "))
+}
+
+// Members of a Go package after 'pkg.', otherwise keywords and every name in scope
+func (d *document) completion(pos Position) []CompletionItem {
+	offset := d.offset(pos)
+	start := offset
+	for start > 0 && isIdentByte(d.Text[start-1]) {
+		start--
+	}
+	if start > 0 && d.Text[start-1] == '.' {
+		end := start - 1
+		begin := end
+		for begin > 0 && isIdentByte(d.Text[begin-1]) {
+			begin--
+		}
+		for _, sym := range d.Resolver.Visible(offset) {
+			if sym.Name == d.Text[begin:end] && sym.Kind == resolver.Package {
+				return goMembers(sym.Path)
+			}
+		}
+		return []CompletionItem{} // Members of hype values aren't known until it runs
+	}
+
+	items := []CompletionItem{}
+	for keyword := range token.BuildKeywords() {
+		items = append(items, CompletionItem{Label: keyword, Kind: completionKeyword})
+	}
+	for _, sym := range d.Resolver.Visible(offset) {
+		items = append(items, CompletionItem{Label: sym.Name, Kind: symbolCompletionKind(sym.Kind), Detail: d.detail(sym)})
+	}
+	sort.Slice(items, func(a, b int) bool { return items[a].Label < items[b].Label })
+	return items
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+func symbolCompletionKind(kind resolver.SymbolKind) int {
+	switch kind {
+	case resolver.Function, resolver.Builtin:
+		return completionFunction
+	case resolver.StructType:
+		return completionStruct
+	case resolver.Package:
+		return completionModule
+	}
+	return completionVariable
+}
+
+// Yaegi keys its symbols by import path then package name, strings/strings
+func goSymbols(pkgPath string) map[string]reflect.Value {
+	return stdlib.Symbols[pkgPath+"/"+path.Base(pkgPath)]
+}
+
+func goMembers(pkgPath string) []CompletionItem {
+	items := []CompletionItem{}
+	for name, v := range goSymbols(pkgPath) {
+		if strings.HasPrefix(name, "_") { // Yaegi's interface wrappers
+			continue
+		}
+		kind := completionVariable
+		switch {
+		case v.Kind() == reflect.Func:
+			kind = completionFunction
+		case isGoType(v):
+			kind = completionClass
+		case isGoConst(v):
+			kind = completionConstant
+		}
+		items = append(items, CompletionItem{Label: name, Kind: kind, Detail: goMemberDetail(pkgPath, name)})
+	}
+	sort.Slice(items, func(a, b int) bool { return items[a].Label < items[b].Label })
+	return items
+}
+
+// Types are stored as a nil pointer to them, vars as a pointer to the var
+func isGoType(v reflect.Value) bool {
+	return v.Kind() == reflect.Pointer && v.IsNil()
+}
+
+// Untyped constants come through as go/constant values
+func isGoConst(v reflect.Value) bool {
+	_, ok := v.Interface().(constant.Value)
+	return ok
+}
+
+func goMemberDetail(pkgPath, name string) string {
+	v, ok := goSymbols(pkgPath)[name]
+	if !ok {
+		return ""
+	}
+	qualified := path.Base(pkgPath) + "." + name
+	switch {
+	case v.Kind() == reflect.Func:
+		return fmt.Sprintf("func %s%s", qualified, strings.TrimPrefix(v.Type().String(), "func"))
+	case isGoType(v):
+		return fmt.Sprintf("type %s %s", qualified, v.Type().Elem().Kind())
+	case isGoConst(v):
+		return fmt.Sprintf("const %s = %s", qualified, v.Interface())
+	case v.Kind() == reflect.Pointer:
+		return fmt.Sprintf("var %s %s", qualified, v.Type().Elem())
+	}
+	return fmt.Sprintf("const %s %s", qualified, v.Type())
+}
+
+// Every func declaration, nested ones under the func they're in
+func (d *document) symbols() []DocumentSymbol {
+	return d.scopeSymbols(d.Resolver.Global)
+}
+
+func (d *document) scopeSymbols(scope *resolver.Scope) []DocumentSymbol {
+	symbols := []DocumentSymbol{}
+	for _, sym := range scope.Order {
+		if sym.Kind != resolver.Function {
+			continue
+		}
+		ds := DocumentSymbol{
+			Name:           sym.Name,
+			Detail:         d.signature(sym),
+			Kind:           symbolFunction,
+			Range:          d.rng(sym.Fun.Span()),
+			SelectionRange: d.rng(sym.Decl.Span()),
+		}
+		if sym.Body != nil {
+			ds.Children = d.scopeSymbols(sym.Body)
+		}
+		symbols = append(symbols, ds)
+	}
+	for _, child := range scope.Children {
+		if child.Fun == nil { // Function bodies were covered by their function
+			symbols = append(symbols, d.scopeSymbols(child)...)
+		}
+	}
+	return symbols
+}
@@ -0,0 +1,196 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const uri = "file:///tmp/vpn.hyp"
+
+const src = `import go ("strings")
+var port = 1194
+func greet(name, greeting = "hi", ...rest) {
+    msg := strings.ToUpper(name)
+    print msg
+}
+greet("vpn")
+var bad = 12ab
+`
+
+type exchange struct {
+	in      bytes.Buffer
+	nextID  int
+	results map[int]json.RawMessage
+	notes   []json.RawMessage // publishDiagnostics params, in order
+}
+
+func (e *exchange) send(method string, params any) int {
+	e.nextID++
+	e.frame(map[string]any{"jsonrpc": "2.0", "id": e.nextID, "method": method, "params": params})
+	return e.nextID
+}
+
+func (e *exchange) notify(method string, params any) {
+	e.frame(map[string]any{"jsonrpc": "2.0", "method": method, "params": params})
+}
+
+func (e *exchange) frame(v any) {
+	body, _ := json.Marshal(v)
+	fmt.Fprintf(&e.in, "Content-Length: %d\r\n\r\n%s", len(body), body)
+}
+
+func (e *exchange) run(t *testing.T) {
+	var out bytes.Buffer
+	if err := NewServer(&e.in, &out).Serve(); err != nil {
+		t.Fatalf("serve: %v", err)
+	}
+	e.results = map[int]json.RawMessage{}
+	r := bufio.NewReader(&out)
+	for {
+		header, err := textproto.NewReader(r).ReadMIMEHeader()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			t.Fatalf("bad header: %v", err)
+		}
+		n, _ := strconv.Atoi(header.Get("Content-Length"))
+		body := make([]byte, n)
+		io.ReadFull(r, body)
+		var msg struct {
+			ID     *int            `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+			Result json.RawMessage `json:"result"`
+		}
+		if err := json.Unmarshal(body, &msg); err != nil {
+			t.Fatalf("bad message %s: %v", body, err)
+		}
+		if msg.ID != nil {
+			e.results[*msg.ID] = msg.Result
+		} else if msg.Method == "textDocument/publishDiagnostics" {
+			e.notes = append(e.notes, msg.Params)
+		}
+	}
+}
+
+func at(line, char int) map[string]any {
+	return map[string]any{"textDocument": map[string]any{"uri": uri}, "position": Position{Line: line, Character: char}}
+}
+
+func TestServer(t *testing.T) {
+	e := &exchange{}
+	e.send("initialize", map[string]any{})
+	e.notify("initialized", map[string]any{})
+	e.notify("textDocument/didOpen", map[string]any{"textDocument": map[string]any{"uri": uri, "text": src, "version": 1}})
+	funDef := e.send("textDocument/definition", at(6, 1))
+	localDef := e.send("textDocument/definition", at(4, 11))
+	funHover := e.send("textDocument/hover", at(6, 2))
+	goHover := e.send("textDocument/hover", at(3, 21))
+	locals := e.send("textDocument/completion", at(4, 10))
+	symbols := e.send("textDocument/documentSymbol", map[string]any{"textDocument": map[string]any{"uri": uri}})
+	e.notify("textDocument/didChange", map[string]any{
+		"textDocument":   map[string]any{"uri": uri, "version": 2},
+		"contentChanges": []map[string]any{{"text": "import go (\"strings\")\nstrings.\n"}},
+	})
+	members := e.send("textDocument/completion", at(1, 8))
+	shutdown := e.send("shutdown", nil)
+	e.notify("exit", nil)
+	e.run(t)
+
+	if !strings.Contains(string(e.results[1]), `"definitionProvider":true`) {
+		t.Errorf("expected capabilities from initialize, got %s", e.results[1])
+	}
+
+	var published publishDiagnosticsParams
+	json.Unmarshal(e.notes[0], &published)
+	want := Diagnostic{Range: Range{Start: Position{7, 10}, End: Position{7, 14}}, Severity: 1, Code: "malformed-number", Source: "hype", Message: "Malformed number '12ab'"}
+	if len(published.Diagnostics) != 1 || published.Diagnostics[0] != want {
+		t.Errorf("expected %v, got %v", want, published.Diagnostics)
+	}
+
+	checkLocation := func(id int, want Range) {
+		var loc Location
+		json.Unmarshal(e.results[id], &loc)
+		if loc.URI != uri || loc.Range != want {
+			t.Errorf("request %d: expected definition at %v, got %s", id, want, e.results[id])
+		}
+	}
+	checkLocation(funDef, Range{Position{2, 5}, Position{2, 10}})
+	checkLocation(localDef, Range{Position{3, 4}, Position{3, 7}})
+
+	checkHover := func(id int, want string) {
+		var hover Hover
+		json.Unmarshal(e.results[id], &hover)
+		if !strings.Contains(hover.Contents.Value, want) {
+			t.Errorf("request %d: expected hover with %q, got %s", id, want, e.results[id])
+		}
+	}
+	checkHover(funHover, `func greet(name, greeting = "hi", ...rest)`)
+	checkHover(goHover, "func strings.ToUpper(string) string")
+
+	checkCompletion := func(id int, want map[string]int, unwanted ...string) {
+		var items []CompletionItem
+		json.Unmarshal(e.results[id], &items)
+		got := map[string]int{}
+		for _, item := range items {
+			got[item.Label] = item.Kind
+		}
+		for label, kind := range want {
+			if got[label] != kind {
+				t.Errorf("request %d: expected %s with kind %d, got kind %d", id, label, kind, got[label])
+			}
+		}
+		for _, label := range unwanted {
+			if _, ok := got[label]; ok {
+				t.Errorf("request %d: expected no %s", id, label)
+			}
+		}
+	}
+	checkCompletion(locals, map[string]int{
+		"msg": completionVariable, "name": completionVariable, "rest": completionVariable,
+		"greet": completionFunction, "port": completionVariable, "strings": completionModule,
+		"len": completionFunction, "while": completionKeyword,
+	}, "bad")
+	checkCompletion(members, map[string]int{"ToUpper": completionFunction, "Builder": completionClass}, "while")
+
+	var syms []DocumentSymbol
+	json.Unmarshal(e.results[symbols], &syms)
+	if len(syms) != 1 || syms[0].Name != "greet" || syms[0].SelectionRange != (Range{Position{2, 5}, Position{2, 10}}) {
+		t.Errorf("expected greet as the only symbol, got %s", e.results[symbols])
+	}
+
+	if string(e.results[shutdown]) != "null" {
+		t.Errorf("expected a null result for shutdown, got %s", e.results[shutdown])
+	}
+}
+
+func TestPositions(t *testing.T) {
+	// é is two bytes but one UTF-16 unit, 😀 is four bytes and two units
+	d := newDocument(uri, "s := \"é😀\"\nx")
+	cases := []struct {
+		pos    Position
+		offset int
+	}{
+		{Position{0, 0}, 0},
+		{Position{0, 7}, 8},
+		{Position{0, 9}, 12},
+		{Position{0, 50}, 13}, // Past the end of the line stops at it
+		{Position{1, 0}, 14},
+	}
+	for _, c := range cases {
+		if got := d.offset(c.pos); got != c.offset {
+			t.Errorf("%v: expected offset %d, got %d", c.pos, c.offset, got)
+		}
+		if c.pos.Character < 50 && d.position(c.offset) != c.pos {
+			t.Errorf("offset %d: expected %v, got %v", c.offset, c.pos, d.position(c.offset))
+		}
+	}
+}
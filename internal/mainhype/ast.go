@@ -0,0 +1,67 @@
+package mainhype
+
+import (
+	"flag"
+	"fmt"
+	"hype-script/internal/astPrinter"
+	"hype-script/internal/diag"
+	"hype-script/internal/environment"
+	"hype-script/internal/parser"
+	"hype-script/internal/scanner"
+	"os"
+)
+
+// hype ast [--json] file.hyp prints what the parser makes of a script, as S-expressions or as JSON for tools
+func (g *Hype) Ast(args []string) error {
+	flags := flag.NewFlagSet("ast", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "print the tree as JSON with positions")
+	if err := flags.Parse(args); err != nil {
+		return ExitCode(2)
+	}
+	if flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: hype ast [--json] file.hyp")
+		return ExitCode(2)
+	}
+	file := flags.Arg(0)
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	sc := scanner.NewScanner()
+	sc.SetFile(file)
+	tokens, err := sc.ScanTokens(string(data))
+	if err != nil {
+		return g.reportParse(file, string(data), err)
+	}
+	stmts, err := parser.NewParser(environment.NewEnvironment(nil)).ParseTokens(tokens)
+	if err != nil {
+		return g.reportParse(file, string(data), err)
+	}
+
+	if *asJSON {
+		out, err := astPrinter.DumpJSON(file, stmts)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+	out, err := astPrinter.NewAstPrinter().PrintStmts(stmts)
+	if err != nil {
+		return err
+	}
+	fmt.Print(out)
+	return nil
+}
+
+// Shows why a script couldn't be read as a tree and fails
+func (g *Hype) reportParse(file, src string, err error) error {
+	d, ok := err.(*diag.Diagnostics)
+	if !ok {
+		return err
+	}
+	diag.AddSource(file, src)
+	g.Report(d)
+	return ExitCode(1)
+}
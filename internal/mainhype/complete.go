@@ -0,0 +1,68 @@
+package mainhype
+
+import (
+	"hype-script/internal/environment"
+	"hype-script/internal/native"
+	"hype-script/internal/token"
+	"sort"
+	"strings"
+)
+
+// Tab completion for the REPL, liner swaps the word before the cursor for whichever completion is picked
+// After pkg. it's the members of an imported Go package, anywhere else keywords and every name in the env chain
+func (g *Hype) complete(line string, pos int) (head string, completions []string, tail string) {
+	start := identStart(line, pos)
+	head, word, tail := line[:start], line[start:pos], line[pos:]
+
+	var candidates []string
+	if start > 0 && line[start-1] == '.' {
+		name := line[identStart(line, start-1) : start-1]
+		val, err := g.Environment.Get(name)
+		pkg, ok := val.(*native.GoPackage)
+		if err != nil || !ok {
+			return head, nil, tail // Members of hype values aren't known without knowing what they are
+		}
+		candidates = pkg.Members()
+	} else {
+		for keyword := range token.BuildKeywords() {
+			candidates = append(candidates, keyword)
+		}
+		candidates = append(candidates, g.names()...)
+	}
+
+	seen := map[string]bool{}
+	for _, c := range candidates {
+		if strings.HasPrefix(c, word) && !seen[c] {
+			seen[c] = true
+			completions = append(completions, c)
+		}
+	}
+	sort.Strings(completions)
+	return head, completions, tail
+}
+
+// Every name defined in the env and the ones it's enclosed by
+func (g *Hype) names() []string {
+	var names []string
+	env, ok := g.Environment.(*environment.Environment)
+	for ok {
+		for name := range env.Values {
+			names = append(names, name)
+		}
+		env, ok = env.Enlcosing.(*environment.Environment)
+	}
+	return names
+}
+
+// Where the identifier that ends at pos starts
+func identStart(line string, pos int) int {
+	start := pos
+	for start > 0 && isIdentByte(line[start-1]) {
+		start--
+	}
+	return start
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,106 @@
+package mainhype
+
+import (
+	"flag"
+	"fmt"
+	"hype-script/internal/diag"
+	"hype-script/internal/format"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Exits the CLI with a status and nothing more said, whatever went wrong has already been printed
+type ExitCode int
+
+func (e ExitCode) Error() string {
+	return fmt.Sprintf("exit status %d", int(e))
+}
+
+// hype fmt [--check] [path ...] rewrites each .hyp file the canonical way and prints the ones it changed
+// --check only lists them and fails if there are any, for CI
+func (g *Hype) Fmt(args []string) error {
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	check := flags.Bool("check", false, "list files that aren't formatted and exit 1 rather than rewriting them")
+	if err := flags.Parse(args); err != nil {
+		return ExitCode(2)
+	}
+	files, err := hypFiles(flags.Args())
+	if err != nil {
+		return err
+	}
+
+	failed := false
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		out, err := format.Source(file, string(data))
+		if err != nil {
+			// A file that doesn't parse can't be formatted, say why and carry on with the rest
+			if d, ok := err.(*diag.Diagnostics); ok {
+				diag.AddSource(file, string(data))
+				g.Report(d)
+			} else {
+				fmt.Fprintln(os.Stderr, err)
+			}
+			failed = true
+			continue
+		}
+		if out == string(data) {
+			continue
+		}
+		fmt.Println(file)
+		if *check {
+			failed = true
+			continue
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(file, []byte(out), info.Mode()); err != nil {
+			return err
+		}
+	}
+	if failed {
+		return ExitCode(1)
+	}
+	return nil
+}
+
+// Every .hyp file under paths, directories are walked and no paths means the current one
+func hypFiles(paths []string) ([]string, error) {
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	var files []string
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, p)
+			continue
+		}
+		err = filepath.WalkDir(p, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() && path != p && strings.HasPrefix(d.Name(), ".") { // .git and friends
+				return filepath.SkipDir
+			}
+			if !d.IsDir() && filepath.Ext(path) == ".hyp" {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
@@ -3,10 +3,11 @@ package mainhype
 import (
 	"os"
 	"path/filepath"
-	"bufio"
 	"fmt"
 	"hype-script/internal/environment"
+	"hype-script/internal/diag"
 	"hype-script/internal/interpreter"
+	"hype-script/internal/lsp"
 	"hype-script/internal/parser"
 	"hype-script/internal/scanner"
 	"hype-script/internal/token"
@@ -16,6 +17,7 @@ import (
 
 type Hype struct {
 	HadError    bool
+	File        string // Script being run, empty for the REPL
 	Scanner     core.ScannerHandler
 	Parser      core.ParserHandler
 	Interpreter core.InterpreterHandler
@@ -35,8 +37,22 @@ func NewHype() *Hype {
 
 func (g *Hype) Start() error {
 	args := os.Args
+	if len(args) >= 2 {
+		switch args[1] {
+		case "lsp":
+			return g.Lsp()
+		case "fmt":
+			return g.Fmt(args[2:])
+		case "vet":
+			return g.Vet(args[2:])
+		case "ast":
+			return g.Ast(args[2:])
+		case "test":
+			return g.Test(args[2:])
+		}
+	}
 	if len(args) > 2 {
-		fmt.Println("Usage: hype [file.hyp]")
+		fmt.Println("Usage: hype [file.hyp] | hype lsp | hype fmt [--check] [path ...] | hype vet [path ...] | hype ast [--json] file.hyp | hype test [--run pattern] [--format tap|junit] [path ...]")
 		return nil
 	} else if len(args) == 2 {
 		return g.Runfile(args[1])
@@ -54,26 +70,24 @@ func (g *Hype) Runfile(file string) error {
 	if err != nil {
 		return err
 	}
+	g.File = file
+	g.Scanner.SetFile(file)
+	g.Interpreter.SetFile(file)
 	return g.Run(string(data))
 }
 
-func (g *Hype) Repl() error {
-	reader := bufio.NewReader(os.Stdin)
-
-	for {
-		fmt.Printf("> ")
-		line, err := reader.ReadString('\n')
-		if err != nil {
-			break
-		}
-		g.Run(line)
-		g.HadError = false
-	}
-	return nil
+// Serves LSP on stdin and stdout until the editor is done with it
+func (g *Hype) Lsp() error {
+	out := os.Stdout
+	os.Stdout = os.Stderr // Stray prints would land in the middle of a message
+	defer func() { os.Stdout = out }()
+	return lsp.NewServer(os.Stdin, out).Serve()
 }
 
 func (g *Hype) Run(source string) error {
+	diag.AddSource(g.File, source)
 	tokens, err := g.Scanner.ScanTokens(source)
+	g.Report(g.Scanner.GetDiagnostics())
 	if err != nil {
 		return err
 	}
@@ -85,6 +99,7 @@ func (g *Hype) Run(source string) error {
 
 	statements, err := g.Parser.ParseTokens(tokens)
 	if err != nil {
+		g.Report(g.Parser.GetDiagnostics())
 		return err
 	}
 	// Debug to see statement info
@@ -93,6 +108,7 @@ func (g *Hype) Run(source string) error {
 	}
 
 	err = g.Interpreter.InterpretStmts(statements)
+	g.Report(g.Interpreter.GetDiagnostics())
 	if err != nil {
 		return err
 	}
@@ -108,4 +124,9 @@ func (g *Hype) Run(source string) error {
 	// }
 
 	return nil
-}
\ No newline at end of file
+}
+
+// Shows diagnostics the way the CLI prints them, embedders can read them off each stage instead
+func (g *Hype) Report(d *diag.Diagnostics) {
+	diag.Render(os.Stdout, d)
+}
@@ -0,0 +1,248 @@
+package mainhype
+
+import (
+	"errors"
+	"fmt"
+	"hype-script/internal/diag"
+	"hype-script/internal/environment"
+	"hype-script/internal/native"
+	"hype-script/internal/scanner"
+	"hype-script/internal/token"
+	"hype-script/internal/types"
+	"hype-script/internal/utils"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/peterh/liner"
+)
+
+// What diagnostics from typed in code say they came from
+const replFile = "<stdin>"
+
+// Kept in the home dir so history carries over between sessions
+const historyFile = ".hype_history"
+
+const replHelp = `:env          list what's been defined
+:reset        forget everything and start over
+:load <file>  run a script, what it defines stays around
+:help         show this
+Ctrl-C drops a half typed entry, Ctrl-D quits`
+
+// Reads entries until Ctrl-D, one entry can span lines while brackets or a string are still open
+// A bare expression has its value printed, and kept in _ for the next entry
+func (g *Hype) Repl() error {
+	line := liner.NewLiner()
+	defer line.Close()
+	line.SetCtrlCAborts(true)
+	line.SetTabCompletionStyle(liner.TabPrints)
+	line.SetWordCompleter(g.complete)
+
+	history := historyPath()
+	if f, err := os.Open(history); err == nil {
+		line.ReadHistory(f)
+		f.Close()
+	}
+	defer func() {
+		if history == "" {
+			return
+		}
+		if f, err := os.Create(history); err == nil {
+			line.WriteHistory(f)
+			f.Close()
+		}
+	}()
+
+	g.useFile(replFile)
+	pending := ""
+	for {
+		prompt := "> "
+		if pending != "" {
+			prompt = "... "
+		}
+		input, err := line.Prompt(prompt)
+		if errors.Is(err, liner.ErrPromptAborted) {
+			pending = ""
+			continue
+		}
+		if err == io.EOF {
+			fmt.Println()
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(input) != "" {
+			line.AppendHistory(input)
+		}
+
+		if pending == "" && strings.HasPrefix(strings.TrimSpace(input), ":") {
+			g.command(strings.TrimSpace(input))
+			continue
+		}
+		src := pending + input + "\n"
+		if incomplete(src) {
+			pending = src
+			continue
+		}
+		pending = ""
+		g.eval(src, true)
+	}
+}
+
+func historyPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, historyFile)
+}
+
+// Points diagnostics and traces at file
+func (g *Hype) useFile(file string) {
+	g.File = file
+	g.Scanner.SetFile(file)
+	g.Interpreter.SetFile(file)
+}
+
+// More lines are needed while a bracket or string is still open, anything else wrong is the parser's to say
+func incomplete(src string) bool {
+	sc := scanner.NewScanner()
+	tokens, _ := sc.ScanTokens(src)
+	for _, d := range sc.GetDiagnostics().List {
+		if d.Code == diag.UnterminatedString {
+			return true
+		}
+	}
+	depth := 0
+	for _, tok := range tokens {
+		switch tok.Type {
+		case token.LEFT_PAREN, token.LEFT_BRACE, token.LEFT_BRACKET:
+			depth++
+		case token.RIGHT_PAREN, token.RIGHT_BRACE, token.RIGHT_BRACKET:
+			depth--
+		}
+	}
+	return depth > 0
+}
+
+// Runs src like Run without the debug output, echo prints the value of a bare expression at the end
+func (g *Hype) eval(src string, echo bool) {
+	diag.AddSource(g.File, src)
+	tokens, err := g.Scanner.ScanTokens(src)
+	g.Report(g.Scanner.GetDiagnostics())
+	if err != nil {
+		return
+	}
+	stmts, err := g.Parser.ParseTokens(tokens)
+	if err != nil {
+		g.Report(g.Parser.GetDiagnostics())
+		return
+	}
+
+	echo = echo && echoes(stmts)
+	var last *types.Expression
+	if echo {
+		// Evaluated on its own so a multi value call echoes as is, var _ = f() would want one value
+		last = stmts[len(stmts)-1].(*types.Expression)
+		stmts = stmts[:len(stmts)-1]
+	}
+	err = g.Interpreter.InterpretStmts(stmts)
+	g.Report(g.Interpreter.GetDiagnostics())
+	if err != nil || !echo {
+		return
+	}
+	val, err := g.Interpreter.Evaluate(last.Expr)
+	g.Report(g.Interpreter.GetDiagnostics())
+	if err != nil {
+		return
+	}
+	g.Environment.Define("_", val) // Kept for the next entry
+	if val != nil {
+		fmt.Println(show(val))
+	}
+}
+
+// Whether the last statement is an expression worth printing, x = 1 and x++ are run for what they do
+func echoes(stmts []types.Stmt) bool {
+	if len(stmts) == 0 {
+		return false
+	}
+	last, ok := stmts[len(stmts)-1].(*types.Expression)
+	if !ok {
+		return false
+	}
+	switch expr := last.Expr.(type) {
+	case *types.AssignExpr, *types.IndexAssignExpr, *types.PostfixExpr:
+		return false
+	case *types.BinaryExpr:
+		switch expr.Operator.Type {
+		case token.PLUS_EQUAL, token.MINUS_EQUAL, token.STAR_EQUAL, token.SLASH_EQUAL:
+			return false
+		}
+	}
+	return true
+}
+
+// Strings are quoted so "1" and 1 look different
+func show(val any) string {
+	if s, ok := val.(string); ok {
+		return strconv.Quote(s)
+	}
+	return utils.Stringify(val)
+}
+
+func (g *Hype) command(input string) {
+	name, arg, _ := strings.Cut(input, " ")
+	arg = strings.TrimSpace(arg)
+	switch name {
+	case ":env":
+		g.showEnv()
+	case ":reset":
+		*g = *NewHype()
+		g.useFile(replFile)
+	case ":load":
+		if arg == "" {
+			fmt.Println("Usage: :load <file>")
+			return
+		}
+		data, err := os.ReadFile(arg)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		g.useFile(arg)
+		g.eval(string(data), false)
+		g.useFile(replFile)
+	case ":help":
+		fmt.Println(replHelp)
+	default:
+		fmt.Printf("Unknown command %s, :help lists them\n", name)
+	}
+}
+
+// Every global by name, builtins and _ left out
+func (g *Hype) showEnv() {
+	env, ok := g.Environment.(*environment.Environment)
+	if !ok {
+		return
+	}
+	var names []string
+	for name, val := range env.Values {
+		switch val.(type) {
+		case *native.BuiltinCallable, *native.ClockCallable:
+			continue
+		}
+		if name == "_" {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Printf("%s = %s\n", name, show(env.Values[name]))
+	}
+}
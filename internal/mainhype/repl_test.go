@@ -0,0 +1,114 @@
+package mainhype
+
+import (
+	"hype-script/internal/environment"
+	"hype-script/internal/parser"
+	"hype-script/internal/scanner"
+	"slices"
+	"testing"
+)
+
+func TestIncomplete(t *testing.T) {
+	cases := map[string]bool{
+		"print 1\n":                      false,
+		"func f(a) {\n":                  true,
+		"func f(a) {\n    return a\n}\n": false,
+		"xs := [1,\n":                    true,
+		"f(1,\n2)\n":                     false,
+		"s := \"two\n":                   true,
+		"s := \"two\nlines\"\n":          false,
+		"print )\n":                      false, // Too many closed is the parser's problem
+	}
+	for src, want := range cases {
+		if got := incomplete(src); got != want {
+			t.Errorf("%q: expected incomplete %t, got %t", src, want, got)
+		}
+	}
+}
+
+func TestEchoes(t *testing.T) {
+	cases := map[string]bool{
+		"1 + 2\n":        true,
+		"f(1)\n":         true,
+		"x = 1\n":        false,
+		"x += 1\n":       false,
+		"x++\n":          false,
+		"m[\"k\"] = 1\n": false,
+		"var x = 1\n":    false,
+		"print 1\n":      false,
+		"var x = 1\nx\n": true,
+		"x\nvar y = 2\n": false,
+	}
+	for src, want := range cases {
+		tokens, err := scanner.NewScanner().ScanTokens(src)
+		if err != nil {
+			t.Fatalf("%q: %v", src, err)
+		}
+		stmts, err := parser.NewParser(environment.NewEnvironment(nil)).ParseTokens(tokens)
+		if err != nil {
+			t.Fatalf("%q: %v", src, err)
+		}
+		if got := echoes(stmts); got != want {
+			t.Errorf("%q: expected echoes %t, got %t", src, want, got)
+		}
+	}
+}
+
+func TestComplete(t *testing.T) {
+	g := NewHype()
+	g.useFile(replFile)
+	g.eval("import go (\n    \"strings\"\n)\nvar host = 1\n", false)
+
+	cases := []struct {
+		line, head, want string
+	}{
+		{"ho", "", "host"},
+		{"print ho", "print ", "host"},
+		{"wh", "", "while"},
+		{"strings.ToU", "strings.", "ToUpper"},
+		{"x := strings.Has", "x := strings.", "HasPrefix"},
+	}
+	for _, c := range cases {
+		head, completions, tail := g.complete(c.line, len(c.line))
+		if head != c.head || tail != "" {
+			t.Errorf("%q: expected head %q, got %q and tail %q", c.line, c.head, head, tail)
+		}
+		if !slices.Contains(completions, c.want) {
+			t.Errorf("%q: expected %s in %v", c.line, c.want, completions)
+		}
+	}
+
+	if _, completions, _ := g.complete("host.x", 6); len(completions) != 0 {
+		t.Errorf("expected nothing after a non package, got %v", completions)
+	}
+	if _, completions, _ := g.complete("strings.", 8); slices.Contains(completions, "host") {
+		t.Errorf("expected only members of strings, got %v", completions)
+	}
+}
+
+func TestEvalEchoesMultiValue(t *testing.T) {
+	g := NewHype()
+	g.useFile(replFile)
+	g.eval("import go (\n    \"strconv\"\n)\nfunc two() {\n    return 1, 2\n}\n", false)
+
+	cases := map[string]string{
+		"two()\n":               "1, 2",
+		"strconv.Atoi(\"5\")\n": "5, nil",
+		"_\n":                   "5, nil",
+		"var x = 3\nx + 1\n":    "4",
+	}
+	for _, src := range []string{"two()\n", "strconv.Atoi(\"5\")\n", "_\n", "var x = 3\nx + 1\n"} {
+		g.eval(src, true)
+		if d := g.Interpreter.GetDiagnostics(); d.Len() > 0 {
+			t.Errorf("%q: %v", src, d)
+			continue
+		}
+		val, err := g.Environment.Get("_")
+		if err != nil {
+			t.Fatalf("%q: %v", src, err)
+		}
+		if got := show(val); got != cases[src] {
+			t.Errorf("%q: expected _ to be %s, got %s", src, cases[src], got)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package mainhype
+
+import (
+	"flag"
+	"fmt"
+	"hype-script/internal/diag"
+	"hype-script/internal/hypetest"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// hype test [--run pattern] [--format tap|junit] [path ...] runs the tests in each _test.hyp file and fails if any do
+func (g *Hype) Test(args []string) error {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	run := flags.String("run", "", "only run tests whose name matches this regexp")
+	format := flags.String("format", "tap", "report as tap or junit")
+	if err := flags.Parse(args); err != nil {
+		return ExitCode(2)
+	}
+	var pattern *regexp.Regexp
+	if *run != "" {
+		var err error
+		if pattern, err = regexp.Compile(*run); err != nil {
+			fmt.Fprintf(os.Stderr, "Bad --run pattern: %s\n", err)
+			return ExitCode(2)
+		}
+	}
+	report := hypetest.TAP
+	switch *format {
+	case "tap":
+	case "junit":
+		report = hypetest.JUnit
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown --format %s, it's tap or junit\n", *format)
+		return ExitCode(2)
+	}
+	files, err := hypFiles(flags.Args())
+	if err != nil {
+		return err
+	}
+
+	failed := false
+	var results []hypetest.Result
+	for _, file := range files {
+		if !strings.HasSuffix(file, hypetest.Suffix) {
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		diag.AddSource(file, string(data))
+		found, err := hypetest.File(file, string(data), pattern)
+		if err != nil {
+			// Stdout is the report, it has to stay readable by whatever reads it
+			if d, ok := err.(*diag.Diagnostics); ok {
+				diag.Render(os.Stderr, d)
+			} else {
+				fmt.Fprintln(os.Stderr, err)
+			}
+			failed = true
+			continue
+		}
+		results = append(results, found...)
+	}
+	if err := report(os.Stdout, results); err != nil {
+		return err
+	}
+
+	for _, result := range results {
+		if result.Status != hypetest.Pass {
+			failed = true
+		}
+	}
+	if failed {
+		return ExitCode(1)
+	}
+	return nil
+}
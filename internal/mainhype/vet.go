@@ -0,0 +1,48 @@
+package mainhype
+
+import (
+	"flag"
+	"fmt"
+	"hype-script/internal/diag"
+	"hype-script/internal/vet"
+	"os"
+)
+
+// hype vet [path ...] reports likely mistakes in each .hyp file and fails if there are any
+func (g *Hype) Vet(args []string) error {
+	flags := flag.NewFlagSet("vet", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return ExitCode(2)
+	}
+	files, err := hypFiles(flags.Args())
+	if err != nil {
+		return err
+	}
+
+	failed := false
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		diag.AddSource(file, string(data))
+		found, err := vet.Source(file, string(data))
+		if err != nil {
+			if d, ok := err.(*diag.Diagnostics); ok {
+				g.Report(d)
+			} else {
+				fmt.Fprintln(os.Stderr, err)
+			}
+			failed = true
+			continue
+		}
+		if found.Len() > 0 {
+			g.Report(found)
+			failed = true
+		}
+	}
+	if failed {
+		return ExitCode(1)
+	}
+	return nil
+}
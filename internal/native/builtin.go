@@ -0,0 +1,118 @@
+package native
+
+import (
+	"fmt"
+	"hype-script/internal/glorpups"
+	"hype-script/internal/token"
+	"hype-script/internal/types/core"
+	"unicode/utf8"
+)
+
+// A function implemented in Go and defined in the global env before any script runs
+type BuiltinCallable struct {
+	Name   string
+	Params int
+	Fn     func(args []any) (any, error)
+}
+
+func NewBuiltinCallable(name string, params int, fn func(args []any) (any, error)) Callable {
+	return &BuiltinCallable{
+		Name:   name,
+		Params: params,
+		Fn:     fn,
+	}
+}
+
+func (b *BuiltinCallable) Call(interpreter core.InterpreterHandler, args []any) (any, error) {
+	return b.Fn(args)
+}
+
+func (b *BuiltinCallable) Arity() int {
+	return b.Params
+}
+
+func (b *BuiltinCallable) String() string {
+	return fmt.Sprintf("<native fn %s>", b.Name)
+}
+
+// Every builtin by the name it is defined under
+func Builtins() map[string]Callable {
+	return map[string]Callable{
+		"clock":  NewClockCallable(),
+		"len":    NewBuiltinCallable("len", 1, builtinLen),
+		"keys":   NewBuiltinCallable("keys", 1, builtinKeys),
+		"values": NewBuiltinCallable("values", 1, builtinValues),
+		"has":    NewBuiltinCallable("has", 2, builtinHas),
+		"delete": NewBuiltinCallable("delete", 2, builtinDelete),
+		"append": NewBuiltinCallable("append", 2, builtinAppend),
+	}
+}
+
+func builtinLen(args []any) (any, error) {
+	switch v := args[0].(type) {
+	case string:
+		return int64(utf8.RuneCountInString(v)), nil
+	case *Gmap:
+		return int64(v.Len()), nil
+	case *Glist:
+		return int64(v.Len()), nil
+	}
+	return nil, glorpups.NewTypeGlorpup(token.Token{}, fmt.Sprintf("len() not supported for %s.", TypeName(args[0])), nil)
+}
+
+func builtinKeys(args []any) (any, error) {
+	m, err := gmapArg("keys", args[0])
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]any, len(m.Keys))
+	copy(keys, m.Keys)
+	return NewGlist(keys), nil
+}
+
+func builtinValues(args []any) (any, error) {
+	m, err := gmapArg("values", args[0])
+	if err != nil {
+		return nil, err
+	}
+	vals := make([]any, 0, len(m.Keys))
+	for _, k := range m.Keys {
+		vals = append(vals, m.Vals[k])
+	}
+	return NewGlist(vals), nil
+}
+
+func builtinHas(args []any) (any, error) {
+	m, err := gmapArg("has", args[0])
+	if err != nil {
+		return nil, err
+	}
+	return m.Has(args[1]), nil
+}
+
+func builtinDelete(args []any) (any, error) {
+	m, err := gmapArg("delete", args[0])
+	if err != nil {
+		return nil, err
+	}
+	m.Delete(args[1])
+	return nil, nil
+}
+
+// Adds item to the end of the glist in place, handing the glist back so xs = append(xs, v) also reads right
+func builtinAppend(args []any) (any, error) {
+	list, ok := args[0].(*Glist)
+	if !ok {
+		return nil, glorpups.NewTypeGlorpup(token.Token{}, fmt.Sprintf("append() expects a glist, got %s.", TypeName(args[0])), nil)
+	}
+	list.Append(args[1])
+	return list, nil
+}
+
+func gmapArg(name string, arg any) (*Gmap, error) {
+	m, ok := arg.(*Gmap)
+	if !ok {
+		return nil, glorpups.NewTypeGlorpup(token.Token{}, fmt.Sprintf("%s() expects a gmap, got %s.", name, TypeName(arg)), nil)
+	}
+	return m, nil
+}
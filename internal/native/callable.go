@@ -2,8 +2,8 @@ package native
 
 import (
 	"fmt"
-	herror "hype-script/internal/error"
 	"hype-script/internal/environment"
+	herror "hype-script/internal/error"
 	"hype-script/internal/types"
 	"hype-script/internal/types/core"
 )
@@ -16,11 +16,13 @@ type Callable interface {
 
 type GlorpFunction struct {
 	Declaration types.Fun
+	Closure     types.EnvironmentHandler // Env the function was declared in, its body sees what that env sees
 }
 
-func NewGlorpFunction(declaration types.Fun) Callable {
+func NewGlorpFunction(declaration types.Fun, closure types.EnvironmentHandler) Callable {
 	return &GlorpFunction{
 		Declaration: declaration,
+		Closure:     closure,
 	}
 }
 
@@ -28,19 +30,33 @@ func NewGlorpFunction(declaration types.Fun) Callable {
 // A new environment is necassary when thinking about recursive funs
 // They do not share local vars
 func (f *GlorpFunction) Call(interpreter core.InterpreterHandler, args []any) (any, error) {
-	environment := environment.NewEnvironment(interpreter.GetGlobals())
+	environment := environment.NewEnvironment(f.Closure)
+	body := f.Declaration.Body
+	var defaults []types.Stmt
 	for i := 0; i < len(f.Declaration.Params); i++ {
+		param := f.Declaration.Params[i]
+		// Defaults run inside the call so they can see the params before them
+		if args[i] == Missing {
+			defaults = append(defaults, types.NewVar(param, f.Declaration.Defaults[i], false))
+			continue
+		}
 		// Place passed args as accessible in the body locally
-		environment.Define(f.Declaration.Params[i].Lexeme, args[i])
+		environment.Define(param.Lexeme, args[i])
+	}
+	if f.Declaration.Rest.Lexeme != "" {
+		environment.Define(f.Declaration.Rest.Lexeme, args[len(f.Declaration.Params)])
+	}
+	if len(defaults) > 0 {
+		body = append(defaults, body...)
 	}
 	// Call function and discard environ, reverting to prev
-	err := interpreter.ExecuteBlock(f.Declaration.Body, environment)
+	err := interpreter.ExecuteBlock(body, environment)
 	ret, ok := err.(*herror.ReturnErr)
 	if ok {
 		return ret.Val, nil
 	}
 
-	// _, ok = err.(*glorpError.WertErr) // If it is a wert, allow it up 
+	// _, ok = err.(*glorpError.WertErr) // If it is a wert, allow it up
 	// if ok {
 	// 	return nil, err
 	// }
@@ -51,6 +67,17 @@ func (f *GlorpFunction) Arity() int {
 	return len(f.Declaration.Params)
 }
 
+func (f *GlorpFunction) Signature() Signature {
+	sig := Signature{Rest: f.Declaration.Rest.Lexeme}
+	for i, param := range f.Declaration.Params {
+		sig.Params = append(sig.Params, param.Lexeme)
+		if i >= len(f.Declaration.Defaults) || f.Declaration.Defaults[i] == nil {
+			sig.Required++
+		}
+	}
+	return sig
+}
+
 func (f *GlorpFunction) String() string {
 	return fmt.Sprintf("<fn %s>", f.Declaration.Name.Lexeme)
 }
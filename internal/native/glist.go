@@ -0,0 +1,46 @@
+package native
+
+// Runtime value of a glist literal
+// Shared by reference, so xs[0] = 5 is seen through every name bound to xs
+type Glist struct {
+	Items []any
+}
+
+func NewGlist(items []any) *Glist {
+	if items == nil {
+		items = []any{}
+	}
+	return &Glist{
+		Items: items,
+	}
+}
+
+func (l *Glist) Len() int {
+	return len(l.Items)
+}
+
+func (l *Glist) Append(items ...any) {
+	l.Items = append(l.Items, items...)
+}
+
+// New glist holding the items of l followed by the items of other
+func (l *Glist) Concat(other *Glist) *Glist {
+	items := make([]any, 0, len(l.Items)+len(other.Items))
+	items = append(items, l.Items...)
+	items = append(items, other.Items...)
+	return NewGlist(items)
+}
+
+// Copy of items [start, end), bounds are expected to be resolved already
+func (l *Glist) Slice(start, end int) *Glist {
+	items := make([]any, end-start)
+	copy(items, l.Items[start:end])
+	return NewGlist(items)
+}
+
+// If a and b are both glists, return them and true
+func ConvGlist(a, b any) (*Glist, *Glist, bool) {
+	left, lok := a.(*Glist)
+	right, rok := b.(*Glist)
+	return left, right, lok && rok
+}
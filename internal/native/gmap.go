@@ -0,0 +1,72 @@
+package native
+
+import "math"
+
+// Runtime value of a gmap literal
+// Keys remembers insertion order so printing and keys() are deterministic
+type Gmap struct {
+	Keys []any
+	Vals map[any]any
+}
+
+func NewGmap() *Gmap {
+	return &Gmap{
+		Keys: []any{},
+		Vals: make(map[any]any),
+	}
+}
+
+// Only values that compare by value can be keys
+func IsHashable(key any) bool {
+	switch key.(type) {
+	case nil, bool, int64, float64, string:
+		return true
+	}
+	return false
+}
+
+// Whole floats are stored as ints so m[1] and m[1.0] are the same key
+func normKey(key any) any {
+	if f, ok := key.(float64); ok && f == math.Trunc(f) && math.Abs(f) < 1<<63 {
+		return int64(f)
+	}
+	return key
+}
+
+// Missing keys give newt, use Has to tell the difference
+func (m *Gmap) Get(key any) any {
+	key = normKey(key)
+	return m.Vals[key]
+}
+
+func (m *Gmap) Has(key any) bool {
+	key = normKey(key)
+	_, ok := m.Vals[key]
+	return ok
+}
+
+func (m *Gmap) Set(key any, val any) {
+	key = normKey(key)
+	if !m.Has(key) {
+		m.Keys = append(m.Keys, key)
+	}
+	m.Vals[key] = val
+}
+
+func (m *Gmap) Delete(key any) {
+	key = normKey(key)
+	if !m.Has(key) {
+		return
+	}
+	delete(m.Vals, key)
+	for i, k := range m.Keys {
+		if k == key {
+			m.Keys = append(m.Keys[:i], m.Keys[i+1:]...)
+			break
+		}
+	}
+}
+
+func (m *Gmap) Len() int {
+	return len(m.Keys)
+}
@@ -0,0 +1,343 @@
+package native
+
+import (
+	"fmt"
+	"hype-script/internal/glorpups"
+	"hype-script/internal/token"
+	"hype-script/internal/types/core"
+	"math"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/traefik/yaegi/stdlib"
+)
+
+// An imported Go package, members are looked up through yaegi by the interpreter
+type GoPackage struct {
+	Name string // Alias the package is imported under
+	Path string
+}
+
+func NewGoPackage(name, path string) *GoPackage {
+	return &GoPackage{
+		Name: name,
+		Path: path,
+	}
+}
+
+func (p *GoPackage) String() string {
+	return fmt.Sprintf("<go package %s>", p.Path)
+}
+
+// Names the package exports, sorted, read from the same yaegi symbol table the calls go through
+func (p *GoPackage) Members() []string {
+	var names []string
+	for name := range stdlib.Symbols[p.Path+"/"+path.Base(p.Path)] {
+		if !strings.HasPrefix(name, "_") { // Yaegi's interface wrappers
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// A Go func or method, hype args are converted to the exact Go param types on the way in
+// and results converted back to hype values on the way out, several results come back as a Tuple
+type GoFunction struct {
+	Name string
+	Fn   reflect.Value
+}
+
+func NewGoFunction(name string, fn reflect.Value) Callable {
+	return &GoFunction{
+		Name: name,
+		Fn:   fn,
+	}
+}
+
+func (g *GoFunction) Call(interpreter core.InterpreterHandler, args []any) (ret any, err error) {
+	fnType := g.Fn.Type()
+	variadic := fnType.IsVariadic()
+	fixed := fnType.NumIn()
+	if variadic {
+		fixed--
+	}
+	if len(args) < fixed || (!variadic && len(args) > fixed) {
+		want := fmt.Sprint(fixed)
+		if variadic {
+			want = "at least " + want
+		}
+		return nil, glorpups.NewArityGlorpup(token.Token{}, fmt.Sprintf("%s expects %s args but got %d.", g.Name, want, len(args)), nil)
+	}
+
+	in := make([]reflect.Value, len(args))
+	for i, arg := range args {
+		var param reflect.Type
+		if variadic && i >= fixed {
+			param = fnType.In(fixed).Elem()
+		} else {
+			param = fnType.In(i)
+		}
+		v, err := ToGo(arg, param)
+		if err != nil {
+			return nil, glorpups.NewTypeGlorpup(token.Token{}, fmt.Sprintf("Bad arg %d to %s: %s", i+1, g.Name, err.Error()), nil)
+		}
+		in[i] = v
+	}
+
+	// A panic inside Go shouldn't take the whole script down with it
+	defer func() {
+		if r := recover(); r != nil {
+			ret = nil
+			err = glorpups.NewRuntimeGlorpup(token.Token{}, fmt.Sprintf("%s panicked: %v", g.Name, r), nil)
+		}
+	}()
+
+	out := g.Fn.Call(in)
+	switch len(out) {
+	case 0:
+		return nil, nil
+	case 1:
+		return FromGo(out[0]), nil
+	}
+	results := make([]any, len(out))
+	for i, o := range out {
+		results[i] = FromGo(o)
+	}
+	return NewTuple(results), nil
+}
+
+// Variadic funcs take any number of args past their fixed ones
+func (g *GoFunction) Arity() int {
+	if g.Fn.Type().IsVariadic() {
+		return -1
+	}
+	return g.Fn.Type().NumIn()
+}
+
+func (g *GoFunction) String() string {
+	return fmt.Sprintf("<go fn %s>", g.Name)
+}
+
+// Converts a hype value to the Go type t
+// Ints only go into int params when they fit, and floats only when they are whole
+func ToGo(val any, t reflect.Type) (reflect.Value, error) {
+	if val == nil {
+		switch t.Kind() {
+		case reflect.Interface, reflect.Pointer, reflect.Slice, reflect.Map, reflect.Func, reflect.Chan:
+			return reflect.Zero(t), nil
+		}
+		return reflect.Value{}, fmt.Errorf("can't pass newt as %s", t)
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := wholeNumber(val)
+		if !ok {
+			break
+		}
+		v := reflect.New(t).Elem()
+		if v.OverflowInt(n) {
+			return reflect.Value{}, fmt.Errorf("%d overflows %s", n, t)
+		}
+		v.SetInt(n)
+		return v, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, ok := wholeNumber(val)
+		if !ok {
+			break
+		}
+		v := reflect.New(t).Elem()
+		if n < 0 || v.OverflowUint(uint64(n)) {
+			return reflect.Value{}, fmt.Errorf("%d overflows %s", n, t)
+		}
+		v.SetUint(uint64(n))
+		return v, nil
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		switch n := val.(type) {
+		case int64:
+			f = float64(n)
+		case float64:
+			f = n
+		default:
+			return reflect.Value{}, fmt.Errorf("can't pass %s as %s", TypeName(val), t)
+		}
+		v := reflect.New(t).Elem()
+		if v.OverflowFloat(f) {
+			return reflect.Value{}, fmt.Errorf("%v overflows %s", f, t)
+		}
+		v.SetFloat(f)
+		return v, nil
+	case reflect.Slice:
+		list, ok := val.(*Glist)
+		if !ok {
+			if s, ok := val.(string); ok && t.Elem().Kind() == reflect.Uint8 {
+				return reflect.ValueOf([]byte(s)).Convert(t), nil
+			}
+			break
+		}
+		v := reflect.MakeSlice(t, list.Len(), list.Len())
+		for i, item := range list.Items {
+			elem, err := ToGo(item, t.Elem())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			v.Index(i).Set(elem)
+		}
+		return v, nil
+	case reflect.Map:
+		m, ok := val.(*Gmap)
+		if !ok {
+			break
+		}
+		v := reflect.MakeMapWithSize(t, m.Len())
+		for _, k := range m.Keys {
+			key, err := ToGo(k, t.Key())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			elem, err := ToGo(m.Vals[k], t.Elem())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			v.SetMapIndex(key, elem)
+		}
+		return v, nil
+	case reflect.Interface:
+		// Ints are plain Go ints when Go doesn't care about the type, glists and gmaps unwrap
+		var v reflect.Value
+		var err error
+		switch n := val.(type) {
+		case int64:
+			if n >= math.MinInt && n <= math.MaxInt {
+				v = reflect.ValueOf(int(n))
+			} else {
+				v = reflect.ValueOf(n)
+			}
+		case *Glist:
+			v, err = ToGo(n, reflect.TypeOf([]any{}))
+		case *Gmap:
+			v, err = ToGo(n, reflect.TypeOf(map[any]any{}))
+		default:
+			v = reflect.ValueOf(val)
+		}
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if v.Type().Implements(t) {
+			return v, nil
+		}
+	}
+
+	v := reflect.ValueOf(val)
+	if v.Type().AssignableTo(t) {
+		return v, nil
+	}
+	if v.Kind() == t.Kind() && v.Type().ConvertibleTo(t) { // Named types like time.Duration from a Go call
+		return v.Convert(t), nil
+	}
+	return reflect.Value{}, fmt.Errorf("can't pass %s as %s", TypeName(val), t)
+}
+
+func wholeNumber(val any) (int64, bool) {
+	switch n := val.(type) {
+	case int64:
+		return n, true
+	case float64:
+		if n == math.Trunc(n) && math.Abs(n) < 1<<63 {
+			return int64(n), true
+		}
+	}
+	return 0, false
+}
+
+// Converts a Go value into the hype value it looks like
+// Anything with no hype equivalent stays a Go value and can still have its members accessed
+func FromGo(v reflect.Value) any {
+	if !v.IsValid() {
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Type().NumMethod() > 0 { // time.Duration and friends keep their methods
+			return v.Interface()
+		}
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if v.Type().NumMethod() > 0 {
+			return v.Interface()
+		}
+		if n := v.Uint(); n <= math.MaxInt64 {
+			return int64(n)
+		}
+		return float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 || (v.Kind() == reflect.Slice && v.IsNil()) {
+			return v.Interface() // Byte buffers stay bytes
+		}
+		items := make([]any, v.Len())
+		for i := range items {
+			items[i] = FromGo(v.Index(i))
+		}
+		return NewGlist(items)
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(a, b int) bool { // Go maps have no order, give gmaps a stable one
+			return fmt.Sprint(keys[a]) < fmt.Sprint(keys[b])
+		})
+		m := NewGmap()
+		for _, k := range keys {
+			key := FromGo(k)
+			if !IsHashable(key) {
+				return v.Interface()
+			}
+			m.Set(key, FromGo(v.MapIndex(k)))
+		}
+		return m
+	case reflect.Interface, reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		if v.Kind() == reflect.Interface {
+			return FromGo(v.Elem())
+		}
+	case reflect.Func:
+		if v.IsNil() {
+			return nil
+		}
+		return NewGoFunction(v.Type().String(), v)
+	}
+	return v.Interface()
+}
+
+// Looks up a method or exported field on a Go value
+func GoMember(val any, name string) (any, bool) {
+	v := reflect.ValueOf(val)
+	if !v.IsValid() {
+		return nil, false
+	}
+	if method := v.MethodByName(name); method.IsValid() {
+		return NewGoFunction(name, method), true
+	}
+	if v.Kind() == reflect.Pointer && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Struct {
+		if field, ok := v.Type().FieldByName(name); ok && field.IsExported() {
+			return FromGo(v.FieldByIndex(field.Index)), true
+		}
+	}
+	return nil, false
+}
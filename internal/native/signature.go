@@ -0,0 +1,95 @@
+package native
+
+import (
+	"fmt"
+	"hype-script/internal/glorpups"
+	"hype-script/internal/token"
+	"slices"
+)
+
+// The param names a callable declares, so calls can pass name=value and leave defaulted params out
+type Signature struct {
+	Params   []string
+	Required int    // Params before the first default, these always need an arg
+	Rest     string // Name of the ...rest param, "" if there isn't one
+}
+
+// Callables that know their param names, anything else only takes positional args
+type Signed interface {
+	Signature() Signature
+}
+
+type NamedArg struct {
+	Name token.Token
+	Val  any
+}
+
+type missing struct{}
+
+// Sits in a param slot the call left empty, the callable fills it with the default
+var Missing = &missing{}
+
+// Lines the call's args up with what fun takes, one per param with Missing where the default is used
+// Extra positional args are collected into a glist for the rest param and passed last
+func BindArgs(fun Callable, args []any, named []NamedArg, tok token.Token) ([]any, error) {
+	signed, ok := fun.(Signed)
+	if !ok {
+		if len(named) > 0 {
+			return nil, glorpups.NewTypeGlorpup(named[0].Name, fmt.Sprintf("%s doesn't take named args.", fun.String()), nil)
+		}
+		if fun.Arity() >= 0 && len(args) != fun.Arity() {
+			return nil, glorpups.NewArityGlorpup(tok, fmt.Sprintf("%s expects %d args but got %d.", fun.String(), fun.Arity(), len(args)), nil)
+		}
+		return args, nil
+	}
+
+	sig := signed.Signature()
+	bound := make([]any, len(sig.Params))
+	for i := range bound {
+		bound[i] = Missing
+	}
+	rest := []any{}
+	for i, arg := range args {
+		switch {
+		case i < len(bound):
+			bound[i] = arg
+		case sig.Rest != "":
+			rest = append(rest, arg)
+		default:
+			return nil, glorpups.NewArityGlorpup(tok, fmt.Sprintf("%s expects %s args but got %d.", fun.String(), sig.Expects(), len(args)), nil)
+		}
+	}
+
+	for _, arg := range named {
+		idx := slices.Index(sig.Params, arg.Name.Lexeme)
+		if idx < 0 {
+			return nil, glorpups.NewTypeGlorpup(arg.Name, fmt.Sprintf("%s has no param '%s'.", fun.String(), arg.Name.Lexeme), nil)
+		}
+		if bound[idx] != Missing {
+			return nil, glorpups.NewTypeGlorpup(arg.Name, fmt.Sprintf("Param '%s' was already given.", arg.Name.Lexeme), nil)
+		}
+		bound[idx] = arg.Val
+	}
+
+	for i := 0; i < sig.Required; i++ {
+		if bound[i] == Missing {
+			return nil, glorpups.NewArityGlorpup(tok, fmt.Sprintf("%s is missing arg '%s', it expects %s args.", fun.String(), sig.Params[i], sig.Expects()), nil)
+		}
+	}
+
+	if sig.Rest != "" {
+		bound = append(bound, NewGlist(rest))
+	}
+	return bound, nil
+}
+
+// How many args the signature takes, e.g. "2", "1 to 3" or "at least 1"
+func (s Signature) Expects() string {
+	switch {
+	case s.Rest != "":
+		return fmt.Sprintf("at least %d", s.Required)
+	case s.Required == len(s.Params):
+		return fmt.Sprintf("%d", s.Required)
+	}
+	return fmt.Sprintf("%d to %d", s.Required, len(s.Params))
+}
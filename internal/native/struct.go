@@ -0,0 +1,69 @@
+package native
+
+import (
+	"fmt"
+	"hype-script/internal/glorpups"
+	"hype-script/internal/token"
+	"hype-script/internal/types/core"
+)
+
+// What struct Profile { host, port } defines, calling it builds a StructVal
+type StructType struct {
+	Name   string
+	Fields []string
+}
+
+func NewStructType(name string, fields []string) *StructType {
+	return &StructType{
+		Name:   name,
+		Fields: fields,
+	}
+}
+
+// Args fill the fields in declaration order
+func (s *StructType) Call(interpreter core.InterpreterHandler, args []any) (any, error) {
+	vals := make(map[string]any, len(s.Fields))
+	for i, field := range s.Fields {
+		vals[field] = args[i]
+	}
+	return &StructVal{Type: s, Vals: vals}, nil
+}
+
+func (s *StructType) Arity() int {
+	return len(s.Fields)
+}
+
+// Profile(host="x", port=1) names fields the same way a func call names params
+func (s *StructType) Signature() Signature {
+	return Signature{Params: s.Fields, Required: len(s.Fields)}
+}
+
+func (s *StructType) String() string {
+	return fmt.Sprintf("<struct %s>", s.Name)
+}
+
+// An instance of a struct, fields are fixed by its type
+type StructVal struct {
+	Type *StructType
+	Vals map[string]any
+}
+
+func (s *StructVal) Has(field string) bool {
+	_, ok := s.Vals[field]
+	return ok
+}
+
+func (s *StructVal) Get(field string, tok token.Token) (any, error) {
+	if !s.Has(field) {
+		return nil, glorpups.NewTypeGlorpup(tok, fmt.Sprintf("%s has no field '%s'.", s.Type.Name, field), nil)
+	}
+	return s.Vals[field], nil
+}
+
+func (s *StructVal) Set(field string, val any, tok token.Token) error {
+	if !s.Has(field) {
+		return glorpups.NewTypeGlorpup(tok, fmt.Sprintf("%s has no field '%s'.", s.Type.Name, field), nil)
+	}
+	s.Vals[field] = val
+	return nil
+}
@@ -0,0 +1,16 @@
+package native
+
+// The values of return a, b or a multi result Go call, only meant to be destructured
+type Tuple struct {
+	Items []any
+}
+
+func NewTuple(items []any) *Tuple {
+	return &Tuple{
+		Items: items,
+	}
+}
+
+func (t *Tuple) Len() int {
+	return len(t.Items)
+}
@@ -0,0 +1,34 @@
+package native
+
+import "fmt"
+
+// Name of a runtime value's type as a hype user knows it, for error messages
+func TypeName(val any) string {
+	switch v := val.(type) {
+	case nil:
+		return "newt"
+	case bool:
+		return "bool"
+	case int64:
+		return "int"
+	case float64:
+		return "float"
+	case string:
+		return "string"
+	case *Glist:
+		return "glist"
+	case *Gmap:
+		return "gmap"
+	case *StructVal:
+		return v.Type.Name
+	case *StructType:
+		return "struct"
+	case Callable:
+		return "func"
+	case *Tuple:
+		return "values"
+	case *GoPackage:
+		return "go package"
+	}
+	return fmt.Sprintf("%T", val)
+}
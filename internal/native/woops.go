@@ -0,0 +1,65 @@
+package native
+
+import (
+	"fmt"
+	herror "hype-script/internal/error"
+	"hype-script/internal/glorpups"
+)
+
+// Runtime value a woops block binds its error to
+type Woops struct {
+	Kind    string // Runtime, IndexBounds, Type, Arity or Assert
+	Message string
+	Line    int
+	Val     any // Whatever was werted, newt for glorpups raised by the interpreter
+}
+
+func NewWoops(kind, message string, line int, val any) *Woops {
+	return &Woops{
+		Kind:    kind,
+		Message: message,
+		Line:    line,
+		Val:     val,
+	}
+}
+
+// Turns anything a try block failed with into the value woops sees
+func WoopsFrom(err error) *Woops {
+	switch e := err.(type) {
+	case *herror.WertErr:
+		if w, ok := e.Val.(*Woops); ok {
+			return w
+		}
+		return NewWoops("Runtime", fmt.Sprint(e.Val), e.Line, e.Val)
+	case *glorpups.RuntimeGlorpup:
+		return NewWoops("Runtime", e.Message, e.Token.Line, nil)
+	case *glorpups.IndexBoundsGlorpup:
+		return NewWoops("IndexBounds", e.Message, e.Token.Line, nil)
+	case *glorpups.TypeGlorpup:
+		return NewWoops("Type", e.Message, e.Token.Line, nil)
+	case *glorpups.ArityGlorpup:
+		return NewWoops("Arity", e.Message, e.Token.Line, nil)
+	case *glorpups.AssertGlorpup:
+		return NewWoops("Assert", e.Message, e.Token.Line, nil)
+	}
+	return NewWoops("Runtime", err.Error(), 0, nil)
+}
+
+// err.message, err.kind, err.line and err.value inside a woops block
+func (w *Woops) Member(name string) (any, bool) {
+	switch name {
+	case "message":
+		return w.Message, true
+	case "kind":
+		return w.Kind, true
+	case "line":
+		return int64(w.Line), true
+	case "value":
+		return w.Val, true
+	}
+	return nil, false
+}
+
+func (w *Woops) String() string {
+	return fmt.Sprintf("%sGlorpup: %s", w.Kind, w.Message)
+}
@@ -2,8 +2,7 @@ package parser
 
 import (
 	"errors"
-	"fmt"
-	herror "hype-script/internal/error"
+	"hype-script/internal/diag"
 	"hype-script/internal/token"
 	"hype-script/internal/types"
 )
@@ -40,6 +39,8 @@ type Parser struct {
 	Tokens      []token.Token
 	Environment types.EnvironmentHandler
 	Current     int
+	Loops       []string // Labels of the loops enclosing the current stmt, "" if unlabelled
+	Diagnostics *diag.Diagnostics
 }
 
 func NewParser(e types.EnvironmentHandler) *Parser {
@@ -47,12 +48,15 @@ func NewParser(e types.EnvironmentHandler) *Parser {
 		HadError:    false,
 		Current:     0,
 		Environment: e,
+		Diagnostics: &diag.Diagnostics{},
 	}
 }
 
 // Takes in parsed tokens from Scanner and outputs list of Statements
 func (p *Parser) ParseTokens(tokens []token.Token) ([]types.Stmt, error) {
 	p.Tokens = tokens
+	p.Current = 0
+	p.Diagnostics.Reset()
 	statements := []types.Stmt{}
 
 	// We see no tokens, just return
@@ -61,18 +65,41 @@ func (p *Parser) ParseTokens(tokens []token.Token) ([]types.Stmt, error) {
 	// While we are still within range of passed tokens
 	for !p.isAtEnd() {
 		p.match(token.END)           // Consume endline token if its there
+		reported := p.Diagnostics.Len()
 		decl, err := p.declaration() // Decl is start of recursive statment parsing
 		if err != nil {
-			fmt.Println("Parser error: ", err.Error())
+			if p.Diagnostics.Len() == reported { // Nothing said why yet
+				p.error(p.peek(), err.Error())
+			}
 			p.HadError = true
 			p.syncronize()
 			continue
 		}
 		statements = append(statements, decl)
 	}
-	var err error = nil
-	if p.HadError { err = fmt.Errorf("error encountered in parser") }
-	return statements, err
+	return statements, p.Diagnostics.Err()
+}
+
+func (p *Parser) GetDiagnostics() *diag.Diagnostics {
+	return p.Diagnostics
+}
+
+// Records a syntax error at tok, callers still return an error to unwind
+func (p *Parser) error(tok token.Token, message string) {
+	if tok.Type == token.ERROR { // The scanner already said what's wrong here
+		return
+	}
+	if tok.Type == token.EOF {
+		message += " Reached the end of the file."
+	}
+	// Unwinding through several rules can report the same spot more than once
+	if n := p.Diagnostics.Len(); n > 0 {
+		last := p.Diagnostics.List[n-1]
+		if last.Span == tok.Span() && last.Message == message {
+			return
+		}
+	}
+	p.Diagnostics.Errorf(diag.Syntax, tok.Span(), "%s", message)
 }
 
 // If passed token is the type of next token, consume it, otherwise error
@@ -81,10 +108,20 @@ func (p *Parser) consume(tokType token.TokenType, message string) (token.Token,
 		return p.advance(), nil
 	} // If next token is passed type, consume it and pass the previous token
 
-	herror.ParserError(p.peek(), message)
+	p.error(p.peek(), message)
 	return token.Token{}, errors.New(message)
 }
 
+// Statements end at an END, or at the '}' closing a one line block { print x }
+// The '}' is left for the block to consume
+func (p *Parser) endStmt(message string) error {
+	if p.check(token.RIGHT_BRACE) || p.isAtEnd() {
+		return nil
+	}
+	_, err := p.consume(token.END, message)
+	return err
+}
+
 // Discards tokens until it has found the end of a statement
 // Now we begin again at the next statement
 // Hopefully all tokens that would have been affected by an earlier error are discorded
@@ -97,16 +134,8 @@ func (p *Parser) syncronize() {
 		} // Found statement boundary
 
 		switch p.peek().Type {
-		case token.FUN:
-		case token.PAR:
-		case token.HYP:
-		case token.IMPORT:
-		case token.VAR:
-		case token.FOR:
-		case token.IF:
-		case token.WHILE:
-		case token.PRINT:
-		case token.RETURN: // Found statement boundry here too
+		case token.FUN, token.PAR, token.HYP, token.IMPORT, token.VAR, token.FOR, token.IF, token.WHILE, token.PRINT,
+			token.BREAK, token.CONTINUE, token.TRY, token.WERT, token.SWITCH, token.STRUCT, token.RETURN: // Found statement boundry here too
 			return
 		}
 		p.advance()
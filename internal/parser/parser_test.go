@@ -0,0 +1,251 @@
+package parser
+
+import (
+	"hype-script/internal/diag"
+	"hype-script/internal/environment"
+	"hype-script/internal/scanner"
+	"hype-script/internal/token"
+	"hype-script/internal/types"
+	"testing"
+)
+
+func parse(src string) error {
+	tokens, _ := scanner.NewScanner().ScanTokens(src)
+	_, err := NewParser(environment.NewEnvironment(nil)).ParseTokens(tokens)
+	return err
+}
+
+func TestLoopControlOutsideLoop(t *testing.T) {
+	bad := []string{
+		"break\n",
+		"continue\n",
+		"while true {\n    func f() {\n        break\n    }\n}\n",
+		"a: while true {\n    break b\n}\n",
+	}
+	for _, src := range bad {
+		if parse(src) == nil {
+			t.Errorf("expected parse error for %q", src)
+		}
+	}
+
+	good := []string{
+		"while true {\n    break\n}\n",
+		"a: while true {\n    while true {\n        continue a\n    }\n}\n",
+	}
+	for _, src := range good {
+		if err := parse(src); err != nil {
+			t.Errorf("unexpected parse error for %q: %v", src, err)
+		}
+	}
+}
+
+func TestTryWoopsSyntax(t *testing.T) {
+	bad := []string{
+		"try {\n    print 1\n}\n",
+		"try print 1\n",
+		"try { print 1 } woops err print err\n",
+		"wert\n",
+	}
+	for _, src := range bad {
+		if parse(src) == nil {
+			t.Errorf("expected parse error for %q", src)
+		}
+	}
+
+	good := []string{
+		"try {\n    print 1\n} woops err {\n    print err\n}\n",
+		"try { wert \"x\" } woops { print 1 }\n",
+		"if true {\n    print 1\n} else {\n    print 2\n}\n",
+	}
+	for _, src := range good {
+		if err := parse(src); err != nil {
+			t.Errorf("unexpected parse error for %q: %v", src, err)
+		}
+	}
+}
+
+func TestDestructureTargets(t *testing.T) {
+	bad := []string{
+		"a, 1 = 2, 3\n",
+		"m.k, b := 1, 2\n",
+		"a, b\n",
+	}
+	for _, src := range bad {
+		if parse(src) == nil {
+			t.Errorf("expected parse error for %q", src)
+		}
+	}
+
+	good := []string{
+		"a, b = b, a\n",
+		"q, r := f()\n",
+		"xs[0], m.k = 1, 2\n",
+		"func f() {\n    return 1, 2\n}\n",
+	}
+	for _, src := range good {
+		if err := parse(src); err != nil {
+			t.Errorf("unexpected parse error for %q: %v", src, err)
+		}
+	}
+}
+
+func TestShortVarDeclSyntax(t *testing.T) {
+	for _, src := range []string{"1 := 2\n", "m.k := 1\n", "xs[0] := 1\n", "x :=\n"} {
+		if parse(src) == nil {
+			t.Errorf("expected parse error for %q", src)
+		}
+	}
+	for _, src := range []string{"x := 1\n", "t := time.Now()\n", "for i := 0; i < 3; i++ {\n    print i\n}\n", "{ x := 1 }\n"} {
+		if err := parse(src); err != nil {
+			t.Errorf("unexpected parse error for %q: %v", src, err)
+		}
+	}
+}
+
+func TestSwitchSyntax(t *testing.T) {
+	bad := []string{
+		"switch x {\ncase 1\n    print 1\n}\n",
+		"switch x {\ndefault:\ndefault:\n}\n",
+		"switch x {\nprint 1\n}\n",
+		"switch x {\ncase [...rest, a]:\n}\n",
+	}
+	for _, src := range bad {
+		if parse(src) == nil {
+			t.Errorf("expected parse error for %q", src)
+		}
+	}
+
+	good := []string{
+		"switch os.Args[1] {\ncase \"up\", \"start\":\n    up()\ncase \"down\":\ndefault:\n    print 1\n}\n",
+		"switch {\ncase x > 1: print 1\n}\n",
+		"switch args {\ncase [\"route\", _, ...rest]:\n    print rest\ncase [a, [b, c]]:\n}\n",
+	}
+	for _, src := range good {
+		if err := parse(src); err != nil {
+			t.Errorf("unexpected parse error for %q: %v", src, err)
+		}
+	}
+}
+
+func TestStructSyntax(t *testing.T) {
+	for _, src := range []string{"struct { a }\n", "struct P { a, a }\n", "struct P { 1 }\n", "struct P a, b\n"} {
+		if parse(src) == nil {
+			t.Errorf("expected parse error for %q", src)
+		}
+	}
+	for _, src := range []string{"struct P { host, port }\n", "struct P {\n    host\n    port,\n}\n", "struct Empty {}\n"} {
+		if err := parse(src); err != nil {
+			t.Errorf("unexpected parse error for %q: %v", src, err)
+		}
+	}
+}
+
+// Member access can follow any call or index, not just a name
+func TestAccessAfterPostfix(t *testing.T) {
+	for _, src := range []string{"print ps[0].host\n", "print f().a.b\n", "ps[0].host = 1\n", "print m[\"k\"].x[1]\n"} {
+		tokens, _ := scanner.NewScanner().ScanTokens(src)
+		stmts, err := NewParser(environment.NewEnvironment(nil)).ParseTokens(tokens)
+		if err != nil {
+			t.Errorf("unexpected parse error for %q: %v", src, err)
+			continue
+		}
+		var expr types.Expr
+		switch s := stmts[0].(type) {
+		case *types.Print:
+			expr = s.Expr
+		case *types.Expression:
+			expr = s.Expr
+		}
+		switch expr.(type) {
+		case *types.AccessExpr, *types.IndexAssignExpr:
+		default:
+			t.Errorf("expected %q to parse as member access, got %T", src, expr)
+		}
+	}
+}
+
+func TestCallArgSyntax(t *testing.T) {
+	for _, src := range []string{
+		"func f(a = 1, b) {}\n",
+		"func f(...a, b) {}\n",
+		"func f(a, a) {}\n",
+		"f(a=1, 2)\n",
+		"f(a=1, a=2)\n",
+	} {
+		if parse(src) == nil {
+			t.Errorf("expected parse error for %q", src)
+		}
+	}
+	for _, src := range []string{
+		"func f(a, b = 1, ...rest) {}\n",
+		"func f(...rest) {}\n",
+		"f(1, ...xs, b=2)\n",
+		"f(\n    a=1,\n    b=2,\n)\n",
+	} {
+		if err := parse(src); err != nil {
+			t.Errorf("unexpected parse error for %q: %v", src, err)
+		}
+	}
+}
+
+func TestNodeSpans(t *testing.T) {
+	s := scanner.NewScanner()
+	s.SetFile("vpn.hyp")
+	tokens, _ := s.ScanTokens("func up(iface, port = 1194) {\n    print \"${iface}:${port}\"\n}\nvar ok = (up(\"tun0\") == newt)\n")
+	stmts, err := NewParser(environment.NewEnvironment(nil)).ParseTokens(tokens)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fun := stmts[0].(*types.Fun)
+	checkSpan(t, "func", fun.Span(), "vpn.hyp:1:6", 2, 29)
+	checkSpan(t, "default", fun.Defaults[1].Span(), "vpn.hyp:1:23", 1, 27)
+	checkSpan(t, "print", fun.Body[0].Span(), "vpn.hyp:2:5", 2, 29)
+
+	// The ${iface} inside the string points at where it sits in the file, not the string
+	interp := fun.Body[0].(*types.Print).Expr.(*types.InterpolationExpr)
+	checkSpan(t, "interpolated var", interp.Parts[0].Span(), "vpn.hyp:2:14", 2, 19)
+
+	v := stmts[1].(*types.Var)
+	checkSpan(t, "var", v.Span(), "vpn.hyp:4:5", 4, 30)
+	checkSpan(t, "grouping", v.Initializer.Span(), "vpn.hyp:4:10", 4, 30)
+	call := v.Initializer.(*types.GroupingExpr).Expr.(*types.BinaryExpr).Left
+	checkSpan(t, "call", call.Span(), "vpn.hyp:4:11", 4, 21)
+}
+
+func checkSpan(t *testing.T, what string, span token.Span, start string, endLine, endCol int) {
+	t.Helper()
+	if span.String() != start || span.End.Line != endLine || span.End.Col != endCol {
+		t.Errorf("%s: expected %s to %d:%d, got %s to %d:%d", what, start, endLine, endCol, span, span.End.Line, span.End.Col)
+	}
+}
+
+func TestParseDiagnostics(t *testing.T) {
+	s := scanner.NewScanner()
+	s.SetFile("vpn.hyp")
+	tokens, _ := s.ScanTokens("var a = 1 + * 2\nvar b = )\nprint \"${)}\"\n")
+	p := NewParser(environment.NewEnvironment(nil))
+	_, err := p.ParseTokens(tokens)
+	if err != p.GetDiagnostics() {
+		t.Fatalf("expected the parser's diagnostics back as the error, got %v", err)
+	}
+	want := []string{"vpn.hyp:1:13", "vpn.hyp:2:9", "vpn.hyp:3:10"}
+	if p.Diagnostics.Len() != len(want) {
+		t.Fatalf("expected %d diagnostics, got %v", len(want), err)
+	}
+	for i, d := range p.Diagnostics.List {
+		if d.Code != diag.Syntax || d.Severity != diag.Error || d.Span.String() != want[i] {
+			t.Errorf("diagnostic %d: expected a syntax error at %s, got %v", i, want[i], d)
+		}
+	}
+}
+
+func TestParseErrorTokens(t *testing.T) {
+	// The scanner already reported the @, the parser shouldn't pile its own error on top
+	tokens, _ := scanner.NewScanner().ScanTokens("var a = @\nvar b = )\n")
+	p := NewParser(environment.NewEnvironment(nil))
+	p.ParseTokens(tokens)
+	if p.Diagnostics.Len() != 1 || p.Diagnostics.List[0].Span.String() != "<stdin>:2:9" {
+		t.Errorf("expected one syntax error for the ')', got %v", p.Diagnostics)
+	}
+}
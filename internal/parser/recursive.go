@@ -3,8 +3,8 @@ package parser
 import (
 	"errors"
 	"fmt"
-	herror "hype-script/internal/error"
 	"hype-script/internal/literal"
+	"hype-script/internal/scanner"
 	"hype-script/internal/token"
 	"hype-script/internal/types"
 )
@@ -18,6 +18,9 @@ func (p *Parser) declaration() (types.Stmt, error) {
 	if p.match(token.FUN) {
 		return p.funDeclaration()
 	}
+	if p.match(token.STRUCT) {
+		return p.structDeclaration()
+	}
 	// If not, fallback to standard stmt
 	return p.statement()
 }
@@ -35,16 +38,53 @@ func (p *Parser) funDeclaration() (types.Stmt, error) {
 	}
 
 	var params []token.Token
+	var defaults []types.Expr
+	var rest token.Token
+	seen := map[string]bool{}
+	// Scanner places END before ')'
+	p.match(token.END)
 	if !p.check(token.RIGHT_PAREN) { // The next item is an identifier
 		for {
 			if len(params) >= 255 {
-				herror.ParserError(p.peek(), "Number of params exceeds 255 limit.")
+				p.error(p.peek(), "Number of params exceeds 255 limit.")
 			}
+			spread := p.match(token.ELLIPSIS)
 			val, err := p.consume(token.IDENTIFIER, "Expect identifier as paramteter.")
 			if err != nil {
 				return nil, err
 			}
+			if seen[val.Lexeme] {
+				msg := fmt.Sprintf("Duplicate param '%s' in %s.", val.Lexeme, name.Lexeme)
+				p.error(val, msg)
+				return nil, errors.New(msg)
+			}
+			seen[val.Lexeme] = true
+			p.match(token.END)
+
+			// ...rest soaks up whatever is left so nothing can follow it
+			if spread {
+				rest = val
+				if !p.check(token.RIGHT_PAREN) {
+					msg := fmt.Sprintf("Rest param '...%s' must be the last param.", val.Lexeme)
+					p.error(val, msg)
+					return nil, errors.New(msg)
+				}
+				break
+			}
+
+			var def types.Expr
+			if p.match(token.EQUAL) {
+				if def, err = p.expression(); err != nil {
+					return nil, err
+				}
+				p.match(token.END)
+			} else if len(defaults) > 0 && defaults[len(defaults)-1] != nil {
+				msg := fmt.Sprintf("Param '%s' needs a default, it comes after one that has one.", val.Lexeme)
+				p.error(val, msg)
+				return nil, errors.New(msg)
+			}
 			params = append(params, val)
+			defaults = append(defaults, def)
 
 			if p.check(token.RIGHT_PAREN) {
 				break
@@ -65,12 +105,52 @@ func (p *Parser) funDeclaration() (types.Stmt, error) {
 		return nil, err
 	}
 
+	// Loops do not reach into the function body, break can't leave a function
+	loops := p.Loops
+	p.Loops = nil
 	body, err := p.block()
+	p.Loops = loops
 	if err != nil {
 		return nil, err
 	}
 
-	return types.NewFun(name, params, body, p.Environment), nil
+	return types.NewFun(name, params, defaults, rest, body, p.Environment), nil
+}
+
+// struct Profile { host, port }, fields split by commas or newlines
+func (p *Parser) structDeclaration() (types.Stmt, error) {
+	name, err := p.consume(token.IDENTIFIER, "Expect struct name.")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.consume(token.LEFT_BRACE, "Expect '{' after struct name."); err != nil {
+		return nil, err
+	}
+
+	var fields []token.Token
+	seen := map[string]bool{}
+	for !p.check(token.RIGHT_BRACE) && !p.isAtEnd() {
+		if p.match(token.END, token.COMMA) {
+			continue
+		}
+		field, err := p.consume(token.IDENTIFIER, "Expect field name.")
+		if err != nil {
+			return nil, err
+		}
+		if seen[field.Lexeme] {
+			msg := fmt.Sprintf("Duplicate field '%s' in struct %s.", field.Lexeme, name.Lexeme)
+			p.error(field, msg)
+			return nil, errors.New(msg)
+		}
+		seen[field.Lexeme] = true
+		fields = append(fields, field)
+	}
+
+	if _, err := p.consume(token.RIGHT_BRACE, "Expect '}' after struct fields."); err != nil {
+		return nil, err
+	}
+	p.match(token.END)
+	return types.NewStruct(name, fields), nil
 }
 
 // The problem is that all functions are defined within the global scope
@@ -99,8 +179,7 @@ func (p *Parser) varDeclaration() (types.Stmt, error) {
 		}
 	}
 
-	_, err = p.consume(token.END, "Expect 'end' after var decl/init.")
-	if err != nil {
+	if err := p.endStmt("Expect 'end' after var decl/init."); err != nil {
 		return nil, err
 	}
 
@@ -110,16 +189,41 @@ func (p *Parser) varDeclaration() (types.Stmt, error) {
 
 // Decide what kind of statement to branch to
 func (p *Parser) statement() (types.Stmt, error) {
+	// outer: while ... { }
+	if p.check(token.IDENTIFIER) && p.peekNext().Type == token.COLON {
+		return p.labeledStmt()
+	}
+
 	if p.match(token.RETURN) {
 		return p.returnStmt()
 	}
 
+	if p.match(token.BREAK) {
+		return p.breakStmt()
+	}
+
+	if p.match(token.CONTINUE) {
+		return p.continueStmt()
+	}
+
 	if p.match(token.PRINT) {
 		return p.printStmt()
 	}
 
+	if p.match(token.TRY) {
+		return p.tryStmt()
+	}
+
+	if p.match(token.SWITCH) {
+		return p.switchStmt()
+	}
+
+	if p.match(token.WERT) {
+		return p.wertStmt()
+	}
+
 	if p.match(token.FOR) {
-		return p.forStmt()
+		return p.forStmt(token.Token{})
 	}
 
 	if p.match(token.IF) {
@@ -127,7 +231,7 @@ func (p *Parser) statement() (types.Stmt, error) {
 	}
 
 	if p.match(token.WHILE) {
-		return p.whileStmt()
+		return p.whileStmt(token.Token{})
 	}
 
 	if p.match(token.PAR) {
@@ -176,10 +280,8 @@ func (p *Parser) block() ([]types.Stmt, error) {
 		return nil, err
 	}
 
-	_, err = p.consume(token.END, "Expect 'end' after block.")
-	if err != nil {
-		return nil, err
-	}
+	// A block can be followed by more of its statement, } else {, } woops err {
+	p.match(token.END)
 
 	return stmts, nil
 }
@@ -197,20 +299,47 @@ func (p *Parser) assignment() (types.Expr, error) {
 	}
 
 	if p.match(token.EQUAL) {
+		equals := p.previous()
 		if val, err = p.assignment(); err != nil {
 			return nil, err
 		}
 
-		// If expr is a var
-		if varExpr, ok := expr.(*types.VarExpr); ok {
-			name := varExpr.Name
-			return types.NewAssignExpr(name, val), nil
+		switch target := p.assignTarget(expr, equals).(type) {
+		case *types.VarExpr: // x = v
+			return types.NewAssignExpr(target.Name, val), nil
+		case *types.IndexExpr: // m["k"] = v
+			return types.NewIndexAssignExpr(target.Expr, target.Index, val, equals), nil
 		}
+
+		msg := "Invalid assignment target."
+		p.error(equals, msg)
+		return nil, errors.New(msg)
 	}
 
 	return expr, nil
 }
 
+// Normalises what can sit on the left of '=' to a VarExpr or IndexExpr, nil if it can't
+// m.k is sugar for m["k"]
+func (p *Parser) assignTarget(expr types.Expr, equals token.Token) types.Expr {
+	switch target := expr.(type) {
+	case *types.VarExpr, *types.IndexExpr:
+		return target
+	case *types.AccessExpr:
+		field, ok := target.Exprs[len(target.Exprs)-1].(*types.VarExpr)
+		if !ok {
+			return nil
+		}
+		var receiver types.Expr = types.NewAccessExpr(target.Exprs[:len(target.Exprs)-1])
+		if len(target.Exprs) == 2 {
+			receiver = target.Exprs[0]
+		}
+		key := types.NewLiteralExpr(literal.NewLiteral(field.Name.Lexeme), field.Name)
+		return types.NewIndexExpr(receiver, key, equals)
+	}
+	return nil
+}
+
 func (p *Parser) or() (types.Expr, error) {
 	expr, err := p.and()
 	if err != nil {
@@ -269,7 +398,7 @@ func (p *Parser) equality() (types.Expr, error) {
 }
 
 func (p *Parser) comparison() (types.Expr, error) {
-	expr, err := p.term()
+	expr, err := p.bitOr()
 	if err != nil {
 		return nil, err
 	}
@@ -277,7 +406,40 @@ func (p *Parser) comparison() (types.Expr, error) {
 	// While we are currently in a token that is composed of 2 of these
 	for p.match(token.GREATER, token.GREATER_EQUAL, token.LESS, token.LESS_EQUAL) {
 		operator := p.previous()
-		right, err := p.term()
+		right, err := p.bitOr()
+		if err != nil {
+			return nil, err
+		}
+		expr = types.NewBinaryExpr(expr, operator, right)
+	}
+
+	return expr, nil
+}
+
+// Bitwise ops sit between comparison and term like python, so
+// x & mask == 0 is (x & mask) == 0 and 1 << 32 - prefix is 1 << (32 - prefix)
+func (p *Parser) bitOr() (types.Expr, error) {
+	return p.binaryLevel(p.bitAnd, token.PIPE)
+}
+
+func (p *Parser) bitAnd() (types.Expr, error) {
+	return p.binaryLevel(p.shift, token.AMPERSAND)
+}
+
+func (p *Parser) shift() (types.Expr, error) {
+	return p.binaryLevel(p.term, token.LESS_LESS, token.GREATER_GREATER)
+}
+
+// Left associative level of binary operators, each operand parsed by next
+func (p *Parser) binaryLevel(next func() (types.Expr, error), operators ...token.TokenType) (types.Expr, error) {
+	expr, err := next()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match(operators...) {
+		operator := p.previous()
+		right, err := next()
 		if err != nil {
 			return nil, err
 		}
@@ -312,7 +474,7 @@ func (p *Parser) factor() (types.Expr, error) {
 		return nil, err
 	}
 
-	for p.match(token.SLASH, token.STAR) {
+	for p.match(token.SLASH, token.STAR, token.PERCENT, token.TILDE_SLASH) {
 		operator := p.previous()
 		right, err := p.unary()
 		if err != nil {
@@ -333,62 +495,79 @@ func (p *Parser) unary() (types.Expr, error) {
 		}
 		return types.NewUnaryExpr(operator, right), nil
 	}
-	return p.access()
+	return p.power()
 	// Must have reached highest level precedence
 }
 
-// Mwah (may 30 2025)
-func (p *Parser) access() (types.Expr, error) {
-	if p.peekNext().Type == token.DOT {
-		e, err := p.postfix()
+// ** binds tighter than a unary on its left and is right associative
+// -2 ** 2 is -(2 ** 2), 2 ** 3 ** 2 is 2 ** (3 ** 2) and 2 ** -1 works
+func (p *Parser) power() (types.Expr, error) {
+	expr, err := p.postfix()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.match(token.STAR_STAR) {
+		operator := p.previous()
+		right, err := p.unary()
 		if err != nil {
 			return nil, err
 		}
-		exprs := []types.Expr{e}
-		for p.match(token.DOT) {
-			e, err = p.postfix()
-			if err != nil {
-				return nil, err
-			}
-			exprs = append(exprs, e)
-			if p.match(token.END) {
-				return types.NewAccessExpr(exprs), nil
-			}
-		}
+		expr = types.NewBinaryExpr(expr, operator, right)
 	}
-	return p.postfix()
+
+	return expr, nil
 }
 
-func (p *Parser) postfix() (types.Expr, error) {
-	switch p.peekNext().Type {
-	case token.PLUS_PLUS, token.MINUS_MINUS:
-		left, err := p.call()
+// Mwah (may 30 2025)
+// The root can be any call or index, so ps[0].host and f().a work as well as p.host
+func (p *Parser) access() (types.Expr, error) {
+	e, err := p.call()
+	if err != nil || !p.check(token.DOT) {
+		return e, err
+	}
+	exprs := []types.Expr{e}
+	for p.match(token.DOT) {
+		e, err = p.call()
 		if err != nil {
 			return nil, err
 		}
-		if !p.match(token.PLUS_PLUS, token.MINUS_MINUS) {
-			msg := "Expected ++ or -- postfix."
-			herror.ParserError(p.peek(), msg)
-			return nil, errors.New(msg)
-		}
-		return types.NewPostfixExpr(left, p.previous()), nil
+		exprs = append(exprs, e)
 	}
-	return p.call()
+	return types.NewAccessExpr(exprs), nil
+}
+
+// Applies to the whole target, so xs[0]++ and p.port++ work as well as i++
+func (p *Parser) postfix() (types.Expr, error) {
+	expr, err := p.access()
+	if err != nil {
+		return nil, err
+	}
+	if p.match(token.PLUS_PLUS, token.MINUS_MINUS) {
+		return types.NewPostfixExpr(expr, p.previous()), nil
+	}
+	return expr, nil
 }
 
 func (p *Parser) call() (types.Expr, error) {
 	var expr types.Expr
 	var err error
-	if expr, err = p.index(); err != nil {
+	if expr, err = p.primary(); err != nil {
 		return nil, err
 	}
 
+	// Calls and indexes chain in any order, f()[0], xs[0][1], fs[0]()
 	for {
 		if p.match(token.LEFT_PAREN) { // If, after consuming maybe identifier, an opening paren exists
 			expr, err = p.finishCall(expr)
 			if err != nil {
 				return nil, err
 			}
+		} else if p.match(token.LEFT_BRACKET) {
+			expr, err = p.finishIndex(expr)
+			if err != nil {
+				return nil, err
+			}
 		} else {
 			break
 		}
@@ -399,28 +578,63 @@ func (p *Parser) call() (types.Expr, error) {
 
 func (p *Parser) finishCall(callee types.Expr) (types.Expr, error) {
 	var args []types.Expr
+	var named []*types.NamedArg
 
 	// What happens when foo(
 	// If right paren is not there we assume params
 	//
+	p.match(token.END)
 	if !p.check(token.RIGHT_PAREN) { // If we dont see right paren as we are walking the args
 		for {
-			if len(args) >= 255 {
+			if len(args)+len(named) >= 255 {
 				fmt.Println("Args is over 255!")
 				break
 			}
-			expr, err := p.expression()
-			if err != nil {
-				return nil, err
+			switch {
+			case p.check(token.IDENTIFIER) && p.peekNext().Type == token.EQUAL: // port=8080
+				name := p.advance()
+				p.advance()
+				for _, n := range named {
+					if n.Name.Lexeme == name.Lexeme {
+						msg := fmt.Sprintf("Named arg '%s' given twice.", name.Lexeme)
+						p.error(name, msg)
+						return nil, errors.New(msg)
+					}
+				}
+				val, err := p.expression()
+				if err != nil {
+					return nil, err
+				}
+				named = append(named, &types.NamedArg{Name: name, Val: val})
+			case len(named) > 0:
+				msg := "Positional args must come before named args."
+				p.error(p.peek(), msg)
+				return nil, errors.New(msg)
+			case p.match(token.ELLIPSIS): // ...xs
+				dots := p.previous()
+				expr, err := p.expression()
+				if err != nil {
+					return nil, err
+				}
+				args = append(args, types.NewSpreadExpr(dots, expr))
+			default:
+				expr, err := p.expression()
+				if err != nil {
+					return nil, err
+				}
+				args = append(args, expr)
 			}
-			args = append(args, expr)
-		
+
 			// foo(v END, x END) END
 			p.match(token.END)
 
 			if !p.match(token.COMMA) {
 				break
 			}
+			p.match(token.END)
+			if p.check(token.RIGHT_PAREN) { // Trailing comma on a call split over lines
+				break
+			}
 		}
 	}
 
@@ -429,44 +643,61 @@ func (p *Parser) finishCall(callee types.Expr) (types.Expr, error) {
 		return nil, err
 	}
 	// Finally perform func call
-	return types.NewCallExpr(callee, paren, args), nil
+	return types.NewCallExpr(callee, paren, args, named), nil
 }
 
-func (p *Parser) index() (types.Expr, error) {
-	expr, err := p.primary()
-	if err != nil {
-		return nil, err
+// Rest of xs[i] or xs[start:end], the '[' is already consumed
+func (p *Parser) finishIndex(expr types.Expr) (types.Expr, error) {
+	var err error
+	bracket := p.previous()
+	var idx types.Expr
+	if !p.check(token.COLON) { // xs[:2] has no start
+		if idx, err = p.expression(); err != nil {
+			return nil, err
+		}
 	}
 
-	if expr != nil && p.match(token.LEFT_BRACKET) { // If is non nil expr and leftbracket lies after, could only be index
-		idx, err := p.expression()
-		if err != nil {
-			return nil, err
+	// xs[1:3], either side can be left off
+	if p.match(token.COLON) {
+		var end types.Expr
+		if !p.check(token.RIGHT_BRACKET) {
+			if end, err = p.expression(); err != nil {
+				return nil, err
+			}
 		}
-		_, err = p.consume(token.RIGHT_BRACKET, "Expect ']' to end indexing expression.")
+		_, err = p.consume(token.RIGHT_BRACKET, "Expect ']' to end slice expression.")
 		if err != nil {
 			return nil, err
 		}
-		return types.NewIndexExpr(expr, idx), nil
+		return types.NewSliceExpr(expr, idx, end, bracket), nil
 	}
-	return expr, nil
+
+	_, err = p.consume(token.RIGHT_BRACKET, "Expect ']' to end indexing expression.")
+	if err != nil {
+		return nil, err
+	}
+	return types.NewIndexExpr(expr, idx, bracket), nil
 }
 
 func (p *Parser) primary() (types.Expr, error) {
 	if p.match(token.FALSE) {
-		return types.NewLiteralExpr(literal.NewLiteral(false)), nil
+		return types.NewLiteralExpr(literal.NewLiteral(false), p.previous()), nil
 	}
 
 	if p.match(token.TRUE) {
-		return types.NewLiteralExpr(literal.NewLiteral(true)), nil
+		return types.NewLiteralExpr(literal.NewLiteral(true), p.previous()), nil
 	}
 
 	if p.match(token.NEWT) {
-		return types.NewLiteralExpr(literal.NewLiteral(nil)), nil
+		return types.NewLiteralExpr(literal.NewLiteral(nil), p.previous()), nil
 	}
 
 	if p.match(token.NUMBER, token.STRING) {
-		return types.NewLiteralExpr(p.previous().Literal), nil
+		return types.NewLiteralExpr(p.previous().Literal, p.previous()), nil
+	}
+
+	if p.match(token.INTERPOLATION) {
+		return p.interpolation()
 	}
 
 	// IDENT DOT IDENT
@@ -489,27 +720,39 @@ func (p *Parser) primary() (types.Expr, error) {
 	// IDENT DOT IDENT DOT IDENT
 
 	if p.match(token.LEFT_PAREN) {
+		open := p.previous()
 		expr, err := p.expression()
 		if err != nil {
 			return nil, err
 		}
-		_, err = p.consume(token.RIGHT_PAREN, "Expect ')' after expression.")
+		p.match(token.END)
+		close, err := p.consume(token.RIGHT_PAREN, "Expect ')' after expression.")
 		if err != nil {
 			return nil, err
 		}
-		return types.NewGroupingExpr(expr), nil
+		return types.NewGroupingExpr(expr, open, close), nil
+	}
+
+	// Statement level '{' is always a block, so here it can only open a gmap
+	if p.match(token.LEFT_BRACE) {
+		return p.gmap()
 	}
 
 	// It has to be in a func that sees if left bracket lies after an expression
 	if p.match(token.LEFT_BRACKET) {
 		literalToken := p.previous()
 		var data []types.Expr
-		for !p.match(token.RIGHT_BRACKET) && !p.isAtEnd() {
+		for !p.isAtEnd() {
+			p.match(token.END) // Items can sit one per line
+			if p.match(token.RIGHT_BRACKET) {
+				break
+			}
 			expr, err := p.expression()
 			if err != nil {
 				return nil, err
 			}
 			data = append(data, expr)
+			p.match(token.END)
 			if !p.match(token.COMMA) {
 				_, err := p.consume(token.RIGHT_BRACKET, "Expect ']' at the end of glist.")
 				if err != nil {
@@ -523,6 +766,97 @@ func (p *Parser) primary() (types.Expr, error) {
 
 	// If we have gotten here, the token given cannot start an expression
 	msg := "Expect expression."
-	herror.ParserError(p.peek(), msg)
+	p.error(p.peek(), msg)
 	return nil, errors.New(msg)
 }
+
+// {"a": 1, b: 2}
+// A bare identifier key is shorthand for its name as a string, any other key is an expression
+func (p *Parser) gmap() (types.Expr, error) {
+	brace := p.previous()
+	var keys, vals []types.Expr
+	for {
+		p.match(token.END) // Entries can sit one per line
+		if p.match(token.RIGHT_BRACE) {
+			break
+		}
+
+		var key types.Expr
+		var err error
+		if p.check(token.IDENTIFIER) && p.peekNext().Type == token.COLON {
+			name := p.advance()
+			key = types.NewLiteralExpr(literal.NewLiteral(name.Lexeme), name)
+		} else if key, err = p.expression(); err != nil {
+			return nil, err
+		}
+		_, err = p.consume(token.COLON, "Expect ':' after gmap key.")
+		if err != nil {
+			return nil, err
+		}
+
+		val, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+		vals = append(vals, val)
+
+		p.match(token.END)
+		if !p.match(token.COMMA) {
+			_, err := p.consume(token.RIGHT_BRACE, "Expect '}' at the end of gmap.")
+			if err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+	return types.NewGmapExpr(keys, vals, brace), nil
+}
+
+// The scanner hands over the source of each ${...} as text, scan and parse it here as its own expression
+func (p *Parser) interpolation() (types.Expr, error) {
+	tok := p.previous()
+	var parts []types.Expr
+	for _, part := range tok.Literal.Val.([]token.StringPart) {
+		if !part.Expr {
+			if part.Text != "" {
+				parts = append(parts, types.NewLiteralExpr(literal.NewLiteral(part.Text), tok))
+			}
+			continue
+		}
+
+		sc := scanner.NewScanner()
+		tokens, err := sc.ScanTokens(part.Text)
+		for _, d := range sc.GetDiagnostics().List { // Point lexical errors in the ${} at the file too
+			d.Span = d.Span.Shift(part.Pos, tok.File)
+			p.Diagnostics.List = append(p.Diagnostics.List, d)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(tokens) == 0 {
+			msg := "Expect expression inside '${}'."
+			p.error(tok, msg)
+			return nil, errors.New(msg)
+		}
+		for i := range tokens { // Sub scanner counts from the start of the ${}
+			tokens[i].Shift(part.Pos, tok.File)
+		}
+
+		sub := NewParser(p.Environment)
+		sub.Tokens = tokens
+		sub.Diagnostics = p.Diagnostics
+		expr, err := sub.expression()
+		if err != nil {
+			return nil, err
+		}
+		sub.match(token.END)
+		if !sub.isAtEnd() {
+			msg := "Expect '}' after interpolated expression."
+			p.error(sub.peek(), msg)
+			return nil, errors.New(msg)
+		}
+		parts = append(parts, expr)
+	}
+	return types.NewInterpolationExpr(parts, tok), nil
+}
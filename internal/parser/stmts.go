@@ -1,15 +1,21 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
 	"hype-script/internal/literal"
 	"hype-script/internal/token"
 	"hype-script/internal/types"
 )
 
-func (p *Parser) forStmt() (types.Stmt, error) {
+func (p *Parser) forStmt(label token.Token) (types.Stmt, error) {
 	var err error
 
+	// for k in m { }
+	if p.check(token.IDENTIFIER) && p.peekNext().Type == token.IN {
+		return p.forInStmt(label)
+	}
+
 	// Dont forget
 	// Match advances 'consumes' the next token if matched
 	// Check returns wether the next is it or not simply
@@ -17,7 +23,7 @@ func (p *Parser) forStmt() (types.Stmt, error) {
 	var initializer types.Stmt
 	if p.match(token.END) { // Just a semicolon, this is directly following the opening (
 		initializer = nil
-	} else if p.match(token.VAR) { // Init is a new var, x := 1
+	} else if p.match(token.VAR) { // Init is a new var, var x = 1, x := 1 goes through exprStmt
 		if initializer, err = p.varDeclaration(); err != nil {
 			return nil, err
 		}
@@ -48,16 +54,14 @@ func (p *Parser) forStmt() (types.Stmt, error) {
 	}
 
 	var body types.Stmt = nil
-	if body, err = p.statement(); err != nil {
+	if body, err = p.loopBody(label); err != nil {
 		return nil, err
 	}
-	if increment != nil {
-		body = types.NewBlock([]types.Stmt{body, types.NewExpression(increment)})
-	}
 	if condition == nil {
-		condition = types.NewLiteralExpr(literal.NewLiteral(true))
+		condition = types.NewLiteralExpr(literal.NewLiteral(true), token.Token{})
 	}
-	body = types.NewWhile(condition, body)
+	// Increment lives on the loop rather than the end of body so continue doesn't skip it
+	body = types.NewLoop(label, condition, body, increment)
 	if initializer != nil {
 		body = types.NewBlock([]types.Stmt{initializer, body})
 	}
@@ -65,35 +69,136 @@ func (p *Parser) forStmt() (types.Stmt, error) {
 	return body, nil
 }
 
+func (p *Parser) forInStmt(label token.Token) (types.Stmt, error) {
+	name := p.advance()
+	p.advance() // 'in'
+
+	iterable, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := p.loopBody(label)
+	if err != nil {
+		return nil, err
+	}
+	return types.NewForIn(label, name, iterable, body), nil
+}
+
 func (p *Parser) returnStmt() (types.Stmt, error) {
 	keyword := p.previous()
 	var val types.Expr = nil
-	if !p.check(token.END) { // As long as ; is not the next token, cause a ; cant start an expression
-		expr, err := p.expression()
+	if !p.check(token.END) && !p.check(token.RIGHT_BRACE) { // As long as ; is not the next token, cause a ; cant start an expression
+		vals, err := p.expressionList()
 		if err != nil {
 			return nil, err
 		}
-		val = expr
+		val = vals[0]
+		if len(vals) > 1 { // return a, b
+			val = types.NewTupleExpr(vals, keyword)
+		}
 	}
-	_, err := p.consume(token.END, "Expect 'end' after return value.")
-	if err != nil {
+	if err := p.endStmt("Expect 'end' after return value."); err != nil {
 		return nil, err
 	}
 	return types.NewReturn(keyword, val), nil
 }
 
-func (p *Parser) whileStmt() (types.Stmt, error) {
+func (p *Parser) whileStmt(label token.Token) (types.Stmt, error) {
 	condition, err := p.expression()
 	if err != nil {
 		return nil, err
 	}
 
+	body, err := p.loopBody(label)
+	if err != nil {
+		return nil, err
+	}
+
+	return types.NewLoop(label, condition, body, nil), nil
+}
+
+// Parse the body of a loop with its label in scope for break and continue
+func (p *Parser) loopBody(label token.Token) (types.Stmt, error) {
+	p.Loops = append(p.Loops, label.Lexeme)
 	body, err := p.statement()
+	p.Loops = p.Loops[:len(p.Loops)-1]
+	return body, err
+}
+
+// name: while ... or name: for ...
+func (p *Parser) labeledStmt() (types.Stmt, error) {
+	label := p.advance()
+	p.advance() // ':'
+
+	for _, l := range p.Loops {
+		if l == label.Lexeme {
+			msg := fmt.Sprintf("Loop label '%s' already in use.", label.Lexeme)
+			p.error(label, msg)
+			return nil, errors.New(msg)
+		}
+	}
+
+	if p.match(token.WHILE) {
+		return p.whileStmt(label)
+	}
+	if p.match(token.FOR) {
+		return p.forStmt(label)
+	}
+
+	msg := "Expect 'while' or 'for' after loop label."
+	p.error(p.peek(), msg)
+	return nil, errors.New(msg)
+}
+
+func (p *Parser) breakStmt() (types.Stmt, error) {
+	keyword := p.previous()
+	label, err := p.loopControl()
 	if err != nil {
 		return nil, err
 	}
+	return types.NewBreak(keyword, label), nil
+}
 
-	return types.NewWhile(condition, body), nil
+func (p *Parser) continueStmt() (types.Stmt, error) {
+	keyword := p.previous()
+	label, err := p.loopControl()
+	if err != nil {
+		return nil, err
+	}
+	return types.NewContinue(keyword, label), nil
+}
+
+// Shared tail of break and continue, an optional label then END
+// Both are only valid inside a loop, and a label must name one of the enclosing loops
+func (p *Parser) loopControl() (token.Token, error) {
+	keyword := p.previous()
+	if len(p.Loops) == 0 {
+		msg := fmt.Sprintf("Can't use '%s' outside of a loop.", keyword.Lexeme)
+		p.error(keyword, msg)
+		return token.Token{}, errors.New(msg)
+	}
+
+	var label token.Token
+	if p.match(token.IDENTIFIER) {
+		label = p.previous()
+		found := false
+		for _, l := range p.Loops {
+			if l == label.Lexeme {
+				found = true
+			}
+		}
+		if !found {
+			msg := fmt.Sprintf("No enclosing loop labelled '%s'.", label.Lexeme)
+			p.error(label, msg)
+			return token.Token{}, errors.New(msg)
+		}
+	}
+
+	if err := p.endStmt(fmt.Sprintf("Expect 'end' after '%s'.", keyword.Lexeme)); err != nil {
+		return token.Token{}, err
+	}
+	return label, nil
 }
 
 func (p *Parser) ifStmt() (types.Stmt, error) {
@@ -116,15 +221,15 @@ func (p *Parser) ifStmt() (types.Stmt, error) {
 }
 
 func (p *Parser) printStmt() (types.Stmt, error) {
+	keyword := p.previous()
 	val, err := p.expression()
 	if err != nil {
 		return nil, err
 	}
-	_, err = p.consume(token.END, "Expect 'end' after value.")
-	if err != nil {
+	if err := p.endStmt("Expect 'end' after value."); err != nil {
 		return nil, err
 	}
-	return types.NewPrint(val), nil
+	return types.NewPrint(keyword, val), nil
 }
 
 func (p *Parser) exprStmt() (types.Stmt, error) {
@@ -132,6 +237,12 @@ func (p *Parser) exprStmt() (types.Stmt, error) {
 	if err != nil {
 		return nil, err
 	}
+	if p.check(token.COMMA) {
+		return p.destructure(val)
+	}
+	if p.match(token.COLON_EQUAL) {
+		return p.shortVarDecl(val)
+	}
 	// Eat end after expr if exists
 	p.match(token.END)
 	// _, err = p.consume(token.END, "Expect 'end' after value.")
@@ -141,6 +252,228 @@ func (p *Parser) exprStmt() (types.Stmt, error) {
 	return types.NewExpression(val), nil
 }
 
+// switch subject { case a, b: ... default: ... }
+func (p *Parser) switchStmt() (types.Stmt, error) {
+	keyword := p.previous()
+	var err error
+	var subject types.Expr = types.NewLiteralExpr(literal.NewLiteral(true), keyword) // switch { case x > 1: }
+	if !p.check(token.LEFT_BRACE) {
+		if subject, err = p.expression(); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := p.consume(token.LEFT_BRACE, "Expect '{' after switch value."); err != nil {
+		return nil, err
+	}
+
+	var cases []*types.SwitchCase
+	var def *types.SwitchCase
+	for !p.check(token.RIGHT_BRACE) && !p.isAtEnd() {
+		if p.match(token.END) {
+			continue
+		}
+
+		var patterns []*types.Pattern
+		if p.match(token.CASE) {
+			for {
+				pattern, err := p.pattern(true)
+				if err != nil {
+					return nil, err
+				}
+				patterns = append(patterns, pattern)
+				if !p.match(token.COMMA) {
+					break
+				}
+			}
+		} else if p.match(token.DEFAULT) {
+			if def != nil {
+				msg := "Switch can only have one default."
+				p.error(p.previous(), msg)
+				return nil, errors.New(msg)
+			}
+		} else {
+			msg := "Expect 'case' or 'default' in switch."
+			p.error(p.peek(), msg)
+			return nil, errors.New(msg)
+		}
+		isDefault := p.previous().Type == token.DEFAULT
+
+		if _, err := p.consume(token.COLON, "Expect ':' after case."); err != nil {
+			return nil, err
+		}
+		body, err := p.caseBody()
+		if err != nil {
+			return nil, err
+		}
+
+		c := &types.SwitchCase{Patterns: patterns, Body: body}
+		if isDefault {
+			def = c
+		} else {
+			cases = append(cases, c)
+		}
+	}
+
+	if _, err := p.consume(token.RIGHT_BRACE, "Expect '}' after switch."); err != nil {
+		return nil, err
+	}
+	p.match(token.END)
+	return types.NewSwitch(keyword, subject, cases, def), nil
+}
+
+// Statements up to the next case, default or the end of the switch, there is no fallthrough
+func (p *Parser) caseBody() ([]types.Stmt, error) {
+	var stmts []types.Stmt
+	for !p.check(token.CASE) && !p.check(token.DEFAULT) && !p.check(token.RIGHT_BRACE) && !p.isAtEnd() {
+		if p.match(token.END) {
+			continue
+		}
+		decl, err := p.declaration()
+		if err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, decl)
+	}
+	return stmts, nil
+}
+
+// A case value is compared with ==, unless it is a glist which is matched as a pattern
+// Inside a glist pattern a bare name binds the item, _ skips it and ...rest takes what is left
+func (p *Parser) pattern(top bool) (*types.Pattern, error) {
+	if p.match(token.LEFT_BRACKET) {
+		return p.listPattern()
+	}
+
+	if !top && p.check(token.IDENTIFIER) && (p.peekNext().Type == token.COMMA || p.peekNext().Type == token.RIGHT_BRACKET) {
+		name := p.advance()
+		if name.Lexeme == "_" {
+			return &types.Pattern{Kind: types.PatternWild}, nil
+		}
+		return &types.Pattern{Kind: types.PatternBind, Name: name}, nil
+	}
+
+	val, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	return &types.Pattern{Kind: types.PatternValue, Value: val}, nil
+}
+
+func (p *Parser) listPattern() (*types.Pattern, error) {
+	pattern := &types.Pattern{Kind: types.PatternList}
+	for !p.check(token.RIGHT_BRACKET) && !p.isAtEnd() {
+		if p.match(token.END) {
+			continue
+		}
+		if p.match(token.ELLIPSIS) {
+			rest, err := p.consume(token.IDENTIFIER, "Expect name after '...'.")
+			if err != nil {
+				return nil, err
+			}
+			pattern.Rest = &rest
+			p.match(token.END)
+			if !p.check(token.RIGHT_BRACKET) {
+				msg := "'...' must be the last item in a pattern."
+				p.error(p.peek(), msg)
+				return nil, errors.New(msg)
+			}
+			break
+		}
+
+		item, err := p.pattern(false)
+		if err != nil {
+			return nil, err
+		}
+		pattern.Items = append(pattern.Items, item)
+		p.match(token.END)
+		if !p.match(token.COMMA) {
+			break
+		}
+	}
+	p.match(token.END)
+	if _, err := p.consume(token.RIGHT_BRACKET, "Expect ']' after pattern."); err != nil {
+		return nil, err
+	}
+	return pattern, nil
+}
+
+// x := expr declares x in the current scope, same as var x = expr
+func (p *Parser) shortVarDecl(target types.Expr) (types.Stmt, error) {
+	op := p.previous()
+	v, ok := target.(*types.VarExpr)
+	if !ok {
+		msg := "Expect variable name on the left of ':='."
+		p.error(op, msg)
+		return nil, errors.New(msg)
+	}
+
+	initializer, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.endStmt("Expect 'end' after ':=' declaration."); err != nil {
+		return nil, err
+	}
+	return types.NewVar(v.Name, initializer, false), nil
+}
+
+// a, b = b, a or a, b := f(), first is the target already parsed by exprStmt
+func (p *Parser) destructure(first types.Expr) (types.Stmt, error) {
+	exprs := []types.Expr{first}
+	for p.match(token.COMMA) {
+		target, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, target)
+	}
+
+	if !p.match(token.EQUAL, token.COLON_EQUAL) {
+		msg := "Expect '=' or ':=' after assignment targets."
+		p.error(p.peek(), msg)
+		return nil, errors.New(msg)
+	}
+	op := p.previous()
+
+	targets := make([]types.Expr, len(exprs))
+	for i, expr := range exprs {
+		target := p.assignTarget(expr, op)
+		if _, ok := target.(*types.VarExpr); op.Type == token.COLON_EQUAL && !ok {
+			target = nil // := only declares plain names
+		}
+		if target == nil {
+			msg := "Invalid assignment target."
+			p.error(op, msg)
+			return nil, errors.New(msg)
+		}
+		targets[i] = target
+	}
+
+	vals, err := p.expressionList()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.endStmt("Expect 'end' after assignment."); err != nil {
+		return nil, err
+	}
+	return types.NewDestructure(targets, op, vals), nil
+}
+
+// expression ( "," expression )*
+func (p *Parser) expressionList() ([]types.Expr, error) {
+	var exprs []types.Expr
+	for {
+		expr, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+		if !p.match(token.COMMA) {
+			return exprs, nil
+		}
+	}
+}
+
 // import go ("fmt")
 // import hyp (time "./time.hyp")
 // Notice the alias
@@ -201,35 +534,42 @@ func (p *Parser) importStmt() (types.Stmt, error) {
 // Expression (x + y) We are expressing some type of action. Must evaluate to a value
 // Stmt, so an import is a type of statement. We are stating that this is happening
 
-// func (p *Parser) wertStmt() (types.Stmt, error) {
-// 	val, err := p.expression()
-// 	if err != nil {
-// 		return nil, err
-// 	}
-// 	_, err = p.consume(token.END, "Expect 'end' after wert statement.")
-// 	if err != nil {
-// 		return nil, err
-// 	}
-// 	return types.NewWert(val), nil
-// }
-
-// func (p *Parser) tryStmt() (types.Stmt, error) {
-// 	var woops types.Stmt
-// 	var wert token.Token
-
-// 	attempt, err := p.statement()
-// 	if err != nil {
-// 		return nil, err
-// 	}
-
-// 	// if p.match(token.WOOPS) {
-// 	// 	wert = p.advance()
-
-// 	// 	woops, err = p.statement()
-// 	// 	if err != nil {
-// 	// 		return nil, err
-// 	// 	}
-// 	// }
-
-// 	return types.NewTry(attempt, woops, wert), nil
-// }
+func (p *Parser) wertStmt() (types.Stmt, error) {
+	keyword := p.previous()
+	val, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.endStmt("Expect 'end' after wert statement."); err != nil {
+		return nil, err
+	}
+	return types.NewWert(keyword, val), nil
+}
+
+// try { ... } woops err { ... }
+func (p *Parser) tryStmt() (types.Stmt, error) {
+	if _, err := p.consume(token.LEFT_BRACE, "Expect '{' after try."); err != nil {
+		return nil, err
+	}
+	attempt, err := p.block()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := p.consume(token.WOOPS, "Expect 'woops' after try block."); err != nil {
+		return nil, err
+	}
+	var name token.Token
+	if p.match(token.IDENTIFIER) {
+		name = p.previous()
+	}
+	if _, err := p.consume(token.LEFT_BRACE, "Expect '{' after woops."); err != nil {
+		return nil, err
+	}
+	woops, err := p.block()
+	if err != nil {
+		return nil, err
+	}
+
+	return types.NewTry(types.NewBlock(attempt), name, types.NewBlock(woops)), nil
+}
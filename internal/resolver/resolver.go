@@ -1,46 +1,604 @@
 package resolver
 
 import (
-	"hype-script/internal/interpreter"
+	"hype-script/internal/native"
+	"hype-script/internal/token"
 	"hype-script/internal/types"
+	"path"
 )
 
+// Works out which declaration each name in a script points at without running it, for the lsp and friends
+// Scopes mirror the envs the interpreter makes, so a name resolves here to what it would find at runtime
+
+type SymbolKind int
+
+const (
+	Variable SymbolKind = iota
+	Param
+	Function
+	StructType
+	Package // A Go package brought in with import go
+	Builtin
+)
+
+type Symbol struct {
+	Name    string
+	Kind    SymbolKind
+	Decl    token.Token   // Name where it was declared, empty for builtins
+	Fun     *types.Fun    // Declaration of a function, nil for everything else
+	Struct  *types.Struct // Declaration of a struct type
+	Path    string        // Import path of a Go package
+	Body    *Scope        // Scope of a function's body
+	Uses    int           // Times it was read, assigning to it doesn't count
+	Shadows *Symbol       // What it hides in an enclosing scope, nil when it hides nothing but builtins
+}
+
+type Scope struct {
+	Parent   *Scope
+	Span     token.Span // Source the scope covers, zero for the global scope which covers everything
+	Fun      *Symbol    // Function the scope is the body of, nil for other scopes
+	Symbols  map[string]*Symbol
+	Order    []*Symbol // Symbols in the order they were declared
+	Children []*Scope
+}
+
+func newScope(parent *Scope, span token.Span) *Scope {
+	scope := &Scope{
+		Parent:  parent,
+		Span:    span,
+		Symbols: map[string]*Symbol{},
+	}
+	if parent != nil {
+		parent.Children = append(parent.Children, scope)
+	}
+	return scope
+}
+
+// Walks out from scope to the first symbol declared as name
+func (s *Scope) Lookup(name string) *Symbol {
+	for scope := s; scope != nil; scope = scope.Parent {
+		if sym, ok := scope.Symbols[name]; ok {
+			return sym
+		}
+	}
+	return nil
+}
+
+// Zero spans contain everything, offsets at the very end still count so a cursor after the last char is inside
+func (s *Scope) contains(offset int) bool {
+	return s.Span.IsZero() || (s.Span.Start.Offset <= offset && offset <= s.Span.End.Offset)
+}
+
+// A name read or assigned somewhere
+type Ref struct {
+	Name   token.Token
+	Symbol *Symbol // nil when nothing the resolver can see declares it
+	Assign bool    // x = v rather than a read
+}
+
+// A call of a plain name, f(x) but not a.f(x), with what the name resolves to
+type Call struct {
+	Expr   *types.CallExpr
+	Callee *Symbol
+}
+
+// The first member taken off a Go package, ToUpper in strings.ToUpper(s)
+type Member struct {
+	Package *Symbol
+	Name    token.Token
+}
+
 type Resolver struct {
-	Interprter interpreter.Interpreter
+	Global  *Scope
+	Symbols []*Symbol // Every declaration, builtins aside
+	Refs    []Ref
+	Members []Member
+	Calls   []Call
+	scope   *Scope
+	bodies  []body // Functions waiting to have their bodies resolved, see VisitFunStmt
 }
 
-func NewResolver(interpreter interpreter.Interpreter) *Resolver {
+type body struct {
+	fun   *Symbol
+	scope *Scope // Where the function was declared
+}
+
+func NewResolver() *Resolver {
+	global := newScope(nil, token.Span{})
+	for name := range native.Builtins() {
+		global.Symbols[name] = &Symbol{Name: name, Kind: Builtin}
+	}
 	return &Resolver{
-		Interprter: interpreter,
+		Global: global,
+		scope:  global,
+	}
+}
+
+// Resolves every name in stmts, statements that failed to parse are just missing
+func (r *Resolver) Resolve(stmts []types.Stmt) {
+	r.resolve(stmts)
+	// Bodies only run once the script has declared everything, so they can use functions declared after them
+	for len(r.bodies) > 0 {
+		b := r.bodies[0]
+		r.bodies = r.bodies[1:]
+		r.resolveBody(b)
+	}
+}
+
+// The symbol whose name sits at offset, whether that's where it was declared or where it was used
+func (r *Resolver) At(offset int) (*Symbol, token.Token) {
+	for _, sym := range r.Symbols {
+		if within(sym.Decl, offset) {
+			return sym, sym.Decl
+		}
+	}
+	for _, ref := range r.Refs {
+		if within(ref.Name, offset) {
+			return ref.Symbol, ref.Name
+		}
+	}
+	return nil, token.Token{}
+}
+
+// The package member whose name sits at offset
+func (r *Resolver) MemberAt(offset int) (Member, bool) {
+	for _, m := range r.Members {
+		if within(m.Name, offset) {
+			return m, true
+		}
+	}
+	return Member{}, false
+}
+
+// Every symbol that can be used at offset, innermost first, shadowed ones left out
+func (r *Resolver) Visible(offset int) []*Symbol {
+	scope := r.Global
+	for {
+		inner := scope
+		for _, child := range scope.Children {
+			if !child.Span.IsZero() && child.contains(offset) {
+				inner = child
+				break
+			}
+		}
+		if inner == scope {
+			break
+		}
+		scope = inner
+	}
+
+	var visible []*Symbol
+	seen := map[string]bool{}
+	for ; scope != nil; scope = scope.Parent {
+		// Locals only exist once they've been declared, globals can be used from anywhere
+		for _, sym := range scope.Order {
+			if !seen[sym.Name] && (scope == r.Global || sym.Decl.Offset < offset) {
+				seen[sym.Name] = true
+				visible = append(visible, sym)
+			}
+		}
+		if scope == r.Global {
+			for _, sym := range scope.Symbols {
+				if sym.Kind == Builtin && !seen[sym.Name] {
+					seen[sym.Name] = true
+					visible = append(visible, sym)
+				}
+			}
+		}
 	}
+	return visible
+}
+
+func within(tok token.Token, offset int) bool {
+	return tok.Lexeme != "" && tok.Offset <= offset && offset <= tok.End.Offset
+}
+
+func (r *Resolver) declare(name token.Token, kind SymbolKind) *Symbol {
+	sym := &Symbol{Name: name.Lexeme, Kind: kind, Decl: name}
+	if r.scope.Parent != nil {
+		if outer := r.scope.Parent.Lookup(name.Lexeme); outer != nil && outer.Kind != Builtin {
+			sym.Shadows = outer
+		}
+	}
+	// Declaring again replaces the old one from here on, uses before still point at the old one
+	r.scope.Symbols[name.Lexeme] = sym
+	r.scope.Order = append(r.scope.Order, sym)
+	r.Symbols = append(r.Symbols, sym)
+	return sym
+}
+
+func (r *Resolver) use(name token.Token) *Symbol {
+	sym := r.scope.Lookup(name.Lexeme)
+	if sym != nil {
+		sym.Uses++
+	}
+	r.Refs = append(r.Refs, Ref{Name: name, Symbol: sym})
+	return sym
+}
+
+func (r *Resolver) assign(name token.Token) {
+	r.Refs = append(r.Refs, Ref{Name: name, Symbol: r.scope.Lookup(name.Lexeme), Assign: true})
+}
+
+func (r *Resolver) beginScope(span token.Span) {
+	r.scope = newScope(r.scope, span)
+}
+
+func (r *Resolver) endScope() {
+	r.scope = r.scope.Parent
+}
+
+func (r *Resolver) resolve(stmts []types.Stmt) {
+	for _, stmt := range stmts {
+		r.resolveStmt(stmt)
+	}
+}
+
+func (r *Resolver) resolveStmt(stmt types.Stmt) {
+	if stmt != nil {
+		stmt.Accept(r)
+	}
+}
+
+func (r *Resolver) resolveExpr(exprs ...types.Expr) {
+	for _, expr := range exprs {
+		if expr != nil {
+			expr.Accept(r)
+		}
+	}
+}
+
+// Functions close over the scope they're declared in, the same as a call's env
+// Bodies are resolved last, so they see everything that scope declares even after them, like a call made later would
+func (r *Resolver) resolveBody(b body) {
+	fun := b.fun.Fun
+	outer := r.scope
+	r.scope = newScope(b.scope, fun.Span())
+	r.scope.Fun = b.fun
+	b.fun.Body = r.scope
+	for idx, param := range fun.Params {
+		// Defaults run in the call before the param is bound, they can see the params before them
+		if idx < len(fun.Defaults) {
+			r.resolveExpr(fun.Defaults[idx])
+		}
+		r.declare(param, Param)
+	}
+	if fun.Rest.Lexeme != "" {
+		r.declare(fun.Rest, Param)
+	}
+	r.resolve(fun.Body)
+	r.endScope()
+	r.scope = outer
+}
+
+func (r *Resolver) VisitExprStmt(stmt *types.Expression) error {
+	r.resolveExpr(stmt.Expr)
+	return nil
+}
+
+func (r *Resolver) VisitPrintStmt(stmt *types.Print) error {
+	r.resolveExpr(stmt.Expr)
+	return nil
+}
+
+func (r *Resolver) VisitVarStmt(stmt *types.Var) error {
+	// var x = x reads the outer x before the new one exists
+	r.resolveExpr(stmt.Initializer)
+	r.declare(stmt.Name, Variable)
+	return nil
 }
 
 func (r *Resolver) VisitBlockStmt(stmt *types.Block) error {
-	// r.beginScope()
+	r.beginScope(stmt.Span())
 	r.resolve(stmt.Statements)
-	// r.endScope()
+	r.endScope()
 	return nil
 }
 
-func (r *Resolver) resolve(stmts []types.Stmt) error {
-	for _, stmt := range stmts {
-		err := r.resolveStmt(stmt)
-		if err != nil {
-			return err
+func (r *Resolver) VisitIfStmt(stmt *types.If) error {
+	r.resolveExpr(stmt.Condition)
+	r.resolveStmt(stmt.Then)
+	r.resolveStmt(stmt.Final)
+	return nil
+}
+
+func (r *Resolver) VisitWhileStmt(stmt *types.While) error {
+	r.resolveExpr(stmt.Condition)
+	r.resolveStmt(stmt.Body)
+	r.resolveExpr(stmt.Increment)
+	return nil
+}
+
+// The name is declared where the function is, its body waits until everything around it is declared
+func (r *Resolver) VisitFunStmt(stmt *types.Fun) error {
+	sym := r.declare(stmt.Name, Function)
+	sym.Fun = stmt
+	r.bodies = append(r.bodies, body{fun: sym, scope: r.scope})
+	return nil
+}
+
+func (r *Resolver) VisitReturnStmt(stmt *types.Return) error {
+	r.resolveExpr(stmt.Val)
+	return nil
+}
+
+func (r *Resolver) VisitImportStmt(stmt *types.Import) error {
+	if stmt.Lang.Lexeme != "go" {
+		return nil
+	}
+	for _, item := range stmt.Imports {
+		pkgPath := item.Val.Literal.String()
+		name := item.Val
+		name.Lexeme = path.Base(pkgPath)
+		if item.Alias.Type == token.IDENTIFIER {
+			name = item.Alias
+		}
+		sym := r.declare(name, Package)
+		sym.Path = pkgPath
+	}
+	return nil
+}
+
+func (r *Resolver) VisitAccessStmt(stmt *types.Access) error {
+	r.resolveExpr(stmt.Expr)
+	return nil
+}
+
+func (r *Resolver) VisitForInStmt(stmt *types.ForIn) error {
+	r.resolveExpr(stmt.Iterable)
+	r.beginScope(stmt.Span())
+	r.declare(stmt.Name, Variable)
+	r.resolveStmt(stmt.Body)
+	r.endScope()
+	return nil
+}
+
+func (r *Resolver) VisitBreakStmt(stmt *types.Break) error {
+	return nil
+}
+
+func (r *Resolver) VisitContinueStmt(stmt *types.Continue) error {
+	return nil
+}
+
+func (r *Resolver) VisitTryStmt(stmt *types.Try) error {
+	r.resolveStmt(stmt.Attempt)
+	if stmt.Woops == nil {
+		return nil
+	}
+	r.beginScope(stmt.Woops.Span())
+	if stmt.Name.Lexeme != "" {
+		r.declare(stmt.Name, Variable)
+	}
+	r.resolveStmt(stmt.Woops)
+	r.endScope()
+	return nil
+}
+
+func (r *Resolver) VisitWertStmt(stmt *types.Wert) error {
+	r.resolveExpr(stmt.Val)
+	return nil
+}
+
+func (r *Resolver) VisitDestructureStmt(stmt *types.Destructure) error {
+	r.resolveExpr(stmt.Vals...)
+	for _, target := range stmt.Targets {
+		if v, ok := target.(*types.VarExpr); ok && stmt.Op.Type == token.COLON_EQUAL {
+			r.declare(v.Name, Variable)
+			continue
+		} else if ok {
+			r.assign(v.Name)
+			continue
 		}
+		r.resolveExpr(target)
 	}
 	return nil
 }
 
-func (r *Resolver) resolveStmt(stmt types.Stmt) error {
-	// return stmt.Accept(r)
+func (r *Resolver) VisitSwitchStmt(stmt *types.Switch) error {
+	r.resolveExpr(stmt.Subject)
+	cases := stmt.Cases
+	if stmt.Default != nil {
+		cases = append(cases[:len(cases):len(cases)], stmt.Default)
+	}
+	for _, c := range cases {
+		span := stmtSpan(c.Body)
+		for _, p := range c.Patterns {
+			span = token.Join(span, p.Span())
+		}
+		r.beginScope(span)
+		for _, p := range c.Patterns {
+			r.resolvePattern(p)
+		}
+		r.resolve(c.Body)
+		r.endScope()
+	}
 	return nil
 }
 
-func (r *Resolver) resolveExpr(expr types.Expr) error {
-	// _, err := expr.Accept(r)
-	// if err != nil {
-	// 	return err
-	// }
+func (r *Resolver) resolvePattern(p *types.Pattern) {
+	switch p.Kind {
+	case types.PatternValue:
+		r.resolveExpr(p.Value)
+	case types.PatternBind:
+		r.declare(p.Name, Variable)
+	case types.PatternList:
+		for _, item := range p.Items {
+			r.resolvePattern(item)
+		}
+		if p.Rest != nil {
+			r.declare(*p.Rest, Variable)
+		}
+	}
+}
+
+func stmtSpan(stmts []types.Stmt) token.Span {
+	var span token.Span
+	for _, stmt := range stmts {
+		span = token.Join(span, stmt.Span())
+	}
+	return span
+}
+
+func (r *Resolver) VisitStructStmt(stmt *types.Struct) error {
+	sym := r.declare(stmt.Name, StructType)
+	sym.Struct = stmt
 	return nil
-}
\ No newline at end of file
+}
+
+func (r *Resolver) Print(expr types.Expr) (string, error) {
+	return "", nil
+}
+
+func (r *Resolver) VisitBinaryExpr(expr *types.BinaryExpr) (any, error) {
+	r.resolveExpr(expr.Left, expr.Right)
+	return nil, nil
+}
+
+func (r *Resolver) VisitLiteralExpr(expr *types.LiteralExpr) (any, error) {
+	return nil, nil
+}
+
+func (r *Resolver) VisitUnaryExpr(expr *types.UnaryExpr) (any, error) {
+	r.resolveExpr(expr.Right)
+	return nil, nil
+}
+
+func (r *Resolver) VisitGroupingExpr(expr *types.GroupingExpr) (any, error) {
+	r.resolveExpr(expr.Expr)
+	return nil, nil
+}
+
+func (r *Resolver) VisitVarExpr(expr *types.VarExpr) (any, error) {
+	r.use(expr.Name)
+	return nil, nil
+}
+
+func (r *Resolver) VisitAssignExpr(expr *types.AssignExpr) (any, error) {
+	r.resolveExpr(expr.Val)
+	r.assign(expr.Name)
+	return nil, nil
+}
+
+func (r *Resolver) VisitLogicalExpr(expr *types.LogicalExpr) (any, error) {
+	r.resolveExpr(expr.Left, expr.Right)
+	return nil, nil
+}
+
+func (r *Resolver) VisitWhileExpr(expr *types.WhileExpr) (any, error) {
+	r.resolveExpr(expr.Condition)
+	r.resolveStmt(expr.Body)
+	return nil, nil
+}
+
+func (r *Resolver) VisitCallExpr(expr *types.CallExpr) (any, error) {
+	r.resolveExpr(expr.Callee)
+	if callee, ok := expr.Callee.(*types.VarExpr); ok {
+		r.Calls = append(r.Calls, Call{Expr: expr, Callee: r.scope.Lookup(callee.Name.Lexeme)})
+	}
+	r.resolveArgs(expr)
+	return nil, nil
+}
+
+func (r *Resolver) resolveArgs(expr *types.CallExpr) {
+	r.resolveExpr(expr.Args...)
+	for _, named := range expr.Named {
+		r.resolveExpr(named.Val)
+	}
+}
+
+func (r *Resolver) VisitFunExpr(expr *types.FunExpr) (any, error) {
+	r.use(expr.Name)
+	return nil, nil
+}
+
+func (r *Resolver) VisitReturnExpr(expr *types.ReturnExpr) (any, error) {
+	r.resolveExpr(expr.Val)
+	return nil, nil
+}
+
+func (r *Resolver) VisitPostfixExpr(expr *types.PostfixExpr) (any, error) {
+	r.resolveExpr(expr.Val)
+	return nil, nil
+}
+
+func (r *Resolver) VisitGlistExpr(expr *types.GlistExpr) (any, error) {
+	r.resolveExpr(expr.Data...)
+	return nil, nil
+}
+
+func (r *Resolver) VisitIndexExpr(expr *types.IndexExpr) (any, error) {
+	r.resolveExpr(expr.Expr, expr.Index)
+	return nil, nil
+}
+
+func (r *Resolver) VisitImportExpr(expr *types.ImportExpr) (any, error) {
+	return nil, nil
+}
+
+// Only the root of a.b.c is a name in scope, the rest are members looked up on it
+func (r *Resolver) VisitAccessExpr(expr *types.AccessExpr) (any, error) {
+	r.resolveExpr(expr.Exprs[0])
+	var pkg *Symbol
+	if root, ok := expr.Exprs[0].(*types.VarExpr); ok {
+		if sym := r.scope.Lookup(root.Name.Lexeme); sym != nil && sym.Kind == Package {
+			pkg = sym
+		}
+	}
+	for idx, m := range expr.Exprs[1:] {
+		name := r.resolveMember(m)
+		if idx == 0 && pkg != nil && name.Lexeme != "" {
+			r.Members = append(r.Members, Member{Package: pkg, Name: name})
+		}
+	}
+	return nil, nil
+}
+
+// Resolves what wraps a member, the index in b[0] or the args in b(x), and hands back the member's name
+func (r *Resolver) resolveMember(m types.Expr) token.Token {
+	switch member := m.(type) {
+	case *types.VarExpr:
+		return member.Name
+	case *types.IndexExpr:
+		name := r.resolveMember(member.Expr)
+		r.resolveExpr(member.Index)
+		return name
+	case *types.CallExpr:
+		name := r.resolveMember(member.Callee)
+		r.resolveArgs(member)
+		return name
+	}
+	r.resolveExpr(m)
+	return token.Token{}
+}
+
+func (r *Resolver) VisitGmapExpr(expr *types.GmapExpr) (any, error) {
+	r.resolveExpr(expr.Keys...)
+	r.resolveExpr(expr.Vals...)
+	return nil, nil
+}
+
+func (r *Resolver) VisitIndexAssignExpr(expr *types.IndexAssignExpr) (any, error) {
+	r.resolveExpr(expr.Expr, expr.Index, expr.Val)
+	return nil, nil
+}
+
+func (r *Resolver) VisitSliceExpr(expr *types.SliceExpr) (any, error) {
+	r.resolveExpr(expr.Expr, expr.Start, expr.End)
+	return nil, nil
+}
+
+func (r *Resolver) VisitInterpolationExpr(expr *types.InterpolationExpr) (any, error) {
+	r.resolveExpr(expr.Parts...)
+	return nil, nil
+}
+
+func (r *Resolver) VisitTupleExpr(expr *types.TupleExpr) (any, error) {
+	r.resolveExpr(expr.Items...)
+	return nil, nil
+}
+
+func (r *Resolver) VisitSpreadExpr(expr *types.SpreadExpr) (any, error) {
+	r.resolveExpr(expr.Expr)
+	return nil, nil
+}
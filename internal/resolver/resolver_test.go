@@ -0,0 +1,131 @@
+package resolver
+
+import (
+	"hype-script/internal/environment"
+	"hype-script/internal/parser"
+	"hype-script/internal/scanner"
+	"strings"
+	"testing"
+)
+
+func resolve(t *testing.T, src string) *Resolver {
+	tokens, err := scanner.NewScanner().ScanTokens(src)
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	stmts, err := parser.NewParser(environment.NewEnvironment(nil)).ParseTokens(tokens)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	r := NewResolver()
+	r.Resolve(stmts)
+	return r
+}
+
+// The line (from 1) a use of name at the nth time it appears in src resolves to, 0 if it's undeclared
+func declLine(t *testing.T, r *Resolver, src, name string, nth int) int {
+	offset := -1
+	for i := 0; i <= nth; i++ {
+		next := strings.Index(src[offset+1:], name)
+		if next < 0 {
+			t.Fatalf("%s only appears %d times", name, i)
+		}
+		offset += next + 1
+	}
+	sym, _ := r.At(offset)
+	if sym == nil {
+		return 0
+	}
+	return sym.Decl.Line
+}
+
+func TestScopes(t *testing.T) {
+	src := `var host = "vpn"
+{
+    var host = "inner"
+    print host
+}
+print host
+func dial() {
+    print host
+    print later()
+    print port
+}
+func later() {
+    return 1
+}
+for port in [1194] {
+    print port
+}
+switch [1, 2] {
+case [first, ...others]:
+    print first + len(others)
+}
+`
+	r := resolve(t, src)
+	cases := []struct {
+		name string
+		nth  int
+		line int
+	}{
+		{"host", 2, 3},   // The block's own host
+		{"host", 3, 1},   // Back outside the block
+		{"host", 4, 1},   // Functions see globals
+		{"later", 0, 12}, // Even ones declared after them
+		{"port", 0, 0},   // But not the loop var of a loop that isn't around them
+		{"port", 2, 15},
+		{"first", 1, 19},
+		{"others", 1, 19},
+	}
+	for _, c := range cases {
+		if got := declLine(t, r, src, c.name, c.nth); got != c.line {
+			t.Errorf("%s #%d: expected it declared on line %d, got %d", c.name, c.nth, c.line, got)
+		}
+	}
+
+	// Inside dial only globals and builtins are around
+	visible := map[string]bool{}
+	for _, sym := range r.Visible(strings.Index(src, "print later")) {
+		visible[sym.Name] = true
+	}
+	for _, name := range []string{"host", "dial", "later", "len"} {
+		if !visible[name] {
+			t.Errorf("expected %s to be visible in dial", name)
+		}
+	}
+	if visible["port"] || visible["first"] {
+		t.Errorf("expected no locals from elsewhere in dial, got %v", visible)
+	}
+}
+
+// A nested function closes over the locals of the one it's declared in, itself included
+func TestClosures(t *testing.T) {
+	src := `func outer() {
+    var x = 5
+    func fact(n) {
+        return n * fact(n - 1) + x
+    }
+    return fact(4)
+}
+`
+	r := resolve(t, src)
+	cases := []struct {
+		name string
+		nth  int
+		line int
+	}{
+		{"x", 1, 2},
+		{"fact", 1, 3},
+		{"fact", 2, 3},
+	}
+	for _, c := range cases {
+		if got := declLine(t, r, src, c.name, c.nth); got != c.line {
+			t.Errorf("%s #%d: expected it declared on line %d, got %d", c.name, c.nth, c.line, got)
+		}
+	}
+	for _, sym := range r.Symbols {
+		if sym.Name == "x" && sym.Uses != 1 {
+			t.Errorf("expected x read once from fact, got %d", sym.Uses)
+		}
+	}
+}
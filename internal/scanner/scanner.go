@@ -2,12 +2,15 @@ package scanner
 
 import (
 	"fmt"
-	herror "hype-script/internal/error"
+	"hype-script/internal/diag"
 	"hype-script/internal/literal"
 	"hype-script/internal/token"
 	"hype-script/internal/types/core"
 	"os"
+	"sort"
 	"strconv"
+	"strings"
+	"unicode/utf8"
 )
 
 // Whats wrong with putting all tokens in a hashtable?
@@ -19,6 +22,10 @@ type Scanner struct {
 	Start         int // Points to first lexeme being scanner
 	Current       int // Character currently being considered
 	Line          int // The source line that Current is on
+	File          string
+	Diagnostics   diag.Diagnostics // Lexical errors from the last scan
+	Comments      []token.Token    // Comments from the last scan, the parser never sees them
+	LineStarts    []int            // Offset each line starts at, for turning offsets into line and col
 	Keywords      map[string]token.TokenType
 	LeftOperators map[rune]token.TokenType
 }
@@ -39,29 +46,50 @@ func NewScanner() core.ScannerHandler {
 	}
 }
 
+func (s *Scanner) SetFile(name string) {
+	s.File = name
+}
+
 func (s *Scanner) ScanTokens(source string) ([]token.Token, error) {
 	s.Source = source
+	s.Tokens = []token.Token{}
+	s.Start, s.Current, s.Line = 0, 0, 1
+	s.LineStarts = []int{0}
+	s.Diagnostics.Reset()
+	s.Comments = nil
+	for i := 0; i < len(source); i++ {
+		if source[i] == '\n' {
+			s.LineStarts = append(s.LineStarts, i+1)
+		}
+	}
+
 	// Each iteration we scan a single token
 	for !s.isAtEnd() {
 		s.Start = s.Current
+		reported, scanned := s.Diagnostics.Len(), len(s.Tokens)
 		s.scanToken()
+		// A lexeme that went wrong becomes one ERROR token, whatever it managed to add is dropped
+		if s.Diagnostics.Len() > reported {
+			s.Tokens = s.Tokens[:scanned]
+			s.addSimpleToken(token.ERROR)
+		}
 	}
 
 	// We as the scanner performed our job, just return 0 toks
 	if len(s.Tokens) == 0 {
-		return s.Tokens, nil
+		return s.Tokens, s.Diagnostics.Err()
 	}
 
+	s.Start = s.Current
 	if s.Tokens[len(s.Tokens)-1].Type != token.END {
-		endToken := token.NewToken(token.END, "", nil, s.Line)
-		s.Tokens = append(s.Tokens, *endToken)
+		s.addSimpleToken(token.END)
 	}
 
 	// Appends an EOF token at the end
-	newToken := token.NewToken(token.EOF, "", nil, s.Line)
-	s.Tokens = append(s.Tokens, *newToken)
+	s.addSimpleToken(token.EOF)
 
-	return s.Tokens, nil
+	// Every lexical error comes back at once, the tokens are still whole for tools that want to keep going
+	return s.Tokens, s.Diagnostics.Err()
 }
 
 // If current character being checked is >= len of source
@@ -78,7 +106,7 @@ func (s *Scanner) scanToken() {
 		s.addSimpleToken(token.LEFT_PAREN)
 		s.eatBad()
 	case ')':
-		if s.prevToken().Type == token.LEFT_PAREN {
+		if len(s.Tokens) == 0 || s.prevToken().Type == token.LEFT_PAREN {
 			s.addSimpleToken(token.RIGHT_PAREN)
 			break
 		}
@@ -99,7 +127,12 @@ func (s *Scanner) scanToken() {
 	case ',':
 		s.addSimpleToken(token.COMMA)
 	case '.':
-		s.addSimpleToken(token.DOT)
+		if s.peek() == '.' && s.peekNext() == '.' {
+			s.Current += 2
+			s.addSimpleToken(token.ELLIPSIS)
+		} else {
+			s.addSimpleToken(token.DOT)
+		}
 	case '-':
 		if s.match('=') {
 			s.addSimpleToken(token.MINUS_EQUAL)
@@ -119,6 +152,8 @@ func (s *Scanner) scanToken() {
 	case '*':
 		if s.match('=') {
 			s.addSimpleToken(token.STAR_EQUAL)
+		} else if s.match('*') {
+			s.addSimpleToken(token.STAR_STAR)
 		} else {
 			s.addSimpleToken(token.STAR)
 		}
@@ -131,17 +166,27 @@ func (s *Scanner) scanToken() {
 	case '>':
 		if s.match('=') {
 			s.addSimpleToken(token.GREATER_EQUAL)
+		} else if s.match('>') {
+			s.addSimpleToken(token.GREATER_GREATER)
 		} else {
 			s.addSimpleToken(token.GREATER)
 		}
 	case '<':
 		if s.match('=') {
 			s.addSimpleToken(token.LESS_EQUAL)
+		} else if s.match('<') {
+			s.addSimpleToken(token.LESS_LESS)
 		} else {
 			s.addSimpleToken(token.LESS)
 		}
 	case '^':
 		s.addSimpleToken(token.KARAT)
+	case '%':
+		s.addSimpleToken(token.PERCENT)
+	case '&':
+		s.addSimpleToken(token.AMPERSAND)
+	case '|':
+		s.addSimpleToken(token.PIPE)
 	case '!': // Are we looking at a lexeme of ! OR !=
 		if s.match('=') {
 			s.addSimpleToken(token.BANG_EQUAL)
@@ -151,6 +196,8 @@ func (s *Scanner) scanToken() {
 	case '~':
 		if s.match('=') {
 			s.addSimpleToken(token.TILDE_EQUAL)
+		} else if s.match('/') {
+			s.addSimpleToken(token.TILDE_SLASH)
 		} else {
 			s.addSimpleToken(token.TILDE)
 		}
@@ -162,13 +209,11 @@ func (s *Scanner) scanToken() {
 		}
 	case '/': // Are we doing division or commenting?
 		if s.match('/') { // If next char is /, is comment, read till the end of the line
+			// Leave the newline, the '\n' case below decides if it ends a statement
 			for s.peek() != '\n' && !s.isAtEnd() {
-				// for !s.match('\n') && !s.isAtEnd() {
 				s.advance()
 			}
-			if s.match('\n') {
-				s.Line++
-			}
+			s.Comments = append(s.Comments, s.makeToken(token.COMMENT, nil))
 		} else if s.match('=') {
 			s.addSimpleToken(token.SLASH_EQUAL)
 		} else {
@@ -182,8 +227,7 @@ func (s *Scanner) scanToken() {
 		for s.match('\n') {
 			s.Line += 1
 		}
-		if s.prevToken().Type == token.END {
-			s.advance()
+		if len(s.Tokens) == 0 || s.prevToken().Type == token.END {
 			break
 		}
 		s.addSimpleToken(token.END)
@@ -202,7 +246,13 @@ func (s *Scanner) scanToken() {
 		} else if s.isAlpha(c) {
 			s.identifier()
 		} else {
-			herror.ScannerError(s.Line, "Unexpected character")
+			// Take the whole rune so é is one error and not two
+			if c >= utf8.RuneSelf {
+				r, size := utf8.DecodeRuneInString(s.Source[s.Start:])
+				s.Current = s.Start + size
+				c = r
+			}
+			s.error(diag.UnexpectedChar, fmt.Sprintf("Unexpected character '%c'", c))
 		}
 	}
 }
@@ -256,7 +306,9 @@ func (s *Scanner) nextIsBad() bool {
 // Advance if next token is \n, \r, \t or ' '
 func (s *Scanner) eatBad() {
 	for s.nextIsBad() {
-		s.advance()
+		if s.advance() == '\n' {
+			s.Line += 1
+		}
 	}
 }
 
@@ -282,31 +334,136 @@ func (s *Scanner) peekNext() rune {
 }
 
 func (s *Scanner) string() {
+	// """ opens a raw string
+	if s.peek() == '"' && s.peekNext() == '"' {
+		s.advance()
+		s.advance()
+		s.rawString()
+		return
+	}
+
+	var parts []token.StringPart
+	var builder strings.Builder
 	for s.peek() != '"' && !s.isAtEnd() { // Keep searching for string closing
-		if s.peek() == '\n' {
+		c := s.advance()
+		switch {
+		case c == '\n':
 			s.Line += 1
+			builder.WriteByte(byte(c))
+		case c == '\\':
+			s.escape(&builder)
+		case c == '$' && s.peek() == '{':
+			s.advance()
+			parts = append(parts, token.StringPart{Text: builder.String()})
+			builder.Reset()
+			pos := s.pos(s.Current)
+			src, ok := s.interpolation()
+			if !ok {
+				return
+			}
+			parts = append(parts, token.StringPart{Text: src, Expr: true, Pos: pos})
+		default:
+			builder.WriteByte(byte(c))
 		}
-		s.advance()
 	}
 
-	//s.advance()
-
 	if s.isAtEnd() { // If it makes it to the end of line before finding closing "
-		herror.ScannerError(s.Line, "Unterminated string")
+		s.error(diag.UnterminatedString, "Unterminated string")
 		return
 	}
 
-	s.advance()
+	s.advance() // The closing "
 
-	// s.Current is one less than the closing ", make Current into "
-	// s.advance()
+	// Plain strings stay a single literal, "${name} has ${n}" hands the parser its pieces
+	if parts == nil {
+		s.addToken(token.STRING, literal.NewLiteral(builder.String()))
+		return
+	}
+	parts = append(parts, token.StringPart{Text: builder.String()})
+	s.addToken(token.INTERPOLATION, literal.NewLiteral(parts))
+}
 
-	// Cut the begining and closing "'s off
-	val := s.Source[s.Start+1 : s.Current-1]
-	lit := literal.NewLiteral(val)
-	s.addToken(token.STRING, lit)
+// Char after a \ in a string, unknown escapes are reported and kept as is
+func (s *Scanner) escape(builder *strings.Builder) {
+	start := s.Current - 1 // The \
+	c := s.advance()
+	switch c {
+	case 'n':
+		builder.WriteByte('\n')
+	case 't':
+		builder.WriteByte('\t')
+	case 'r':
+		builder.WriteByte('\r')
+	case '0':
+		builder.WriteByte(0)
+	case '\\', '"', '$':
+		builder.WriteByte(byte(c))
+	case 'u': // \u00e9
+		if s.Current+4 <= len(s.Source) {
+			if code, err := strconv.ParseUint(s.Source[s.Current:s.Current+4], 16, 32); err == nil {
+				s.Current += 4
+				builder.WriteRune(rune(code))
+				return
+			}
+		}
+		s.errorAt(start, diag.BadEscape, "Expect 4 hex digits after \\u")
+		builder.WriteString("\\u")
+	default:
+		s.errorAt(start, diag.BadEscape, fmt.Sprintf("Unknown escape sequence '\\%c'", c))
+		builder.WriteByte('\\')
+		builder.WriteByte(byte(c))
+	}
+}
 
-	// s.attemptEarlyEnd()
+// Source of the expression in ${...}, the ${ is already consumed
+// Braces and strings inside the expression are skipped over so ${m["}"]} still finds its end
+func (s *Scanner) interpolation() (string, bool) {
+	start := s.Current
+	depth := 0
+	for !s.isAtEnd() {
+		c := s.advance()
+		switch c {
+		case '\n':
+			s.Line += 1
+		case '{':
+			depth += 1
+		case '}':
+			if depth == 0 {
+				return s.Source[start : s.Current-1], true
+			}
+			depth -= 1
+		case '"':
+			for s.peek() != '"' && !s.isAtEnd() {
+				if s.advance() == '\\' {
+					s.advance()
+				}
+			}
+			s.advance()
+		}
+	}
+	s.error(diag.UnterminatedString, "Unterminated '${' in string")
+	return "", false
+}
+
+// """...""" keeps every char as written, no escapes or interpolation, for help text and templates
+// A newline straight after the opening quotes is dropped so the text can start on its own line
+func (s *Scanner) rawString() {
+	if s.match('\n') {
+		s.Line += 1
+	}
+	start := s.Current
+	for !s.isAtEnd() {
+		if s.peek() == '"' && s.peekNext() == '"' && s.Current+2 < len(s.Source) && s.Source[s.Current+2] == '"' {
+			val := s.Source[start:s.Current]
+			s.Current += 3
+			s.addToken(token.STRING, literal.NewLiteral(val))
+			return
+		}
+		if s.advance() == '\n' {
+			s.Line += 1
+		}
+	}
+	s.error(diag.UnterminatedString, "Unterminated raw string")
 }
 
 // Consumes next character of source line and returns it
@@ -325,11 +482,51 @@ func (s *Scanner) addSimpleToken(tokType token.TokenType) {
 }
 
 func (s *Scanner) addToken(tokType token.TokenType, literal *literal.Literal) {
+	s.Tokens = append(s.Tokens, s.makeToken(tokType, literal))
+}
+
+func (s *Scanner) makeToken(tokType token.TokenType, literal *literal.Literal) token.Token {
 	text := s.Source[s.Start:s.Current]
 	escapedText := strconv.QuoteToASCII(text)
 	escapedText = escapedText[1 : len(escapedText)-1] // Remove the surrounding quotes added by QuoteToASCII
-	newToken := token.NewToken(tokType, escapedText, literal, s.Line)
-	s.Tokens = append(s.Tokens, *newToken)
+	start := s.pos(s.Start)
+	newToken := token.NewToken(tokType, escapedText, literal, start.Line)
+	newToken.File = s.File
+	newToken.Offset = start.Offset
+	newToken.Col = start.Col
+	newToken.End = s.pos(s.Current)
+	return *newToken
+}
+
+// Line and col of a byte offset in the source
+func (s *Scanner) pos(offset int) token.Pos {
+	line := sort.Search(len(s.LineStarts), func(i int) bool { return s.LineStarts[i] > offset })
+	if line == 0 { // Lines haven't been worked out, ScanTokens wasn't used
+		return token.Pos{Offset: offset, Line: 1, Col: offset + 1}
+	}
+	return token.Pos{Offset: offset, Line: line, Col: offset - s.LineStarts[line-1] + 1}
+}
+
+func (s *Scanner) GetComments() []token.Token {
+	return s.Comments
+}
+
+func (s *Scanner) GetDiagnostics() *diag.Diagnostics {
+	return &s.Diagnostics
+}
+
+func (s *Scanner) error(code, message string) {
+	s.Diagnostics.Errorf(code, s.span(), "%s", message)
+}
+
+// Same as error but only points from start, for a bad bit in the middle of a longer lexeme
+func (s *Scanner) errorAt(start int, code, message string) {
+	s.Diagnostics.Errorf(code, token.Span{File: s.File, Start: s.pos(start), End: s.pos(s.Current)}, "%s", message)
+}
+
+// The lexeme being scanned so far, for errors
+func (s *Scanner) span() token.Span {
+	return token.Span{File: s.File, Start: s.pos(s.Start), End: s.pos(s.Current)}
 }
 
 func (s *Scanner) prevToken() token.Token {
@@ -361,28 +558,93 @@ func (s *Scanner) isDigit(c rune) bool {
 	return c >= '0' && c <= '9'
 }
 
+// Ints are whole numbers with no '.' or exponent, they keep every digit as int64
+// 0x, 0o and 0b prefixes pick the base, and _ can split up digits, 1_000_000
 func (s *Scanner) number() {
-	// While the characters being explored are part d
-	for s.isDigit(s.peek()) {
-		s.advance() // What if we try to advance but are at the end?
-	}
+	isFloat := false
+	radix := s.Start+1 < len(s.Source) && s.Source[s.Start] == '0' && strings.ContainsRune("xXoObB", rune(s.Source[s.Start+1]))
+	if radix {
+		s.advance() // The base letter
+		for s.isHexDigit(s.peek()) || s.peek() == '_' {
+			s.advance()
+		}
+	} else {
+		// While the characters being explored are part d
+		for s.isDigit(s.peek()) || s.peek() == '_' {
+			s.advance() // What if we try to advance but are at the end?
+		}
 
-	// If number ends in ., dont parse .
-	if s.peek() == '.' && s.isDigit(s.peekNext()) {
-		// Consume the '.'
-		s.advance()
+		// If number ends in ., dont parse .
+		if s.peek() == '.' && s.isDigit(s.peekNext()) {
+			isFloat = true
+			// Consume the '.'
+			s.advance()
 
-		// Then parse the rest, after the dot
-		for s.isDigit(s.peek()) {
+			// Then parse the rest, after the dot
+			for s.isDigit(s.peek()) || s.peek() == '_' {
+				s.advance()
+			}
+		}
+
+		// 1e9, 2.5E-3
+		if s.peek() == 'e' || s.peek() == 'E' {
+			next := s.peekNext()
+			if s.isDigit(next) || ((next == '+' || next == '-') && s.Current+2 < len(s.Source) && s.isDigit(rune(s.Source[s.Current+2]))) {
+				isFloat = true
+				s.advance()
+				s.match('+')
+				s.match('-')
+				for s.isDigit(s.peek()) {
+					s.advance()
+				}
+			}
+		}
+	}
+
+	// 123abc is one bad number, not a number then a name
+	if s.isAlpha(s.peek()) {
+		for s.isAlphaNumeric(s.peek()) {
 			s.advance()
 		}
+		s.error(diag.MalformedNumber, fmt.Sprintf("Malformed number '%s'", s.Source[s.Start:s.Current]))
+		return
 	}
 
-	f64, _ := strconv.ParseFloat(s.Source[s.Start:s.Current], 32)
-	f64Literal := literal.NewLiteral(f64)
-	s.addToken(token.NUMBER, f64Literal)
+	text := s.Source[s.Start:s.Current]
+	if strings.HasSuffix(text, "_") || strings.Contains(text, "__") || strings.Contains(text, "_.") || strings.Contains(text, "._") {
+		s.error(diag.MalformedNumber, fmt.Sprintf("Malformed number '%s', '_' must sit between digits", text))
+		return
+	}
+
+	if isFloat {
+		f64, err := strconv.ParseFloat(strings.ReplaceAll(text, "_", ""), 64)
+		if err != nil {
+			s.error(diag.MalformedNumber, fmt.Sprintf("Malformed number '%s'", text))
+		}
+		s.addToken(token.NUMBER, literal.NewLiteral(f64))
+		return
+	}
+
+	var i64 int64
+	var err error
+	if radix {
+		i64, err = strconv.ParseInt(text, 0, 64)
+	} else {
+		// Base 10 even with a leading 0, 010 is ten not eight
+		i64, err = strconv.ParseInt(strings.ReplaceAll(text, "_", ""), 10, 64)
+	}
+	if err != nil {
+		if numErr, ok := err.(*strconv.NumError); ok && numErr.Err == strconv.ErrRange {
+			s.error(diag.MalformedNumber, fmt.Sprintf("Integer '%s' does not fit in 64 bits", text))
+		} else {
+			s.error(diag.MalformedNumber, fmt.Sprintf("Malformed number '%s'", text))
+		}
+	}
+	s.addToken(token.NUMBER, literal.NewLiteral(i64))
+}
 
-	// s.attemptEarlyEnd()
+func (s *Scanner) isHexDigit(c rune) bool {
+	return s.isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
 }
 
 func (s *Scanner) identifier() {
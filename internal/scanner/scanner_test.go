@@ -2,6 +2,7 @@ package scanner
 
 import (
 	"testing"
+	"hype-script/internal/diag"
 	"hype-script/internal/token"
 )
 
@@ -67,3 +68,164 @@ func TestFutureChar(t *testing.T) {
 		t.Errorf("Expected future of '}', got %v", scanner.futureChar())
 	}
 }
+
+func TestStringEscapes(t *testing.T) {
+	tokens, _ := NewScanner().ScanTokens(`"a\tb\n\"c\" \$ é"`)
+	want := "a\tb\n\"c\" $ é"
+	if tokens[0].Type != token.STRING || tokens[0].Literal.Val != want {
+		t.Errorf("Expected string %q, got %s %v", want, token.TokenTypeNames[tokens[0].Type], tokens[0].Literal)
+	}
+}
+
+func TestRawString(t *testing.T) {
+	tokens, _ := NewScanner().ScanTokens("\"\"\"\nraw \\n ${x}\nlines\"\"\"")
+	want := "raw \\n ${x}\nlines"
+	if tokens[0].Type != token.STRING || tokens[0].Literal.Val != want {
+		t.Errorf("Expected raw string %q, got %v", want, tokens[0].Literal)
+	}
+}
+
+func TestInterpolation(t *testing.T) {
+	tokens, _ := NewScanner().ScanTokens(`"${name} has ${m["}"]} items"`)
+	if tokens[0].Type != token.INTERPOLATION {
+		t.Fatalf("Expected INTERPOLATION token, got %s", token.TokenTypeNames[tokens[0].Type])
+	}
+	parts := tokens[0].Literal.Val.([]token.StringPart)
+	want := []token.StringPart{
+		{Text: ""},
+		{Text: "name", Expr: true, Pos: token.Pos{Offset: 3, Line: 1, Col: 4}},
+		{Text: " has "},
+		{Text: `m["}"]`, Expr: true, Pos: token.Pos{Offset: 15, Line: 1, Col: 16}},
+		{Text: " items"},
+	}
+	if len(parts) != len(want) {
+		t.Fatalf("Expected %d parts, got %d: %v", len(want), len(parts), parts)
+	}
+	for i := range want {
+		if parts[i] != want[i] {
+			t.Errorf("Part %d: expected %v, got %v", i, want[i], parts[i])
+		}
+	}
+}
+
+func TestNumberLiterals(t *testing.T) {
+	cases := map[string]any{
+		"8080":                int64(8080),
+		"0xff":                int64(255),
+		"0o17":                int64(15),
+		"0b1010":              int64(10),
+		"1_000_000":           int64(1000000),
+		"9007199254740993":    int64(9007199254740993),
+		"20.4":                20.4,
+		"1e3":                 1000.0,
+		"1_000.5":             1000.5,
+		"9223372036854775807": int64(9223372036854775807),
+	}
+	for src, want := range cases {
+		tokens, _ := NewScanner().ScanTokens(src)
+		if tokens[0].Type != token.NUMBER || tokens[0].Literal.Val != want {
+			t.Errorf("%s: expected %v (%T), got %v (%T)", src, want, want, tokens[0].Literal.Val, tokens[0].Literal.Val)
+		}
+	}
+}
+
+func TestTokenPositions(t *testing.T) {
+	s := NewScanner()
+	s.SetFile("vpn.hyp")
+	tokens, _ := s.ScanTokens("var port = 1194\nprint \"up\n${port}\"\n")
+	want := []struct {
+		lexeme string
+		start  token.Pos
+		end    token.Pos
+	}{
+		{"var", token.Pos{Offset: 0, Line: 1, Col: 1}, token.Pos{Offset: 3, Line: 1, Col: 4}},
+		{"port", token.Pos{Offset: 4, Line: 1, Col: 5}, token.Pos{Offset: 8, Line: 1, Col: 9}},
+		{"=", token.Pos{Offset: 9, Line: 1, Col: 10}, token.Pos{Offset: 10, Line: 1, Col: 11}},
+		{"1194", token.Pos{Offset: 11, Line: 1, Col: 12}, token.Pos{Offset: 15, Line: 1, Col: 16}},
+		{`\n`, token.Pos{Offset: 15, Line: 1, Col: 16}, token.Pos{Offset: 16, Line: 2, Col: 1}},
+		{"print", token.Pos{Offset: 16, Line: 2, Col: 1}, token.Pos{Offset: 21, Line: 2, Col: 6}},
+		// A string over two lines starts on the first and ends on the second
+		{`\"up\n${port}\"`, token.Pos{Offset: 22, Line: 2, Col: 7}, token.Pos{Offset: 34, Line: 3, Col: 9}},
+	}
+	for i, w := range want {
+		tok := tokens[i]
+		if tok.Lexeme != w.lexeme || tok.Start() != w.start || tok.End != w.end || tok.File != "vpn.hyp" {
+			t.Errorf("token %d: expected %s %v-%v in vpn.hyp, got %s %v-%v in %q", i, w.lexeme, w.start, w.end, tok.Lexeme, tok.Start(), tok.End, tok.File)
+		}
+	}
+
+	parts := tokens[6].Literal.Val.([]token.StringPart)
+	if parts[1].Pos != (token.Pos{Offset: 28, Line: 3, Col: 3}) {
+		t.Errorf("expected ${port} to start at 3:3, got %v", parts[1].Pos)
+	}
+
+	// Scanning again starts over rather than adding to the last tokens
+	tokens, _ = s.ScanTokens("x")
+	if len(tokens) != 3 || tokens[0].Start() != (token.Pos{Offset: 0, Line: 1, Col: 1}) {
+		t.Errorf("expected a fresh scan, got %v", tokens)
+	}
+}
+
+func TestLexicalErrors(t *testing.T) {
+	s := NewScanner()
+	tokens, err := s.ScanTokens("var a = 12ab @ \"x\\q\" 3\nprint é \"oops")
+	diags, ok := err.(*diag.Diagnostics)
+	if !ok {
+		t.Fatalf("expected *diag.Diagnostics, got %T %v", err, err)
+	}
+
+	want := []struct {
+		code       string
+		start, end token.Pos
+	}{
+		{diag.MalformedNumber, token.Pos{Offset: 8, Line: 1, Col: 9}, token.Pos{Offset: 12, Line: 1, Col: 13}},
+		{diag.UnexpectedChar, token.Pos{Offset: 13, Line: 1, Col: 14}, token.Pos{Offset: 14, Line: 1, Col: 15}},
+		// Points at the escape, not the whole string
+		{diag.BadEscape, token.Pos{Offset: 17, Line: 1, Col: 18}, token.Pos{Offset: 19, Line: 1, Col: 20}},
+		// é is two bytes but one error
+		{diag.UnexpectedChar, token.Pos{Offset: 29, Line: 2, Col: 7}, token.Pos{Offset: 31, Line: 2, Col: 9}},
+		{diag.UnterminatedString, token.Pos{Offset: 32, Line: 2, Col: 10}, token.Pos{Offset: 37, Line: 2, Col: 15}},
+	}
+	if diags.Len() != len(want) {
+		t.Fatalf("expected %d errors, got %d:\n%v", len(want), diags.Len(), diags)
+	}
+	for i, w := range want {
+		d := diags.List[i]
+		if d.Code != w.code || d.Span.Start != w.start || d.Span.End != w.end {
+			t.Errorf("error %d: expected %s at %v-%v, got %s at %v-%v: %s", i, w.code, w.start, w.end, d.Code, d.Span.Start, d.Span.End, d.Message)
+		}
+	}
+
+	// Each bad lexeme is one ERROR token and scanning carries on past it
+	types := []token.TokenType{token.VAR, token.IDENTIFIER, token.EQUAL, token.ERROR, token.ERROR, token.ERROR, token.NUMBER, token.END, token.PRINT, token.ERROR, token.ERROR}
+	for i, tt := range types {
+		if tokens[i].Type != tt {
+			t.Errorf("token %d: expected %s, got %s %q", i, token.TokenTypeNames[tt], token.TokenTypeNames[tokens[i].Type], tokens[i].Lexeme)
+		}
+	}
+	if tokens[len(tokens)-1].Type != token.EOF {
+		t.Errorf("expected the tokens to still end in EOF")
+	}
+
+	// A clean scan afterwards has nothing left over
+	if _, err := s.ScanTokens("print 1"); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestComments(t *testing.T) {
+	s := NewScanner()
+	tokens, _ := s.ScanTokens("// top\nvar x = 1 // why\nx / 2\n")
+	for _, tok := range tokens {
+		if tok.Type == token.COMMENT {
+			t.Errorf("expected comments kept out of the tokens, got %v", tok)
+		}
+	}
+	comments := s.GetComments()
+	if len(comments) != 2 {
+		t.Fatalf("expected 2 comments, got %v", comments)
+	}
+	if comments[0].Lexeme != "// top" || comments[1].Lexeme != "// why" || comments[1].Start() != (token.Pos{Offset: 17, Line: 2, Col: 11}) {
+		t.Errorf("expected // top and // why at 2:11, got %v", comments)
+	}
+}
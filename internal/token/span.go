@@ -0,0 +1,81 @@
+package token
+
+import "fmt"
+
+// A point in the source, Line and Col count from 1
+type Pos struct {
+	Offset int
+	Line   int
+	Col    int
+}
+
+// A stretch of source from Start up to but not including End
+type Span struct {
+	File  string
+	Start Pos
+	End   Pos
+}
+
+func (t Token) Start() Pos {
+	return Pos{Offset: t.Offset, Line: t.Line, Col: t.Col}
+}
+
+func (t Token) Span() Span {
+	return Span{File: t.File, Start: t.Start(), End: t.End}
+}
+
+// Moves a token scanned on its own, like the source of a ${} in a string, to where it sits in the full source
+func (t *Token) Shift(base Pos, file string) {
+	start := t.Start().shift(base)
+	t.Offset, t.Line, t.Col = start.Offset, start.Line, start.Col
+	t.End = t.End.shift(base)
+	t.File = file
+}
+
+func (s Span) Shift(base Pos, file string) Span {
+	return Span{File: file, Start: s.Start.shift(base), End: s.End.shift(base)}
+}
+
+func (p Pos) shift(base Pos) Pos {
+	if p.Line == 1 {
+		p.Col += base.Col - 1
+	}
+	p.Line += base.Line - 1
+	p.Offset += base.Offset
+	return p
+}
+
+// Tokens made up by the parser or in tests have no position
+func (s Span) IsZero() bool {
+	return s.Start.Line == 0
+}
+
+// The smallest span covering every non zero span given
+func Join(spans ...Span) Span {
+	var joined Span
+	for _, s := range spans {
+		if s.IsZero() {
+			continue
+		}
+		if joined.IsZero() {
+			joined = s
+			continue
+		}
+		if s.Start.Offset < joined.Start.Offset {
+			joined.Start = s.Start
+		}
+		if s.End.Offset > joined.End.Offset {
+			joined.End = s.End
+		}
+	}
+	return joined
+}
+
+// file:line:col, scripts with no file are <stdin>
+func (s Span) String() string {
+	file := s.File
+	if file == "" {
+		file = "<stdin>"
+	}
+	return fmt.Sprintf("%s:%d:%d", file, s.Start.Line, s.Start.Col)
+}
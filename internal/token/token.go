@@ -22,6 +22,9 @@ const (
 	SEMICOLON
 	SLASH
 	STAR
+	PERCENT   // %
+	AMPERSAND // &
+	PIPE      // |
 	END
 	SPACE
 	TILDE // ~
@@ -45,11 +48,17 @@ const (
 	SLASH_EQUAL
 	TILDE_EQUAL // ~=
 	COLON_EQUAL // :=
+	STAR_STAR       // **
+	TILDE_SLASH     // ~/
+	LESS_LESS       // <<
+	GREATER_GREATER // >>
+	ELLIPSIS        // ...
 
 	// Literals.
 	IDENTIFIER
 	STRING
 	NUMBER
+	INTERPOLATION // "${x}", Literal holds []StringPart
 
 	// Keywords.
 	AND
@@ -69,6 +78,22 @@ const (
 	AS
 	PAR // Parallel
 	HYP // Hype
+	BREAK
+	CONTINUE
+	IN
+	TRY
+	WOOPS
+	WERT
+	SWITCH
+	CASE
+	DEFAULT
+	STRUCT
+
+	// A lexeme the scanner couldn't make sense of, its diagnostic says why
+	ERROR
+
+	// A // comment, kept beside the tokens as trivia rather than in them
+	COMMENT
 
 	// End of file
 	EOF
@@ -86,6 +111,7 @@ var TokenTypeNames = map[TokenType]string{
 	SEMICOLON:     "SEMICOLON",
 	SLASH:         "SLASH",
 	STAR:          "STAR",
+	PERCENT:       "PERCENT",
 	END:           "END",
 	SPACE:         "SPACE",
 	TILDE:         "TILDE",
@@ -102,6 +128,7 @@ var TokenTypeNames = map[TokenType]string{
 	IDENTIFIER:    "IDENTIFIER",
 	STRING:        "STRING",
 	NUMBER:        "NUMBER",
+	INTERPOLATION: "INTERPOLATION",
 	AND:           "AND",
 	ELSE:          "ELSE",
 	TRUE:          "TRUE",
@@ -116,6 +143,20 @@ var TokenTypeNames = map[TokenType]string{
 	VAR:           "VAR",
 	PAR:           "PAR",
 	HYP:           "HYP",
+	BREAK:         "BREAK",
+	CONTINUE:      "CONTINUE",
+	IN:            "IN",
+	TRY:           "TRY",
+	WOOPS:         "WOOPS",
+	WERT:          "WERT",
+	SWITCH:        "SWITCH",
+	CASE:          "CASE",
+	DEFAULT:       "DEFAULT",
+	STRUCT:        "STRUCT",
+	ERROR:         "ERROR",
+	COMMENT:       "COMMENT",
+	EOF:           "EOF",
+	ELLIPSIS:      "ELLIPSIS",
 	PLUS_EQUAL:    "PLUS_EQUAL",
 	PLUS_PLUS:     "PLUS_PLUS",
 	MINUS_MINUS:   "MINUS_MINUS",
@@ -127,6 +168,12 @@ var TokenTypeNames = map[TokenType]string{
 	IMPORT:        "IMPORT",
 	COLON:         "COLON",
 	COLON_EQUAL:   "COLON_EQUAL",
+	AMPERSAND:     "AMPERSAND",
+	PIPE:          "PIPE",
+	STAR_STAR:     "STAR_STAR",
+	TILDE_SLASH:   "TILDE_SLASH",
+	LESS_LESS:     "LESS_LESS",
+	GREATER_GREATER: "GREATER_GREATER",
 }
 
 var BadTokens = map[rune]bool{
@@ -135,11 +182,22 @@ var BadTokens = map[rune]bool{
 	'\r': true,
 }
 
+// Piece of an interpolated string, Expr pieces hold the source between ${ and }
+type StringPart struct {
+	Text string
+	Expr bool
+	Pos  Pos // Where the expression starts in the source
+}
+
 type Token struct {
 	Type    TokenType			// Const type from token.go
 	Lexeme  string				// String of token as it occurs in the src
 	Literal *literal.Literal	// Container for value if it has one, can be nil
-	Line    int					// Line in src file
+	Line    int					// Line in src file the token starts on
+	File    string				// Script the token came from, empty for the REPL and tests
+	Offset  int					// Byte offset of the first char in src
+	Col     int					// Column of the first char, counted in bytes from 1
+	End     Pos					// Just past the last char
 }
 
 func NewToken(tokType TokenType, lexeme string, literal *literal.Literal, line int) *Token {
@@ -179,6 +237,16 @@ func BuildKeywords() (keywords map[string]TokenType) {
 	keywords["var"] = VAR
 	keywords["par"] = PAR
 	keywords["hyp"] = HYP
+	keywords["break"] = BREAK
+	keywords["continue"] = CONTINUE
+	keywords["in"] = IN
+	keywords["try"] = TRY
+	keywords["woops"] = WOOPS
+	keywords["wert"] = WERT
+	keywords["switch"] = SWITCH
+	keywords["case"] = CASE
+	keywords["default"] = DEFAULT
+	keywords["struct"] = STRUCT
 	return
 }
 
@@ -188,6 +256,9 @@ func BuildLeftOper() (leftOperators map[rune]TokenType) {
 	leftOperators['-'] = MINUS
 	leftOperators['*'] = STAR
 	leftOperators['/'] = SLASH
+	leftOperators['%'] = PERCENT
+	leftOperators['&'] = AMPERSAND
+	leftOperators['|'] = PIPE
 	leftOperators['='] = EQUAL
 	leftOperators['~'] = TILDE
 	leftOperators['^'] = KARAT
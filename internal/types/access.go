@@ -1,5 +1,7 @@
 package types
 
+import "hype-script/internal/token"
+
 type AccessExpr struct {
 	Type  string
 	Exprs []Expr
@@ -23,3 +25,7 @@ func (v *AccessExpr) GetType() string {
 func (v *AccessExpr) GetVal() string {
 	return "Not impl"
 }
+
+func (a *AccessExpr) Span() token.Span {
+	return exprSpan(a.Exprs...)
+}
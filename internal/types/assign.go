@@ -31,3 +31,7 @@ func (v *AssignExpr) GetType() string {
 func (v *AssignExpr) GetVal() string {
 	return fmt.Sprintf("%s, %s", v.Name.String(), v.Val.GetVal())
 }
+
+func (a *AssignExpr) Span() token.Span {
+	return token.Join(a.Name.Span(), exprSpan(a.Val))
+}
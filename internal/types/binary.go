@@ -32,3 +32,7 @@ func (v *BinaryExpr) GetType() string {
 func (v *BinaryExpr) GetVal() string {
 	return fmt.Sprintf("%s, %s, %s", v.Left.GetVal(), v.Operator.String(), v.Right.GetVal())
 }
+
+func (b *BinaryExpr) Span() token.Span {
+	return token.Join(exprSpan(b.Left, b.Right), b.Operator.Span())
+}
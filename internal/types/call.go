@@ -9,14 +9,22 @@ type CallExpr struct {
 	Callee Expr
 	Paren  token.Token // Token for closing parens
 	Args   []Expr
+	Named  []*NamedArg // name=value args, always after the positional ones
 }
 
-func NewCallExpr(callee Expr, paren token.Token, args []Expr) Expr {
+// port=8080 in a call
+type NamedArg struct {
+	Name token.Token
+	Val  Expr
+}
+
+func NewCallExpr(callee Expr, paren token.Token, args []Expr, named []*NamedArg) Expr {
 	return &CallExpr{
 		Type:   "CallExpr",
 		Callee: callee,
 		Paren:  paren,
 		Args:   args,
+		Named:  named,
 	}
 }
 
@@ -31,3 +39,38 @@ func (v *CallExpr) GetType() string {
 func (v *CallExpr) GetVal() string {
 	return v.Callee.GetVal()
 }
+
+// ...xs in a call, the glist is spread out into positional args
+type SpreadExpr struct {
+	Type  string
+	Token token.Token
+	Expr  Expr
+}
+
+func NewSpreadExpr(tok token.Token, expr Expr) Expr {
+	return &SpreadExpr{
+		Type:  "SpreadExpr",
+		Token: tok,
+		Expr:  expr,
+	}
+}
+
+func (s *SpreadExpr) Accept(visitor Visitor) (any, error) {
+	return visitor.VisitSpreadExpr(s)
+}
+
+func (s *SpreadExpr) GetType() string {
+	return s.Type
+}
+
+func (s *SpreadExpr) GetVal() string {
+	return "..." + s.Expr.GetVal()
+}
+
+func (c *CallExpr) Span() token.Span {
+	return token.Join(exprSpan(c.Callee), c.Paren.Span())
+}
+
+func (s *SpreadExpr) Span() token.Span {
+	return token.Join(s.Token.Span(), exprSpan(s.Expr))
+}
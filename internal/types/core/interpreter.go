@@ -1,10 +1,16 @@
 package core
 
-import "hype-script/internal/types"
+import (
+	"hype-script/internal/diag"
+	"hype-script/internal/types"
+)
 
 type InterpreterHandler interface {
 	InterpretStmts(stmts []types.Stmt) error
+	Evaluate(expr types.Expr) (any, error) // An expression's value, errors reported like InterpretStmts
 	GetHadRuntimeError() bool
 	ExecuteBlock(stmts []types.Stmt, environment types.EnvironmentHandler) error
 	GetGlobals() types.EnvironmentHandler
+	SetFile(name string) // Script name traces point at
+	GetDiagnostics() *diag.Diagnostics
 }
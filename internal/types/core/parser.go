@@ -1,6 +1,7 @@
 package core
 
 import (
+	"hype-script/internal/diag"
 	"hype-script/internal/token"
 	"hype-script/internal/types"
 )
@@ -8,4 +9,5 @@ import (
 type ParserHandler interface {
 	ParseTokens(tokens []token.Token) ([]types.Stmt, error)
 	GetHadError() bool 
+	GetDiagnostics() *diag.Diagnostics
 }
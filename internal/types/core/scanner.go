@@ -1,7 +1,13 @@
 package core
 
-import "hype-script/internal/token"
+import (
+	"hype-script/internal/diag"
+	"hype-script/internal/token"
+)
 
 type ScannerHandler interface {
 	ScanTokens(source string) ([]token.Token, error)
+	SetFile(name string) // Script name tokens are tagged with
+	GetDiagnostics() *diag.Diagnostics
+	GetComments() []token.Token // Comment trivia from the last scan, for the formatter
 }
@@ -32,3 +32,7 @@ func (v *FunExpr) GetType() string {
 func (v *FunExpr) GetVal() string {
 	return fmt.Sprintf("%s", v.Name.String()) // Wasnt going to deal with parsing list here either
 }
+
+func (f *FunExpr) Span() token.Span {
+	return token.Join(f.Name.Span(), stmtSpan(f.Body...))
+}
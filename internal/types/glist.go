@@ -34,3 +34,7 @@ func (v *GlistExpr) GetToken() token.Token {
 func (v *GlistExpr) GetVal() string {
 	return fmt.Sprintf("%s", v.Token.String()) // Wasnt going to deal with parsing list
 }
+
+func (g *GlistExpr) Span() token.Span {
+	return token.Join(g.Token.Span(), exprSpan(g.Data...))
+}
@@ -0,0 +1,43 @@
+package types
+
+import (
+	"fmt"
+	"hype-script/internal/token"
+)
+
+// {"a": 1, b: 2}, Keys and Vals line up by position
+type GmapExpr struct {
+	Type  string
+	Token token.Token
+	Keys  []Expr
+	Vals  []Expr
+}
+
+func NewGmapExpr(keys []Expr, vals []Expr, token token.Token) Expr {
+	return &GmapExpr{
+		Type:  "GmapExpr",
+		Token: token,
+		Keys:  keys,
+		Vals:  vals,
+	}
+}
+
+func (v *GmapExpr) Accept(visitor Visitor) (any, error) {
+	return visitor.VisitGmapExpr(v)
+}
+
+func (v *GmapExpr) GetType() string {
+	return v.Type
+}
+
+func (v *GmapExpr) GetToken() token.Token {
+	return v.Token
+}
+
+func (v *GmapExpr) GetVal() string {
+	return fmt.Sprintf("%s, %d entries", v.Token.String(), len(v.Keys))
+}
+
+func (g *GmapExpr) Span() token.Span {
+	return token.Join(g.Token.Span(), exprSpan(g.Keys...), exprSpan(g.Vals...))
+}
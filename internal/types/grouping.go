@@ -1,16 +1,23 @@
 package types
 
-import "fmt"
+import (
+	"fmt"
+	"hype-script/internal/token"
+)
 
 type GroupingExpr struct {
-	Type string
-	Expr Expr
+	Type  string
+	Expr  Expr
+	Open  token.Token
+	Close token.Token
 }
 
-func NewGroupingExpr(expr Expr) Expr {
+func NewGroupingExpr(expr Expr, open, close token.Token) Expr {
 	return &GroupingExpr{
-		Type: "GroupingExpr",
-		Expr: expr,
+		Type:  "GroupingExpr",
+		Expr:  expr,
+		Open:  open,
+		Close: close,
 	}
 }
 
@@ -25,3 +32,7 @@ func (v *GroupingExpr) GetType() string {
 func (v *GroupingExpr) GetVal() string {
 	return fmt.Sprintf("%s", v.Expr.GetVal())
 }
+
+func (g *GroupingExpr) Span() token.Span {
+	return token.Join(g.Open.Span(), exprSpan(g.Expr), g.Close.Span())
+}
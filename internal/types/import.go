@@ -30,3 +30,7 @@ func (v *ImportExpr) GetType() string {
 func (v *ImportExpr) GetVal() string {
 	return fmt.Sprintf("%s, %s", v.Keyword.String(), v.Val.GetVal())
 }
+
+func (i *ImportExpr) Span() token.Span {
+	return token.Join(i.Keyword.Span(), exprSpan(i.Val))
+}
@@ -18,5 +18,5 @@ func NewImportItem(alias, val token.Token) *ImportItem {
 }
 
 func (i *ImportItem) String() string {
-	return fmt.Sprintf("ImportItem -> Alias: %s, Val: %s", &i.Alias.Lexeme, &i.Val.Lexeme)
+	return fmt.Sprintf("ImportItem -> Alias: %s, Val: %s", i.Alias.Lexeme, i.Val.Lexeme)
 }
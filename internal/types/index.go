@@ -1,18 +1,23 @@
 package types
 
-import "fmt"
+import (
+	"fmt"
+	"hype-script/internal/token"
+)
 
 type IndexExpr struct {
-	Type  string
-	Expr  Expr
-	Index Expr
+	Type    string
+	Expr    Expr
+	Index   Expr
+	Bracket token.Token // Opening '[', for errors
 }
 
-func NewIndexExpr(expr Expr, index Expr) Expr {
+func NewIndexExpr(expr Expr, index Expr, bracket token.Token) Expr {
 	return &IndexExpr{
-		Type:  "IndexExpr",
-		Expr:  expr,
-		Index: index,
+		Type:    "IndexExpr",
+		Expr:    expr,
+		Index:   index,
+		Bracket: bracket,
 	}
 }
 
@@ -27,3 +32,7 @@ func (v *IndexExpr) GetType() string {
 func (v *IndexExpr) GetVal() string {
 	return fmt.Sprintf("%s, %s", v.Expr.GetVal(), v.Index.GetVal())
 }
+
+func (i *IndexExpr) Span() token.Span {
+	return token.Join(exprSpan(i.Expr, i.Index), i.Bracket.Span())
+}
@@ -0,0 +1,41 @@
+package types
+
+import (
+	"fmt"
+	"hype-script/internal/token"
+)
+
+// m["k"] = v, also m.k = v which the parser rewrites to m["k"] = v
+type IndexAssignExpr struct {
+	Type   string
+	Expr   Expr
+	Index  Expr
+	Val    Expr
+	Equals token.Token
+}
+
+func NewIndexAssignExpr(expr Expr, index Expr, val Expr, equals token.Token) Expr {
+	return &IndexAssignExpr{
+		Type:   "IndexAssignExpr",
+		Expr:   expr,
+		Index:  index,
+		Val:    val,
+		Equals: equals,
+	}
+}
+
+func (v *IndexAssignExpr) Accept(visitor Visitor) (any, error) {
+	return visitor.VisitIndexAssignExpr(v)
+}
+
+func (v *IndexAssignExpr) GetType() string {
+	return v.Type
+}
+
+func (v *IndexAssignExpr) GetVal() string {
+	return fmt.Sprintf("%s, %s, %s", v.Expr.GetVal(), v.Index.GetVal(), v.Val.GetVal())
+}
+
+func (i *IndexAssignExpr) Span() token.Span {
+	return token.Join(exprSpan(i.Expr, i.Index, i.Val), i.Equals.Span())
+}
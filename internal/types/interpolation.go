@@ -0,0 +1,37 @@
+package types
+
+import (
+	"fmt"
+	"hype-script/internal/token"
+)
+
+// "${name} has ${len(xs)} items", Parts alternate between string literals and the expressions between them
+type InterpolationExpr struct {
+	Type  string
+	Token token.Token
+	Parts []Expr
+}
+
+func NewInterpolationExpr(parts []Expr, token token.Token) Expr {
+	return &InterpolationExpr{
+		Type:  "InterpolationExpr",
+		Token: token,
+		Parts: parts,
+	}
+}
+
+func (v *InterpolationExpr) Accept(visitor Visitor) (any, error) {
+	return visitor.VisitInterpolationExpr(v)
+}
+
+func (v *InterpolationExpr) GetType() string {
+	return v.Type
+}
+
+func (v *InterpolationExpr) GetVal() string {
+	return fmt.Sprintf("%s, %d parts", v.Token.String(), len(v.Parts))
+}
+
+func (i *InterpolationExpr) Span() token.Span {
+	return i.Token.Span()
+}
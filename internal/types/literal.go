@@ -1,19 +1,22 @@
 package types
 
 import (
+	"hype-script/internal/token"
 	"fmt"
 	"hype-script/internal/literal"
 )
 
 type LiteralExpr struct {
-	Type string
-	Val  *literal.Literal
+	Type  string
+	Val   *literal.Literal
+	Token token.Token // Where the literal was written, empty for ones the parser fills in
 }
 
-func NewLiteralExpr(val *literal.Literal) Expr {
+func NewLiteralExpr(val *literal.Literal, tok token.Token) Expr {
 	return &LiteralExpr{
-		Type: "LiteralExpr",
-		Val:  val,
+		Type:  "LiteralExpr",
+		Val:   val,
+		Token: tok,
 	}
 }
 
@@ -32,3 +35,7 @@ func (v *LiteralExpr) GetType() string {
 func (v *LiteralExpr) GetVal() string {
 	return fmt.Sprintf("%s", v.Val.String())
 }
+
+func (l *LiteralExpr) Span() token.Span {
+	return l.Token.Span()
+}
@@ -32,3 +32,7 @@ func (v *LogicalExpr) GetType() string {
 func (v *LogicalExpr) GetVal() string {
 	return fmt.Sprintf("%s, %s, %s", v.Left.GetVal(), v.Operator.String(), v.Right.GetVal())
 }
+
+func (l *LogicalExpr) Span() token.Span {
+	return token.Join(exprSpan(l.Left, l.Right), l.Operator.Span())
+}
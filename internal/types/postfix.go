@@ -31,3 +31,7 @@ func (v *PostfixExpr) GetType() string {
 func (v *PostfixExpr) GetVal() string {
 	return fmt.Sprintf("%s, %s", v.Val.GetVal(), v.Operator.String())
 }
+
+func (p *PostfixExpr) Span() token.Span {
+	return token.Join(exprSpan(p.Val), p.Operator.Span())
+}
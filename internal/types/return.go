@@ -30,3 +30,7 @@ func (v *ReturnExpr) GetType() string {
 func (v *ReturnExpr) GetVal() string {
 	return fmt.Sprintf("%s, %s", v.Keyword.String(), v.Val.GetVal())
 }
+
+func (r *ReturnExpr) Span() token.Span {
+	return token.Join(r.Keyword.Span(), exprSpan(r.Val))
+}
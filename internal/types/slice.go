@@ -0,0 +1,41 @@
+package types
+
+import (
+	"fmt"
+	"hype-script/internal/token"
+)
+
+// xs[start:end], either bound can be nil
+type SliceExpr struct {
+	Type    string
+	Expr    Expr
+	Start   Expr
+	End     Expr
+	Bracket token.Token
+}
+
+func NewSliceExpr(expr Expr, start Expr, end Expr, bracket token.Token) Expr {
+	return &SliceExpr{
+		Type:    "SliceExpr",
+		Expr:    expr,
+		Start:   start,
+		End:     end,
+		Bracket: bracket,
+	}
+}
+
+func (v *SliceExpr) Accept(visitor Visitor) (any, error) {
+	return visitor.VisitSliceExpr(v)
+}
+
+func (v *SliceExpr) GetType() string {
+	return v.Type
+}
+
+func (v *SliceExpr) GetVal() string {
+	return fmt.Sprintf("%s, %s", v.Expr.GetVal(), v.Bracket.String())
+}
+
+func (s *SliceExpr) Span() token.Span {
+	return token.Join(exprSpan(s.Expr, s.Start, s.End), s.Bracket.Span())
+}
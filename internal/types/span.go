@@ -0,0 +1,32 @@
+package types
+
+import "hype-script/internal/token"
+
+// Span covering every expression given, nil ones are skipped
+func exprSpan(exprs ...Expr) token.Span {
+	var span token.Span
+	for _, expr := range exprs {
+		if expr != nil {
+			span = token.Join(span, expr.Span())
+		}
+	}
+	return span
+}
+
+func stmtSpan(stmts ...Stmt) token.Span {
+	var span token.Span
+	for _, stmt := range stmts {
+		if stmt != nil {
+			span = token.Join(span, stmt.Span())
+		}
+	}
+	return span
+}
+
+func tokenSpan(toks ...token.Token) token.Span {
+	var span token.Span
+	for _, tok := range toks {
+		span = token.Join(span, tok.Span())
+	}
+	return span
+}
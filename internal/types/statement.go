@@ -10,7 +10,8 @@ type Expression struct {
 }
 
 type Print struct {
-	Expr Expr
+	Keyword token.Token
+	Expr    Expr
 }
 
 type Var struct {
@@ -32,10 +33,56 @@ type If struct {
 type While struct {
 	Condition Expr
 	Body      Stmt
+	Increment Expr        // Run after every iter, even a continued one. nil for plain while loops
+	Label     token.Token // Name given with 'name: while', empty Lexeme if unlabelled
+}
+
+type ForIn struct {
+	Name     token.Token
+	Iterable Expr
+	Body     Stmt
+	Label    token.Token
+}
+
+type Break struct {
+	Keyword token.Token
+	Label   token.Token // Empty Lexeme targets the innermost loop
+}
+
+type Continue struct {
+	Keyword token.Token
+	Label   token.Token
+}
+
+type Try struct {
+	Attempt Stmt
+	Woops   Stmt
+	Name    token.Token // Empty Lexeme when the error isn't bound, woops { }
+}
+
+type Wert struct {
+	Keyword token.Token
+	Val     Expr
+}
+
+// a, b = b, a and a, b := f()
+// Targets are VarExprs or IndexExprs, Op is '=' or ':='
+type Destructure struct {
+	Targets []Expr
+	Vals    []Expr
+	Op      token.Token
+}
+
+// struct Profile { host, port }
+type Struct struct {
+	Name   token.Token
+	Fields []token.Token
 }
 
 type Fun struct {
 	Params      []token.Token
+	Defaults    []Expr      // One per param, nil when the param has no default
+	Rest        token.Token // ...rest collects extra args into a glist, empty Lexeme when there isn't one
 	Name        token.Token
 	Body        []Stmt
 	Environment EnvironmentHandler
@@ -63,9 +110,11 @@ func NewReturn(keyword token.Token, val Expr) Stmt {
 	}
 }
 
-func NewFun(name token.Token, params []token.Token, body []Stmt, env EnvironmentHandler) Stmt {
+func NewFun(name token.Token, params []token.Token, defaults []Expr, rest token.Token, body []Stmt, env EnvironmentHandler) Stmt {
 	return &Fun{
 		Params:      params,
+		Defaults:    defaults,
+		Rest:        rest,
 		Name:        name,
 		Body:        body,
 		Environment: env,
@@ -73,9 +122,68 @@ func NewFun(name token.Token, params []token.Token, body []Stmt, env Environment
 }
 
 func NewWhile(condition Expr, body Stmt) Stmt {
+	return NewLoop(token.Token{}, condition, body, nil)
+}
+
+func NewLoop(label token.Token, condition Expr, body Stmt, increment Expr) Stmt {
 	return &While{
 		Condition: condition,
 		Body:      body,
+		Increment: increment,
+		Label:     label,
+	}
+}
+
+func NewForIn(label token.Token, name token.Token, iterable Expr, body Stmt) Stmt {
+	return &ForIn{
+		Name:     name,
+		Iterable: iterable,
+		Body:     body,
+		Label:    label,
+	}
+}
+
+func NewTry(attempt Stmt, name token.Token, woops Stmt) Stmt {
+	return &Try{
+		Attempt: attempt,
+		Woops:   woops,
+		Name:    name,
+	}
+}
+
+func NewWert(keyword token.Token, val Expr) Stmt {
+	return &Wert{
+		Keyword: keyword,
+		Val:     val,
+	}
+}
+
+func NewDestructure(targets []Expr, op token.Token, vals []Expr) Stmt {
+	return &Destructure{
+		Targets: targets,
+		Vals:    vals,
+		Op:      op,
+	}
+}
+
+func NewStruct(name token.Token, fields []token.Token) Stmt {
+	return &Struct{
+		Name:   name,
+		Fields: fields,
+	}
+}
+
+func NewBreak(keyword token.Token, label token.Token) Stmt {
+	return &Break{
+		Keyword: keyword,
+		Label:   label,
+	}
+}
+
+func NewContinue(keyword token.Token, label token.Token) Stmt {
+	return &Continue{
+		Keyword: keyword,
+		Label:   label,
 	}
 }
 
@@ -93,9 +201,10 @@ func NewBlock(statements []Stmt) Stmt {
 	}
 }
 
-func NewPrint(expr Expr) Stmt {
+func NewPrint(keyword token.Token, expr Expr) Stmt {
 	return &Print{
-		Expr: expr,
+		Keyword: keyword,
+		Expr:    expr,
 	}
 }
 
@@ -156,6 +265,34 @@ func (e *Import) Accept(visitor StmtVisitor) error {
 	return visitor.VisitImportStmt(e)
 }
 
+func (e *ForIn) Accept(visitor StmtVisitor) error {
+	return visitor.VisitForInStmt(e)
+}
+
+func (e *Break) Accept(visitor StmtVisitor) error {
+	return visitor.VisitBreakStmt(e)
+}
+
+func (e *Continue) Accept(visitor StmtVisitor) error {
+	return visitor.VisitContinueStmt(e)
+}
+
+func (e *Try) Accept(visitor StmtVisitor) error {
+	return visitor.VisitTryStmt(e)
+}
+
+func (e *Wert) Accept(visitor StmtVisitor) error {
+	return visitor.VisitWertStmt(e)
+}
+
+func (e *Struct) Accept(visitor StmtVisitor) error {
+	return visitor.VisitStructStmt(e)
+}
+
+func (e *Destructure) Accept(visitor StmtVisitor) error {
+	return visitor.VisitDestructureStmt(e)
+}
+
 // String()
 func (e *Print) String() string {
 	return fmt.Sprintf("Print ~ Type: %s, Val: %s", e.Expr.GetType(), e.Expr.GetVal())
@@ -166,6 +303,9 @@ func (e *Expression) String() string {
 }
 
 func (e *Var) String() string {
+	if e.Initializer == nil { // var x with no value
+		return fmt.Sprintf("Var, %s", e.Name.Lexeme)
+	}
 	return fmt.Sprintf("%s, %s", e.Initializer.GetType(), e.Initializer.GetVal())
 }
 
@@ -193,3 +333,107 @@ func (e *Return) String() string {
 func (e *Import) String() string {
 	return ""
 }
+
+func (e *ForIn) String() string {
+	return fmt.Sprintf("ForIn ~ Name: %s, Iterable: %s", e.Name.Lexeme, e.Iterable.GetVal())
+}
+
+func (e *Break) String() string {
+	return fmt.Sprintf("Break ~ Label: %s", e.Label.Lexeme)
+}
+
+func (e *Continue) String() string {
+	return fmt.Sprintf("Continue ~ Label: %s", e.Label.Lexeme)
+}
+
+func (e *Try) String() string {
+	return fmt.Sprintf("Try ~ Attempt: %v, Woops %s: %v", e.Attempt, e.Name.Lexeme, e.Woops)
+}
+
+func (e *Wert) String() string {
+	return fmt.Sprintf("Wert ~ Val: %v", e.Val)
+}
+
+func (e *Struct) String() string {
+	fields := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		fields[i] = f.Lexeme
+	}
+	return fmt.Sprintf("Struct ~ Name: %s, Fields: %v", e.Name.Lexeme, fields)
+}
+
+func (e *Destructure) String() string {
+	return fmt.Sprintf("Destructure ~ Targets: %v %s Vals: %v", e.Targets, e.Op.Lexeme, e.Vals)
+}
+
+func (e *Expression) Span() token.Span {
+	return exprSpan(e.Expr)
+}
+
+func (e *Print) Span() token.Span {
+	return token.Join(e.Keyword.Span(), exprSpan(e.Expr))
+}
+
+func (e *Var) Span() token.Span {
+	return token.Join(e.Name.Span(), exprSpan(e.Initializer))
+}
+
+func (e *Block) Span() token.Span {
+	return stmtSpan(e.Statements...)
+}
+
+func (e *If) Span() token.Span {
+	return token.Join(exprSpan(e.Condition), stmtSpan(e.Then, e.Final))
+}
+
+func (e *While) Span() token.Span {
+	return token.Join(e.Label.Span(), exprSpan(e.Condition, e.Increment), stmtSpan(e.Body))
+}
+
+func (e *ForIn) Span() token.Span {
+	return token.Join(tokenSpan(e.Label, e.Name), exprSpan(e.Iterable), stmtSpan(e.Body))
+}
+
+func (e *Break) Span() token.Span {
+	return tokenSpan(e.Keyword, e.Label)
+}
+
+func (e *Continue) Span() token.Span {
+	return tokenSpan(e.Keyword, e.Label)
+}
+
+func (e *Try) Span() token.Span {
+	return token.Join(e.Name.Span(), stmtSpan(e.Attempt, e.Woops))
+}
+
+func (e *Wert) Span() token.Span {
+	return token.Join(e.Keyword.Span(), exprSpan(e.Val))
+}
+
+func (e *Destructure) Span() token.Span {
+	return token.Join(exprSpan(e.Targets...), exprSpan(e.Vals...), e.Op.Span())
+}
+
+func (e *Struct) Span() token.Span {
+	return token.Join(e.Name.Span(), tokenSpan(e.Fields...))
+}
+
+func (e *Fun) Span() token.Span {
+	return token.Join(tokenSpan(e.Name, e.Rest), tokenSpan(e.Params...), exprSpan(e.Defaults...), stmtSpan(e.Body...))
+}
+
+func (e *Return) Span() token.Span {
+	return token.Join(e.Keyword.Span(), exprSpan(e.Val))
+}
+
+func (e *Import) Span() token.Span {
+	span := e.Lang.Span()
+	for _, item := range e.Imports {
+		span = token.Join(span, item.Alias.Span(), item.Val.Span())
+	}
+	return span
+}
+
+func (e *Access) Span() token.Span {
+	return token.Join(e.Name.Span(), exprSpan(e.Expr))
+}
@@ -0,0 +1,108 @@
+package types
+
+import (
+	"fmt"
+	"hype-script/internal/token"
+	"strings"
+)
+
+type PatternKind int
+
+const (
+	PatternValue PatternKind = iota // Matches when equal to Value
+	PatternBind                     // Matches anything and binds it to Name
+	PatternWild                     // _ matches anything
+	PatternList                     // [a, "up", ...rest] matches a glist of that shape
+)
+
+type Pattern struct {
+	Kind  PatternKind
+	Value Expr
+	Name  token.Token
+	Items []*Pattern
+	Rest  *token.Token // ...rest at the end of a list pattern, nil if there isn't one
+}
+
+type SwitchCase struct {
+	Patterns []*Pattern // Empty for default
+	Body     []Stmt
+}
+
+// switch subject { case a, b: ... default: ... }
+// A missing subject switches on true, so each case is a condition
+type Switch struct {
+	Keyword token.Token
+	Subject Expr
+	Cases   []*SwitchCase
+	Default *SwitchCase
+}
+
+func NewSwitch(keyword token.Token, subject Expr, cases []*SwitchCase, def *SwitchCase) Stmt {
+	return &Switch{
+		Keyword: keyword,
+		Subject: subject,
+		Cases:   cases,
+		Default: def,
+	}
+}
+
+func (s *Switch) Accept(visitor StmtVisitor) error {
+	return visitor.VisitSwitchStmt(s)
+}
+
+func (s *Switch) String() string {
+	cases := make([]string, len(s.Cases))
+	for i, c := range s.Cases {
+		patterns := make([]string, len(c.Patterns))
+		for j, p := range c.Patterns {
+			patterns[j] = p.String()
+		}
+		cases[i] = strings.Join(patterns, ", ")
+	}
+	return fmt.Sprintf("Switch ~ Subject: %v, Cases: [%s], Default: %t", s.Subject, strings.Join(cases, " | "), s.Default != nil)
+}
+
+func (p *Pattern) String() string {
+	switch p.Kind {
+	case PatternBind:
+		return p.Name.Lexeme
+	case PatternWild:
+		return "_"
+	case PatternList:
+		items := make([]string, len(p.Items))
+		for i, item := range p.Items {
+			items[i] = item.String()
+		}
+		if p.Rest != nil {
+			items = append(items, "..."+p.Rest.Lexeme)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	}
+	return p.Value.GetVal()
+}
+
+func (s *Switch) Span() token.Span {
+	span := token.Join(s.Keyword.Span(), exprSpan(s.Subject))
+	cases := s.Cases
+	if s.Default != nil {
+		cases = append(cases[:len(cases):len(cases)], s.Default)
+	}
+	for _, c := range cases {
+		for _, p := range c.Patterns {
+			span = token.Join(span, p.Span())
+		}
+		span = token.Join(span, stmtSpan(c.Body...))
+	}
+	return span
+}
+
+func (p *Pattern) Span() token.Span {
+	span := token.Join(exprSpan(p.Value), p.Name.Span())
+	for _, item := range p.Items {
+		span = token.Join(span, item.Span())
+	}
+	if p.Rest != nil {
+		span = token.Join(span, p.Rest.Span())
+	}
+	return span
+}
@@ -0,0 +1,37 @@
+package types
+
+import (
+	"fmt"
+	"hype-script/internal/token"
+)
+
+// return a, b
+type TupleExpr struct {
+	Type  string
+	Token token.Token
+	Items []Expr
+}
+
+func NewTupleExpr(items []Expr, token token.Token) Expr {
+	return &TupleExpr{
+		Type:  "TupleExpr",
+		Token: token,
+		Items: items,
+	}
+}
+
+func (t *TupleExpr) Accept(visitor Visitor) (any, error) {
+	return visitor.VisitTupleExpr(t)
+}
+
+func (t *TupleExpr) GetType() string {
+	return t.Type
+}
+
+func (t *TupleExpr) GetVal() string {
+	return fmt.Sprintf("%d values", len(t.Items))
+}
+
+func (t *TupleExpr) Span() token.Span {
+	return token.Join(t.Token.Span(), exprSpan(t.Items...))
+}
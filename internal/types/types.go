@@ -1,5 +1,7 @@
 package types
 
+import "hype-script/internal/token"
+
 type EnvironmentHandler interface {
 	Get(name string) (any, error)
 	Define(name string, val any)
@@ -10,6 +12,7 @@ type EnvironmentHandler interface {
 type Stmt interface {
 	Accept(visitor StmtVisitor) error
 	String() string
+	Span() token.Span // Source the statement was parsed from
 }
 
 type StmtVisitor interface {
@@ -23,6 +26,14 @@ type StmtVisitor interface {
 	VisitReturnStmt(stmt *Return) error
 	VisitImportStmt(stmt *Import) error
 	VisitAccessStmt(stmt *Access) error
+	VisitForInStmt(stmt *ForIn) error
+	VisitBreakStmt(stmt *Break) error
+	VisitContinueStmt(stmt *Continue) error
+	VisitTryStmt(stmt *Try) error
+	VisitWertStmt(stmt *Wert) error
+	VisitDestructureStmt(stmt *Destructure) error
+	VisitSwitchStmt(stmt *Switch) error
+	VisitStructStmt(stmt *Struct) error
 }
 
 type Visitor interface {
@@ -43,10 +54,17 @@ type Visitor interface {
 	VisitIndexExpr(expr *IndexExpr) (any, error)
 	VisitImportExpr(expr *ImportExpr) (any, error)
 	VisitAccessExpr(expr *AccessExpr) (any, error)
+	VisitGmapExpr(expr *GmapExpr) (any, error)
+	VisitIndexAssignExpr(expr *IndexAssignExpr) (any, error)
+	VisitSliceExpr(expr *SliceExpr) (any, error)
+	VisitInterpolationExpr(expr *InterpolationExpr) (any, error)
+	VisitTupleExpr(expr *TupleExpr) (any, error)
+	VisitSpreadExpr(expr *SpreadExpr) (any, error)
 }
 
 type Expr interface {
 	Accept(visitor Visitor) (any, error)
 	GetType() string // Gets simple type of Expressin
 	GetVal() string  // Gets value of Expression, loose and for debugging. Not cononical or recursive
+	Span() token.Span
 }
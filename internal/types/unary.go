@@ -30,3 +30,7 @@ func (v *UnaryExpr) GetType() string {
 func (v *UnaryExpr) GetVal() string {
 	return fmt.Sprintf("%s, %s", v.Operator.String(), v.Right.GetVal())
 }
+
+func (u *UnaryExpr) Span() token.Span {
+	return token.Join(u.Operator.Span(), exprSpan(u.Right))
+}
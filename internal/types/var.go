@@ -26,4 +26,8 @@ func (v *VarExpr) GetType() string {
 
 func (v *VarExpr) GetVal() string {
 	return v.Name.String()
-}
\ No newline at end of file
+}
+
+func (v *VarExpr) Span() token.Span {
+	return v.Name.Span()
+}
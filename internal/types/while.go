@@ -1,6 +1,9 @@
 package types
 
-import "fmt"
+import (
+	"fmt"
+	"hype-script/internal/token"
+)
 
 type WhileExpr struct {
 	Type      string
@@ -27,3 +30,7 @@ func (v *WhileExpr) GetType() string {
 func (v *WhileExpr) GetVal() string {
 	return fmt.Sprintf("%s, %s", v.Condition.GetVal(), v.Body.String())
 }
+
+func (w *WhileExpr) Span() token.Span {
+	return token.Join(exprSpan(w.Condition), stmtSpan(w.Body))
+}
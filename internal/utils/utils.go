@@ -2,14 +2,18 @@ package utils
 
 import (
 	"fmt"
+	"hype-script/internal/native"
 	"hype-script/internal/types"
+	"math"
+	"strconv"
 	"strings"
 )
 
-// If a and b can be converted to ints, return converted and true
+// If a and b are both numbers, return them as floats and true
+// Ints are promoted, so this is the path for any arithmetic with a float in it
 func ConvFloat(a, b any) (float64, float64, bool) {
-	left, lok := a.(float64)
-	right, rok := b.(float64)
+	left, lok := IsFloat(a)
+	right, rok := IsFloat(b)
 
 	if lok && rok {
 		return left, right, true
@@ -18,6 +22,21 @@ func ConvFloat(a, b any) (float64, float64, bool) {
 	}
 }
 
+// If a and b are both ints, return them and true
+func ConvInt(a, b any) (int64, int64, bool) {
+	left, lok := a.(int64)
+	right, rok := b.(int64)
+	return left, right, lok && rok
+}
+
+func IsNumber(val any) bool {
+	switch val.(type) {
+	case int64, float64:
+		return true
+	}
+	return false
+}
+
 func Parenthesize(visitor types.Visitor, name string, exprs ...types.Expr) (string, error) {
 	var builder strings.Builder
 
@@ -37,17 +56,108 @@ func Parenthesize(visitor types.Visitor, name string, exprs ...types.Expr) (stri
 	return builder.String(), nil
 }
 
+// Any number as a float, ints are promoted
 func IsFloat(val any) (float64, bool) {
-	ival, ok := val.(float64)
-	if !ok {
-		return 0, false
+	switch v := val.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// An int, or a float with nothing after the point, as an int64
+func IsWhole(val any) (int64, bool) {
+	switch v := val.(type) {
+	case int64:
+		return v, true
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<63 {
+			return int64(v), true
+		}
 	}
-	return ival, true
+	return 0, false
+}
+
+// Floats always show a '.', so 3.0 and 3 print differently
+func FormatFloat(f float64) string {
+	str := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(str, ".eEIN") { // Not 1e+21, Inf or NaN
+		str += ".0"
+	}
+	return str
 }
 
 func Stringify(val any) string {
+	return stringify(val, map[any]bool{})
+}
+
+// open holds the containers being printed further up, one that holds itself prints as [...] or {...} instead of forever
+func stringify(val any, open map[any]bool) string {
 	if val == nil {
 		return "nil"
 	}
+	switch v := val.(type) {
+	case *native.Gmap, *native.Glist, *native.StructVal:
+		if open[v] {
+			return cycle(v)
+		}
+		open[v] = true
+		defer delete(open, v)
+	}
+	switch v := val.(type) {
+	case float64:
+		return FormatFloat(v)
+	case *native.Gmap:
+		var builder strings.Builder
+		builder.WriteString("{")
+		for i, k := range v.Keys {
+			if i > 0 {
+				builder.WriteString(", ")
+			}
+			builder.WriteString(stringifyItem(k, open))
+			builder.WriteString(": ")
+			builder.WriteString(stringifyItem(v.Vals[k], open))
+		}
+		builder.WriteString("}")
+		return builder.String()
+	case *native.Glist:
+		items := make([]string, len(v.Items))
+		for i, item := range v.Items {
+			items[i] = stringifyItem(item, open)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case *native.StructVal:
+		fields := make([]string, len(v.Type.Fields))
+		for i, field := range v.Type.Fields {
+			fields[i] = field + ": " + stringifyItem(v.Vals[field], open)
+		}
+		return v.Type.Name + "{" + strings.Join(fields, ", ") + "}"
+	case *native.Tuple:
+		items := make([]string, len(v.Items))
+		for i, item := range v.Items {
+			items[i] = stringifyItem(item, open)
+		}
+		return strings.Join(items, ", ")
+	}
 	return fmt.Sprintf("%v", val)
 }
+
+func cycle(val any) string {
+	switch v := val.(type) {
+	case *native.Glist:
+		return "[...]"
+	case *native.StructVal:
+		return v.Type.Name + "{...}"
+	}
+	return "{...}"
+}
+
+// Strings nested in a container are quoted so {"a": "1"} and {"a": 1} print differently
+func stringifyItem(val any, open map[any]bool) string {
+	if s, ok := val.(string); ok {
+		return strconv.Quote(s)
+	}
+	return stringify(val, open)
+}
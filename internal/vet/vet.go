@@ -0,0 +1,268 @@
+package vet
+
+import (
+	"fmt"
+	"hype-script/internal/diag"
+	"hype-script/internal/environment"
+	"hype-script/internal/native"
+	"hype-script/internal/parser"
+	"hype-script/internal/resolver"
+	"hype-script/internal/scanner"
+	"hype-script/internal/token"
+	"hype-script/internal/types"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Finds code that runs but probably doesn't do what was meant
+// Each check has an ID that doubles as its diagnostics' code, // hype:ignore ID on the line or the one above silences it
+
+const (
+	UnusedVar        = "unused-var"
+	UnusedImport     = "unused-import"
+	Shadow           = "shadow"
+	Unreachable      = "unreachable"
+	UndeclaredAssign = "undeclared-assign"
+	Arity            = "arity"
+	ConstantIf       = "constant-if"
+)
+
+const ignoreDirective = "hype:ignore"
+
+type vetter struct {
+	found diag.Diagnostics
+}
+
+// Vets src and returns what it found, sorted by position and warnings only
+// A script that doesn't scan or parse can't be vetted, the error is its diagnostics
+func Source(file, src string) (*diag.Diagnostics, error) {
+	sc := scanner.NewScanner()
+	sc.SetFile(file)
+	tokens, err := sc.ScanTokens(src)
+	if err != nil {
+		return nil, err
+	}
+	stmts, err := parser.NewParser(environment.NewEnvironment(nil)).ParseTokens(tokens)
+	if err != nil {
+		return nil, err
+	}
+	r := resolver.NewResolver()
+	r.Resolve(stmts)
+
+	v := &vetter{}
+	v.symbols(r)
+	v.refs(r)
+	v.calls(r)
+	v.block(stmts)
+
+	ignored := ignores(src, sc.GetComments())
+	found := &diag.Diagnostics{}
+	for _, d := range v.found.List {
+		if !ignored[d.Span.Start.Line][d.Code] {
+			found.List = append(found.List, d)
+		}
+	}
+	sort.SliceStable(found.List, func(i, j int) bool {
+		return found.List[i].Span.Start.Offset < found.List[j].Span.Start.Offset
+	})
+	return found, nil
+}
+
+func (v *vetter) report(id string, span token.Span, format string, args ...any) *diag.Diagnostic {
+	return v.found.Add(diag.Warning, id, fmt.Sprintf(format, args...), span)
+}
+
+// The IDs ignored on each line, a comment covers its own line and the one after so it can sit above or beside
+func ignores(src string, comments []token.Token) map[int]map[string]bool {
+	ignored := map[int]map[string]bool{}
+	for _, c := range comments {
+		text := strings.TrimSpace(strings.TrimPrefix(src[c.Offset:c.End.Offset], "//"))
+		rest, ok := strings.CutPrefix(text, ignoreDirective)
+		if !ok {
+			continue
+		}
+		// hype:ignore shadow,unused-var anything after the IDs is a reason for the reader
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			continue
+		}
+		for _, line := range []int{c.Line, c.Line + 1} {
+			if ignored[line] == nil {
+				ignored[line] = map[string]bool{}
+			}
+			for _, id := range strings.Split(fields[0], ",") {
+				ignored[line][id] = true
+			}
+		}
+	}
+	return ignored
+}
+
+// Names starting with _ are there on purpose, for x in xs with x unused is fine as _ in xs
+func (v *vetter) symbols(r *resolver.Resolver) {
+	for _, sym := range r.Symbols {
+		if strings.HasPrefix(sym.Name, "_") {
+			continue
+		}
+		switch {
+		case sym.Kind == resolver.Package && sym.Uses == 0:
+			v.report(UnusedImport, sym.Decl.Span(), "\"%s\" is imported but never used.", sym.Path)
+		// Globals can be used by whatever loads the script, only locals are surely unused
+		case sym.Kind == resolver.Variable && sym.Uses == 0 && !slices.Contains(r.Global.Order, sym):
+			v.report(UnusedVar, sym.Decl.Span(), "'%s' is declared but never used.", sym.Name)
+		}
+		// A param named like a global is how most functions are written, don't nag about it
+		if sym.Shadows != nil && sym.Kind != resolver.Param {
+			d := v.report(Shadow, sym.Decl.Span(), "'%s' shadows the '%s' declared on line %d.", sym.Name, sym.Name, sym.Shadows.Decl.Line)
+			d.Notes = append(d.Notes, diag.Note{Message: fmt.Sprintf("'%s' is declared at %s", sym.Name, sym.Shadows.Decl.Span()), Span: sym.Shadows.Decl.Span()})
+		}
+	}
+}
+
+func (v *vetter) refs(r *resolver.Resolver) {
+	for _, ref := range r.Refs {
+		if ref.Assign && ref.Symbol == nil {
+			v.report(UndeclaredAssign, ref.Name.Span(), "Assignment to undeclared '%s', declare it with := or var first.", ref.Name.Lexeme)
+		}
+	}
+}
+
+// Checks calls of functions, builtins and struct types the same way BindArgs would when they run
+func (v *vetter) calls(r *resolver.Resolver) {
+	for _, call := range r.Calls {
+		if call.Callee == nil {
+			continue
+		}
+		fun := callable(call.Callee)
+		if fun == nil {
+			continue
+		}
+		args, named := call.Expr.Args, call.Expr.Named
+		// How many args a spread gives isn't known until it runs
+		if slices.ContainsFunc(args, func(arg types.Expr) bool { _, ok := arg.(*types.SpreadExpr); return ok }) {
+			continue
+		}
+		span := call.Expr.Span()
+		signed, ok := fun.(native.Signed)
+		if !ok {
+			if fun.Arity() >= 0 && len(args) != fun.Arity() {
+				v.report(Arity, span, "%s expects %d args but got %d.", call.Callee.Name, fun.Arity(), len(args))
+			}
+			continue
+		}
+
+		sig := signed.Signature()
+		if sig.Rest == "" && len(args) > len(sig.Params) {
+			v.report(Arity, span, "%s expects %s args but got %d.", call.Callee.Name, sig.Expects(), len(args))
+			continue
+		}
+		given := make([]bool, len(sig.Params))
+		for i := range args {
+			if i < len(given) {
+				given[i] = true
+			}
+		}
+		for _, arg := range named {
+			if idx := slices.Index(sig.Params, arg.Name.Lexeme); idx >= 0 {
+				given[idx] = true
+			}
+		}
+		for i := 0; i < sig.Required; i++ {
+			if !given[i] {
+				v.report(Arity, span, "%s is missing arg '%s', it expects %s args.", call.Callee.Name, sig.Params[i], sig.Expects())
+				break
+			}
+		}
+	}
+}
+
+// What sym would be at runtime when it's something that can be called, nil otherwise
+func callable(sym *resolver.Symbol) native.Callable {
+	switch sym.Kind {
+	case resolver.Function:
+		return native.NewGlorpFunction(*sym.Fun, nil) // Only its signature is wanted, it never runs
+	case resolver.StructType:
+		fields := make([]string, len(sym.Struct.Fields))
+		for i, field := range sym.Struct.Fields {
+			fields[i] = field.Lexeme
+		}
+		return native.NewStructType(sym.Name, fields)
+	case resolver.Builtin:
+		return native.Builtins()[sym.Name]
+	}
+	return nil
+}
+
+// Anything after a statement that always leaves the block can never run, only the first such statement is reported
+func (v *vetter) block(stmts []types.Stmt) {
+	reported := false
+	for i, stmt := range stmts {
+		v.stmt(stmt)
+		if keyword := leaves(stmt); keyword != "" && i+1 < len(stmts) && !reported {
+			v.report(Unreachable, stmts[i+1].Span(), "Unreachable code after %s.", keyword)
+			reported = true
+		}
+	}
+}
+
+func (v *vetter) stmt(stmt types.Stmt) {
+	switch s := stmt.(type) {
+	case *types.Block:
+		v.block(s.Statements)
+	case *types.If:
+		if constant(s.Condition) {
+			v.report(ConstantIf, s.Condition.Span(), "Condition is constant, the if always goes the same way.")
+		}
+		v.stmt(s.Then)
+		v.stmt(s.Final)
+	case *types.While:
+		v.stmt(s.Body)
+	case *types.ForIn:
+		v.stmt(s.Body)
+	case *types.Fun:
+		v.block(s.Body)
+	case *types.Try:
+		v.stmt(s.Attempt)
+		v.stmt(s.Woops)
+	case *types.Switch:
+		for _, c := range s.Cases {
+			v.block(c.Body)
+		}
+		if s.Default != nil {
+			v.block(s.Default.Body)
+		}
+	}
+}
+
+// The keyword of a statement that never falls through to the next one, "" for every other statement
+func leaves(stmt types.Stmt) string {
+	switch s := stmt.(type) {
+	case *types.Return:
+		return s.Keyword.Lexeme
+	case *types.Break:
+		return s.Keyword.Lexeme
+	case *types.Continue:
+		return s.Keyword.Lexeme
+	case *types.Wert:
+		return s.Keyword.Lexeme
+	}
+	return ""
+}
+
+// Built only from literals, so it's the same every time it runs
+func constant(expr types.Expr) bool {
+	switch e := expr.(type) {
+	case *types.LiteralExpr:
+		return true
+	case *types.GroupingExpr:
+		return constant(e.Expr)
+	case *types.UnaryExpr:
+		return constant(e.Right)
+	case *types.BinaryExpr:
+		return constant(e.Left) && constant(e.Right)
+	case *types.LogicalExpr:
+		return constant(e.Left) && constant(e.Right)
+	}
+	return false
+}
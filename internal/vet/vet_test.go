@@ -0,0 +1,109 @@
+package vet
+
+import (
+	"fmt"
+	"hype-script/internal/environment"
+	"hype-script/internal/interpreter"
+	"hype-script/internal/parser"
+	"hype-script/internal/scanner"
+	"slices"
+	"testing"
+)
+
+// Each finding as "line:id", in the order vet reports them
+func findings(t *testing.T, src string) []string {
+	found, err := Source("vet.hyp", src)
+	if err != nil {
+		t.Fatalf("vet: %v", err)
+	}
+	var got []string
+	for _, d := range found.List {
+		got = append(got, fmt.Sprintf("%d:%s", d.Span.Start.Line, d.Code))
+	}
+	return got
+}
+
+func TestChecks(t *testing.T) {
+	cases := []struct {
+		name string
+		src  string
+		want []string
+	}{
+		{"unused import", "import go (\"strings\")\n", []string{"1:" + UnusedImport}},
+		{"used import", "import go (\"strings\")\nprint strings.ToUpper(\"a\")\n", nil},
+		{"unused local", "func f() {\n    x := 1\n    _skip := 2\n}\n", []string{"2:" + UnusedVar}},
+		{"assigned but never read", "{\n    var x = 1\n    x = 2\n}\n", []string{"2:" + UnusedVar}},
+		{"unused global", "var x = 1\n", nil},
+		{"shadow", "var host = 1\n{\n    var host = 2\n    print host\n}\n", []string{"3:" + Shadow}},
+		{"param named like a global", "var host = 1\nfunc f(host) {\n    print host\n}\n", nil},
+		{"unreachable", "func f() {\n    return 1\n    print 2\n    print 3\n}\n", []string{"3:" + Unreachable}},
+		{"unreachable in a loop", "for x in [1] {\n    print x\n    break\n    print 2\n}\n", []string{"4:" + Unreachable}},
+		{"undeclared assign", "x = 1\n", []string{"1:" + UndeclaredAssign}},
+		{"undeclared destructure", "var a = 1\na, b = 1, 2\n", []string{"2:" + UndeclaredAssign}},
+		{"too many args", "func f(a) {\n    print a\n}\nf(1, 2)\n", []string{"4:" + Arity}},
+		{"missing arg", "func f(a, b = 1) {\n    print a + b\n}\nf(b=2)\nf(1)\n", []string{"4:" + Arity}},
+		{"rest takes the extras", "func f(a, ...more) {\n    print a + len(more)\n}\nf(1, 2, 3)\n", nil},
+		{"spread isn't counted", "func f(a) {\n    print a\n}\nf(...[1, 2])\n", nil},
+		{"builtin", "print len(\"a\", \"b\")\n", []string{"1:" + Arity}},
+		{"struct", "struct P { a, b }\nprint P(1)\n", []string{"2:" + Arity}},
+		{"constant if", "if true {\n    print 1\n} else if 1 > 2 and !false {\n    print 2\n}\n", []string{"1:" + ConstantIf, "3:" + ConstantIf}},
+		{"if on a var", "var on = true\nif on {\n    print 1\n}\n", nil},
+	}
+	for _, c := range cases {
+		if got := findings(t, c.src); !slices.Equal(got, c.want) {
+			t.Errorf("%s: expected %v, got %v", c.name, c.want, got)
+		}
+	}
+}
+
+func TestIgnore(t *testing.T) {
+	src := `x = 1 // hype:ignore undeclared-assign
+// hype:ignore undeclared-assign,constant-if because it's a demo
+y = 2
+if true { print 1 } // hype:ignore shadow
+z = 3 // hype:ignore
+`
+	want := []string{"4:" + ConstantIf, "5:" + UndeclaredAssign}
+	if got := findings(t, src); !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+// Functions close over where they were declared, not where they were called from, vet has to agree with what actually runs
+func TestNestedFunctions(t *testing.T) {
+	cases := []struct {
+		name string
+		src  string
+		want []string
+		runs bool
+	}{
+		{"reads globals and params", "var base = 1\nfunc outer() {\n    func inner(n) {\n        return n + base\n    }\n    return inner(2)\n}\nprint outer()\n", nil, true},
+		{"reads a local of outer", "func outer() {\n    var x = 5\n    func inner() {\n        return x\n    }\n    return inner()\n}\nprint outer()\n", nil, true},
+		{"assigns a local of outer", "func outer() {\n    var n = 0\n    func bump() {\n        n = 1\n    }\n    bump()\n    return n\n}\nprint outer()\n", nil, true},
+		{"recurses locally", "func outer() {\n    func fact(n) {\n        if n <= 1 {\n            return 1\n        }\n        return n * fact(n - 1)\n    }\n    return fact(4)\n}\nprint outer()\n", nil, true},
+		{"reads a local of its caller", "func read() {\n    return local\n}\nfunc caller() {\n    var local = 1\n    return read()\n}\nprint caller()\n", []string{"5:" + UnusedVar}, false},
+	}
+	for _, c := range cases {
+		if got := findings(t, c.src); !slices.Equal(got, c.want) {
+			t.Errorf("%s: expected %v, got %v", c.name, c.want, got)
+		}
+		tokens, err := scanner.NewScanner().ScanTokens(c.src)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		env := environment.NewEnvironment(nil)
+		stmts, err := parser.NewParser(env).ParseTokens(tokens)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if err := interpreter.NewInterpreter(env).InterpretStmts(stmts); (err == nil) != c.runs {
+			t.Errorf("%s: expected runs %t, got %v", c.name, c.runs, err)
+		}
+	}
+}
+
+func TestSyntaxError(t *testing.T) {
+	if _, err := Source("vet.hyp", "var = 1\n"); err == nil {
+		t.Errorf("expected a script that doesn't parse to fail")
+	}
+}